@@ -1,3 +1,13 @@
+// Command codegen generates client models from the OpenAPI schema, or from the registry when no
+// -input is given. Each -gen lang:dir flag selects a target:
+//
+//	py     Pydantic v2 models, with fields defaulting to the values each model's Defaulted sets,
+//	       leaving out time-based and generated ones
+//	ts     TypeScript interfaces
+//	proto  tabularium.proto and tabularium.lock.json, which pins the number of every field
+//
+// In the Python and TypeScript models, wrapper fields become discriminated unions, enums become
+// literal types, and desc tags become doc comments.
 package main
 
 import (
@@ -5,9 +15,11 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/praetorian-inc/tabularium/pkg/schema"
+	"gopkg.in/yaml.v2"
 )
 
 // GeneratorFunc defines the signature for functions that generate code for a specific language.
@@ -15,7 +27,9 @@ type GeneratorFunc func(inputFile, outputDir string) error
 
 // generatorRegistry maps language identifiers (e.g., "py") to their corresponding GeneratorFunc.
 var generatorRegistry = map[string]GeneratorFunc{
-	"py": generatePythonPydantic,
+	"py":    generatePythonPydantic,
+	"ts":    generateTypeScript,
+	"proto": generateProtobuf,
 }
 
 // generationTarget stores the language and output directory for a single generation request.
@@ -55,61 +69,75 @@ func main() {
 	var inputFile string
 	var targets generationTargets
 
-	flag.StringVar(&inputFile, "input", "", "Path to the input OpenAPI schema file (required)")
+	flag.StringVar(&inputFile, "input", "", "Path to the input OpenAPI schema file (if not specified, the schema is generated from the registry)")
 	flag.Var(&targets, "gen", "Generation target in the format lang:output_dir (can be specified multiple times)")
 	flag.Parse()
 
-	if inputFile == "" {
-		log.Fatal("-input flag is required")
-	}
 	if len(targets) == 0 {
 		log.Fatal("-gen flag must be specified at least once")
 	}
+	if err := run(inputFile, targets); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run generates each target from inputFile, or from the registry when inputFile is empty
+func run(inputFile string, targets generationTargets) error {
+	if inputFile == "" {
+		generated, err := writeRegistrySchema()
+		if err != nil {
+			return fmt.Errorf("error generating schema from the registry: %w", err)
+		}
+		defer os.Remove(generated)
+		inputFile = generated
+	}
 
 	absInputFile, err := filepath.Abs(inputFile)
 	if err != nil {
-		log.Fatalf("Error getting absolute path for input file %s: %v", inputFile, err)
+		return fmt.Errorf("error getting absolute path for input file %s: %w", inputFile, err)
 	}
 
 	for _, target := range targets {
 		generatorFunc := generatorRegistry[target.Lang]
 		absOutputDir, err := filepath.Abs(target.OutputDir)
 		if err != nil {
-			log.Fatalf("Error getting absolute path for output directory %s: %v", target.OutputDir, err)
+			return fmt.Errorf("error getting absolute path for output directory %s: %w", target.OutputDir, err)
 		}
 
 		fmt.Printf("Generating %s code from %s to %s...\n", target.Lang, absInputFile, absOutputDir)
 
 		if err := os.MkdirAll(absOutputDir, 0755); err != nil {
-			log.Fatalf("Error creating output directory %s: %v", absOutputDir, err)
+			return fmt.Errorf("error creating output directory %s: %w", absOutputDir, err)
 		}
 
 		if err := generatorFunc(absInputFile, absOutputDir); err != nil {
-			log.Fatalf("Error generating %s code: %v", target.Lang, err)
+			return fmt.Errorf("error generating %s code: %w", target.Lang, err)
 		}
 		fmt.Printf("Successfully generated %s code in %s\n", target.Lang, absOutputDir)
 	}
+	return nil
 }
 
-func generatePythonPydantic(inputFile, outputDir string) error {
-	// Assumes datamodel-codegen is installed and in PATH.
-	outputFile := filepath.Join(outputDir, "models.py")
-	cmd := exec.Command("datamodel-codegen",
-		"--input", inputFile,
-		"--input-file-type", "openapi",
-		"--output", outputFile,
-		"--output-model-type", "pydantic_v2.BaseModel",
-		"--use-annotated",
-		"--target-python-version", "3.11",
-	)
-
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	fmt.Printf("Running command: %s\n", cmd.String())
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("datamodel-codegen failed: %w", err)
+// writeRegistrySchema writes the OpenAPI schema of the registered models to a temporary file,
+// so that generators read the registry the same way they read client/api.yaml.
+func writeRegistrySchema() (string, error) {
+	doc, err := schema.GenerateOpenAPISchema()
+	if err != nil {
+		return "", err
 	}
-	return nil
+	bytes, err := yaml.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "api-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := file.Write(bytes); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
 }
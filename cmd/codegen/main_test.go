@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_RemovesGeneratedSchema(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	out := t.TempDir()
+
+	require.NoError(t, run("", generationTargets{{Lang: "ts", OutputDir: out}}))
+	leftover, err := filepath.Glob(filepath.Join(tmp, "api-*.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, leftover)
+
+	// a file where the output directory should be makes generation fail
+	blocked := filepath.Join(out, "blocked")
+	require.NoError(t, os.WriteFile(blocked, nil, 0644))
+	assert.Error(t, run("", generationTargets{{Lang: "ts", OutputDir: blocked}}))
+	leftover, err = filepath.Glob(filepath.Join(tmp, "api-*.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, leftover)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/praetorian-inc/tabularium/pkg/protobuf"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// generateProtobuf emits a .proto for the registered models, reading them from the registry
+// rather than inputFile. Field numbers are pinned by the lockfile in outputDir, which is updated
+// with the numbers of any new fields.
+func generateProtobuf(_ string, outputDir string) error {
+	lockFile := filepath.Join(outputDir, protobuf.LockFile)
+	lock, err := protobuf.LoadLock(lockFile)
+	if err != nil {
+		return err
+	}
+
+	s := protobuf.NewSchema(registry.Registry, lock)
+	protoFile := filepath.Join(outputDir, protobuf.ProtoFile)
+	if err := os.WriteFile(protoFile, []byte(s.Proto()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", protoFile, err)
+	}
+	return s.Lock().Save(lockFile)
+}
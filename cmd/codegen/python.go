@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/praetorian-inc/tabularium/pkg/schema"
+)
+
+// pyIdentifier matches property names that are valid Python attribute names
+var pyIdentifier = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+var pyKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true,
+	"await": true, "break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true,
+	"else": true, "except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+func generatePythonPydantic(inputFile, outputDir string) error {
+	doc, err := schema.LoadOpenAPISchema(inputFile)
+	if err != nil {
+		return err
+	}
+
+	outputFile := filepath.Join(outputDir, "models.py")
+	if err := os.WriteFile(outputFile, []byte(renderPython(doc, registryDefaults())), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputFile, err)
+	}
+	return nil
+}
+
+// generatedDefaults lists, by model name, the properties that Defaulted generates rather than sets
+// to a fixed value, such as timestamps, expiry times and IDs. They are given no default. The
+// properties listed under "*" are generated for every model.
+var generatedDefaults = map[string][]string{
+	"*":       {"created", "updated", "visited", "ttl"},
+	"job":     {"status"}, // the queued status carries the time it was queued
+	"key":     {"id"},
+	"message": {"messageId", "timestamp"},
+	"risk":    {"guid"},
+}
+
+// registryDefaults returns, by model name, the JSON values that each registered model's Defaulted
+// sets, leaving out generatedDefaults
+func registryDefaults() map[string]map[string]any {
+	defaults := defaultedValues()
+	for name, values := range defaults {
+		for _, field := range generatedDefaults["*"] {
+			delete(values, field)
+		}
+		for _, field := range generatedDefaults[name] {
+			delete(values, field)
+		}
+	}
+	return defaults
+}
+
+func defaultedValues() map[string]map[string]any {
+	out := make(map[string]map[string]any)
+	for name := range registry.Registry.GetAllTypes() {
+		zero, _ := registry.Registry.MakeType(name)
+		defaulted, ok := makeDefaulted(name)
+		if !ok {
+			continue
+		}
+
+		zeroValues, defaultedValues := jsonValues(zero), jsonValues(defaulted)
+		values := make(map[string]any)
+		for field, value := range defaultedValues {
+			if !reflect.DeepEqual(value, zeroValues[field]) {
+				values[field] = value
+			}
+		}
+		out[name] = values
+	}
+	return out
+}
+
+// makeDefaulted returns a defaulted instance of a registered model. Models whose zero value
+// cannot be defaulted, such as those embedding a nil pointer, report false.
+func makeDefaulted(name string) (m registry.Model, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	m, ok = registry.Registry.MakeType(name)
+	if ok {
+		m.Defaulted()
+	}
+	return m, ok
+}
+
+func jsonValues(m registry.Model) map[string]any {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var values map[string]any
+	if err := decoder.Decode(&values); err != nil {
+		return nil
+	}
+	return values
+}
+
+// renderPython emits a Pydantic v2 model per component schema. Wrapper fields become unions of the
+// <model>_wrapper models discriminated by their type, enums become Literal types, and fields take
+// their defaults from defaults, keyed by component name.
+func renderPython(doc *openapi3.T, defaults map[string]map[string]any) string {
+	r := &pyRenderer{}
+	r.b.WriteString(`# Code generated by cmd/codegen. DO NOT EDIT.
+
+from __future__ import annotations
+
+from typing import Annotated, Any, Literal, Union
+
+from pydantic import BaseModel, ConfigDict, Field
+`)
+
+	schemas := doc.Components.Schemas
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s := schemas[name].Value
+		if s == nil {
+			continue
+		}
+		if s.Type.Is(openapi3.TypeObject) && s.AdditionalProperties.Schema == nil {
+			r.class(typeName(name), s, defaults[name])
+			continue
+		}
+		fmt.Fprintf(&r.b, "\n\n%s = %s\n", typeName(name), r.annotation(typeName(name), schemas[name]))
+	}
+
+	// Inline objects are emitted after the models that use them; annotations are resolved lazily
+	for len(r.pending) > 0 {
+		next := r.pending[0]
+		r.pending = r.pending[1:]
+		r.class(next.name, next.schema, nil)
+	}
+	return r.b.String()
+}
+
+type pyRenderer struct {
+	b       strings.Builder
+	pending []pyClass
+}
+
+type pyClass struct {
+	name   string
+	schema *openapi3.Schema
+}
+
+func (r *pyRenderer) class(name string, s *openapi3.Schema, defaults map[string]any) {
+	required := make(map[string]bool, len(s.Required))
+	for _, property := range s.Required {
+		required[property] = true
+	}
+
+	properties := make([]string, 0, len(s.Properties))
+	for property := range s.Properties {
+		properties = append(properties, property)
+	}
+	sort.Strings(properties)
+
+	taken := make(map[string]bool, len(properties))
+	for _, property := range properties {
+		taken[property] = true
+	}
+
+	var fields []string
+	aliased := false
+	for _, property := range properties {
+		ref := s.Properties[property]
+		annotation := r.annotation(name+typeName(property), ref)
+
+		var args []string
+		attribute := property
+		if !pyIdentifier.MatchString(property) || pyKeywords[property] {
+			attribute = pyAttributeName(property, taken)
+			args = append(args, "alias="+pyLiteral(property))
+			aliased = true
+		}
+		if ref.Ref == "" && ref.Value != nil {
+			if ref.Value.Discriminator != nil {
+				args = append(args, "discriminator="+pyLiteral(ref.Value.Discriminator.PropertyName))
+			}
+			if ref.Value.Description != "" {
+				args = append(args, "description="+pyLiteral(ref.Value.Description))
+			}
+			if ref.Value.Example != nil {
+				args = append(args, "examples=["+pyLiteral(ref.Value.Example)+"]")
+			}
+		}
+
+		value, hasDefault := defaults[property]
+		if !required[property] && (!hasDefault || value == nil) {
+			annotation += " | None"
+		}
+		if len(args) > 0 {
+			annotation = fmt.Sprintf("Annotated[%s, Field(%s)]", annotation, strings.Join(args, ", "))
+		}
+
+		field := fmt.Sprintf("    %s: %s", attribute, annotation)
+		switch {
+		case hasDefault:
+			field += " = " + pyLiteral(value)
+		case !required[property]:
+			field += " = None"
+		}
+		fields = append(fields, field)
+	}
+
+	fmt.Fprintf(&r.b, "\n\nclass %s(BaseModel):\n", name)
+	if s.Description != "" {
+		fmt.Fprintf(&r.b, "    %s\n", pyDocstring(s.Description))
+		if aliased || len(fields) > 0 {
+			r.b.WriteString("\n")
+		}
+	}
+	if aliased {
+		r.b.WriteString("    model_config = ConfigDict(populate_by_name=True)\n\n")
+	}
+	for _, field := range fields {
+		r.b.WriteString(field + "\n")
+	}
+	if len(fields) == 0 && s.Description == "" {
+		r.b.WriteString("    pass\n")
+	}
+}
+
+// annotation returns the Python type of a schema. Inline objects are generated as a class named name.
+func (r *pyRenderer) annotation(name string, ref *openapi3.SchemaRef) string {
+	if ref == nil {
+		return "Any"
+	}
+	if ref.Ref != "" {
+		return typeName(strings.TrimPrefix(ref.Ref, "#/components/schemas/"))
+	}
+	s := ref.Value
+	if s == nil {
+		return "Any"
+	}
+
+	if len(s.OneOf) > 0 {
+		variants := make([]string, 0, len(s.OneOf))
+		for _, variant := range s.OneOf {
+			variants = append(variants, r.annotation(name, variant))
+		}
+		return "Union[" + strings.Join(variants, ", ") + "]"
+	}
+	if len(s.Enum) > 0 {
+		literals := make([]string, 0, len(s.Enum))
+		for _, value := range s.Enum {
+			literals = append(literals, pyLiteral(value))
+		}
+		return "Literal[" + strings.Join(literals, ", ") + "]"
+	}
+
+	switch {
+	case s.Type.Is(openapi3.TypeString):
+		return "str"
+	case s.Type.Is(openapi3.TypeInteger):
+		return "int"
+	case s.Type.Is(openapi3.TypeNumber):
+		return "float"
+	case s.Type.Is(openapi3.TypeBoolean):
+		return "bool"
+	case s.Type.Is(openapi3.TypeArray):
+		return "list[" + r.annotation(name+"Item", s.Items) + "]"
+	case s.Type.Is(openapi3.TypeObject):
+		if len(s.Properties) > 0 {
+			r.pending = append(r.pending, pyClass{name: name, schema: s})
+			return name
+		}
+		if s.AdditionalProperties.Schema != nil {
+			return "dict[str, " + r.annotation(name+"Value", s.AdditionalProperties.Schema) + "]"
+		}
+		return "dict[str, Any]"
+	}
+	return "Any"
+}
+
+// pyAttributeName derives an attribute name for a property that is not a valid Python identifier
+func pyAttributeName(property string, taken map[string]bool) string {
+	var b strings.Builder
+	for _, c := range strings.TrimLeft(property, "_") {
+		if c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			b.WriteRune(c)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	name := b.String()
+	if name == "" || ('0' <= name[0] && name[0] <= '9') {
+		name = "field_" + name
+	}
+	name += "_"
+	for taken[name] {
+		name += "_"
+	}
+	taken[name] = true
+	return name
+}
+
+// pyLiteral renders a JSON value as a Python literal
+func pyLiteral(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "None"
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case string:
+		return strconv.Quote(v)
+	case json.Number:
+		return v.String()
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, pyLiteral(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, 0, len(v))
+		for _, key := range keys {
+			items = append(items, strconv.Quote(key)+": "+pyLiteral(v[key]))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	case float32, float64:
+		return strconv.FormatFloat(reflect.ValueOf(v).Float(), 'g', -1, 64)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "None"
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return "None"
+	}
+	return pyLiteral(decoded)
+}
+
+func pyDocstring(description string) string {
+	description = strings.ReplaceAll(description, `\`, `\\`)
+	description = strings.ReplaceAll(description, `"""`, `\"\"\"`)
+	if strings.HasSuffix(description, `"`) {
+		description += " "
+	}
+	return `"""` + description + `"""`
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/praetorian-inc/tabularium/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestRenderPython(t *testing.T) {
+	dns := openapi3.NewStringSchema()
+	dns.Description = "The DNS name of the asset."
+	dns.Example = "example.com"
+	asset := openapi3.NewObjectSchema().
+		WithProperty("dns", dns).
+		WithProperty("status", openapi3.NewStringSchema().WithEnum("A", "F")).
+		WithProperty("class", openapi3.NewStringSchema()).
+		WithProperty("_id", openapi3.NewStringSchema()).
+		WithProperty("ttl", openapi3.NewInt64Schema()).
+		WithProperty("tags", openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema())).
+		WithProperty("metadata", openapi3.NewObjectSchema().WithAdditionalProperties(openapi3.NewStringSchema())).
+		WithProperty("inline", openapi3.NewObjectSchema().WithProperty("x", openapi3.NewBoolSchema()))
+	asset.Description = `An "asset"`
+	asset.Required = []string{"class", "dns", "status"}
+	asset.Properties["parent"] = &openapi3.SchemaRef{Value: &openapi3.Schema{
+		OneOf: openapi3.SchemaRefs{
+			openapi3.NewSchemaRef("#/components/schemas/asset_wrapper", nil),
+			openapi3.NewSchemaRef("#/components/schemas/port_wrapper", nil),
+		},
+		Discriminator: &openapi3.Discriminator{PropertyName: "type"},
+	}}
+	wrapper := openapi3.NewObjectSchema().
+		WithProperty("type", openapi3.NewStringSchema().WithEnum("asset")).
+		WithPropertyRef("model", openapi3.NewSchemaRef("#/components/schemas/asset", nil))
+	wrapper.Required = []string{"model", "type"}
+
+	doc := &openapi3.T{Components: &openapi3.Components{Schemas: openapi3.Schemas{
+		"asset":         asset.NewRef(),
+		"asset_wrapper": wrapper.NewRef(),
+	}}}
+	defaults := map[string]map[string]any{
+		"asset": {"status": "A", "ttl": json.Number("168"), "tags": []any{}},
+	}
+
+	expected := `# Code generated by cmd/codegen. DO NOT EDIT.
+
+from __future__ import annotations
+
+from typing import Annotated, Any, Literal, Union
+
+from pydantic import BaseModel, ConfigDict, Field
+
+
+class Asset(BaseModel):
+    """An "asset" """
+
+    model_config = ConfigDict(populate_by_name=True)
+
+    id_: Annotated[str | None, Field(alias="_id")] = None
+    class_: Annotated[str, Field(alias="class")]
+    dns: Annotated[str, Field(description="The DNS name of the asset.", examples=["example.com"])]
+    inline: AssetInline | None = None
+    metadata: dict[str, str] | None = None
+    parent: Annotated[Union[AssetWrapper, PortWrapper] | None, Field(discriminator="type")] = None
+    status: Literal["A", "F"] = "A"
+    tags: list[str] = []
+    ttl: int = 168
+
+
+class AssetWrapper(BaseModel):
+    model: Asset
+    type: Literal["asset"]
+
+
+class AssetInline(BaseModel):
+    x: bool | None = None
+`
+	assert.Equal(t, expected, renderPython(doc, defaults))
+}
+
+func TestRegistryDefaults(t *testing.T) {
+	defaults := registryDefaults()
+
+	asset := defaults["asset"]
+	require.NotNil(t, asset)
+	assert.Equal(t, "A", asset["status"])
+	assert.Equal(t, "self", asset["source"])
+	// timestamps are generated, so they are not defaults
+	assert.NotContains(t, asset, "created")
+	assert.NotContains(t, asset, "visited")
+	assert.NotContains(t, asset, "ttl")
+
+	assert.Equal(t, []any{}, defaults["webpage"]["source"])
+}
+
+// TestRegistryDefaults_Generated fails when a model generates a default that generatedDefaults does not list
+func TestRegistryDefaults_Generated(t *testing.T) {
+	defaults := registryDefaults()
+	// generated IDs differ between calls
+	assert.Equal(t, defaults, registryDefaults())
+
+	timestamp := regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}`)
+	now := time.Now().Unix()
+	for name, values := range defaults {
+		for field, value := range values {
+			switch v := value.(type) {
+			case string:
+				assert.False(t, timestamp.MatchString(v), "%s.%s defaults to a timestamp", name, field)
+			case json.Number:
+				n, err := v.Int64()
+				assert.False(t, err == nil && n > now-365*24*60*60, "%s.%s defaults to a time", name, field)
+			}
+		}
+	}
+}
+
+func TestGeneratePythonPydantic(t *testing.T) {
+	doc, err := schema.GenerateOpenAPISchema()
+	require.NoError(t, err)
+	bytes, err := yaml.Marshal(doc)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "api.yaml")
+	require.NoError(t, os.WriteFile(input, bytes, 0644))
+	require.NoError(t, generatePythonPydantic(input, dir))
+
+	output, err := os.ReadFile(filepath.Join(dir, "models.py"))
+	require.NoError(t, err)
+	source := string(output)
+
+	for _, name := range []string{"Asset", "Risk", "Job", "Webpage", "AssetWrapper", "AegisMgmt"} {
+		assert.Contains(t, source, "\nclass "+name+"(BaseModel):\n")
+	}
+	assert.Contains(t, source, `class_: Annotated[str, Field(alias="class"`)
+	assert.Contains(t, source, `status: Annotated[Literal["active", "paused", "expired"]`)
+	assert.Regexp(t, `parent: Annotated\[Union\[[A-Za-z, ]*\bAssetWrapper\b[A-Za-z, ]*\], Field\(discriminator="type"`, source)
+}
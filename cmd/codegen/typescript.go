@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/praetorian-inc/tabularium/pkg/schema"
+)
+
+// tsIdentifier matches property names that can be written without quotes
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// typeNameSeparator splits component names into the words of a type name
+var typeNameSeparator = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+func generateTypeScript(inputFile, outputDir string) error {
+	doc, err := schema.LoadOpenAPISchema(inputFile)
+	if err != nil {
+		return err
+	}
+
+	outputFile := filepath.Join(outputDir, "models.ts")
+	if err := os.WriteFile(outputFile, []byte(renderTypeScript(doc)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputFile, err)
+	}
+	return nil
+}
+
+// renderTypeScript emits an interface per component schema. Wrapper fields become unions of the
+// <model>_wrapper components, discriminated by their type property, enums become literal unions
+// and descriptions become JSDoc.
+func renderTypeScript(doc *openapi3.T) string {
+	var b strings.Builder
+	b.WriteString("// Code generated by cmd/codegen. DO NOT EDIT.\n")
+
+	schemas := doc.Components.Schemas
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s := schemas[name].Value
+		if s == nil {
+			continue
+		}
+		b.WriteString("\n")
+		writeTSDoc(&b, "", s.Description, nil)
+		if s.Type.Is(openapi3.TypeObject) && s.AdditionalProperties.Schema == nil {
+			fmt.Fprintf(&b, "export interface %s {\n", typeName(name))
+			writeTSProperties(&b, s, "  ")
+			b.WriteString("}\n")
+			continue
+		}
+		fmt.Fprintf(&b, "export type %s = %s;\n", typeName(name), tsType(schemas[name], ""))
+	}
+	return b.String()
+}
+
+func writeTSProperties(b *strings.Builder, s *openapi3.Schema, indent string) {
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property := s.Properties[name]
+		if property.Ref == "" && property.Value != nil {
+			writeTSDoc(b, indent, property.Value.Description, property.Value.Example)
+		}
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		fmt.Fprintf(b, "%s%s%s: %s;\n", indent, tsPropertyName(name), optional, tsType(property, indent))
+	}
+}
+
+// tsType returns the TypeScript type of a schema. Inline objects are indented one level past indent.
+func tsType(ref *openapi3.SchemaRef, indent string) string {
+	if ref == nil {
+		return "unknown"
+	}
+	if ref.Ref != "" {
+		return typeName(strings.TrimPrefix(ref.Ref, "#/components/schemas/"))
+	}
+	s := ref.Value
+	if s == nil {
+		return "unknown"
+	}
+
+	if len(s.OneOf) > 0 {
+		variants := make([]string, 0, len(s.OneOf))
+		for _, variant := range s.OneOf {
+			variants = append(variants, tsType(variant, indent))
+		}
+		return strings.Join(variants, " | ")
+	}
+	if len(s.Enum) > 0 {
+		literals := make([]string, 0, len(s.Enum))
+		for _, value := range s.Enum {
+			literal, _ := json.Marshal(value)
+			literals = append(literals, string(literal))
+		}
+		return strings.Join(literals, " | ")
+	}
+
+	switch {
+	case s.Type.Is(openapi3.TypeString):
+		return "string"
+	case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+		return "number"
+	case s.Type.Is(openapi3.TypeBoolean):
+		return "boolean"
+	case s.Type.Is(openapi3.TypeArray):
+		item := tsType(s.Items, indent)
+		if strings.Contains(item, " | ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case s.Type.Is(openapi3.TypeObject):
+		if len(s.Properties) > 0 {
+			var b strings.Builder
+			b.WriteString("{\n")
+			writeTSProperties(&b, s, indent+"  ")
+			b.WriteString(indent + "}")
+			return b.String()
+		}
+		if s.AdditionalProperties.Schema != nil {
+			return "Record<string, " + tsType(s.AdditionalProperties.Schema, indent) + ">"
+		}
+		return "Record<string, unknown>"
+	}
+	return "unknown"
+}
+
+func writeTSDoc(b *strings.Builder, indent, description string, example any) {
+	var lines []string
+	if description != "" {
+		lines = append(lines, strings.Split(description, "\n")...)
+	}
+	if example != nil {
+		text, ok := example.(string)
+		if !ok {
+			data, _ := json.Marshal(example)
+			text = string(data)
+		}
+		lines = append(lines, "@example "+text)
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	for i, line := range lines {
+		lines[i] = strings.ReplaceAll(line, "*/", `*\/`)
+	}
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s * %s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
+
+// typeName converts a component name such as asset_wrapper or aegis-mgmt to AssetWrapper or AegisMgmt
+func typeName(name string) string {
+	var b strings.Builder
+	for _, part := range typeNameSeparator.Split(name, -1) {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+func tsPropertyName(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	quoted, _ := json.Marshal(name)
+	return string(quoted)
+}
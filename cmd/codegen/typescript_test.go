@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/praetorian-inc/tabularium/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestRenderTypeScript(t *testing.T) {
+	status := openapi3.NewStringSchema().WithEnum("active", "paused")
+	status.Description = "Schedule status"
+	dns := openapi3.NewStringSchema()
+	dns.Description = "The DNS name */ of the asset."
+	dns.Example = "example.com"
+	asset := openapi3.NewObjectSchema().
+		WithProperty("dns", dns).
+		WithProperty("status", status).
+		WithProperty("ports", openapi3.NewArraySchema().WithItems(openapi3.NewInt64Schema())).
+		WithProperty("tags", openapi3.NewObjectSchema().WithAdditionalProperties(openapi3.NewStringSchema())).
+		WithProperty("metadata", openapi3.NewObjectSchema()).
+		WithProperty("inline", openapi3.NewObjectSchema().WithProperty("x", openapi3.NewBoolSchema())).
+		WithProperty("user-agent", openapi3.NewStringSchema())
+	asset.Description = "An asset"
+	asset.Required = []string{"dns"}
+	asset.Properties["parent"] = &openapi3.SchemaRef{Value: &openapi3.Schema{OneOf: openapi3.SchemaRefs{
+		openapi3.NewSchemaRef("#/components/schemas/asset_wrapper", nil),
+		openapi3.NewSchemaRef("#/components/schemas/port_wrapper", nil),
+	}}}
+	wrapper := openapi3.NewObjectSchema().
+		WithProperty("type", openapi3.NewStringSchema().WithEnum("asset", "assets")).
+		WithPropertyRef("model", openapi3.NewSchemaRef("#/components/schemas/asset", nil))
+	wrapper.Required = []string{"model", "type"}
+
+	doc := &openapi3.T{Components: &openapi3.Components{Schemas: openapi3.Schemas{
+		"asset":         asset.NewRef(),
+		"asset_wrapper": wrapper.NewRef(),
+	}}}
+
+	expected := `// Code generated by cmd/codegen. DO NOT EDIT.
+
+/** An asset */
+export interface Asset {
+  /**
+   * The DNS name *\/ of the asset.
+   * @example example.com
+   */
+  dns: string;
+  inline?: {
+    x?: boolean;
+  };
+  metadata?: Record<string, unknown>;
+  parent?: AssetWrapper | PortWrapper;
+  ports?: number[];
+  /** Schedule status */
+  status?: "active" | "paused";
+  tags?: Record<string, string>;
+  "user-agent"?: string;
+}
+
+export interface AssetWrapper {
+  model: Asset;
+  type: "asset" | "assets";
+}
+`
+	assert.Equal(t, expected, renderTypeScript(doc))
+}
+
+func TestGenerateTypeScript(t *testing.T) {
+	doc, err := schema.GenerateOpenAPISchema()
+	require.NoError(t, err)
+	bytes, err := yaml.Marshal(doc)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "api.yaml")
+	require.NoError(t, os.WriteFile(input, bytes, 0644))
+	require.NoError(t, generateTypeScript(input, dir))
+
+	output, err := os.ReadFile(filepath.Join(dir, "models.ts"))
+	require.NoError(t, err)
+	source := string(output)
+
+	for _, name := range []string{"Asset", "Risk", "Job", "Webpage", "AssetWrapper", "Weeklyschedule"} {
+		assert.Contains(t, source, "export interface "+name+" {")
+	}
+	assert.Contains(t, source, `status: "active" | "paused" | "expired";`)
+	assert.Contains(t, source, "sso_identified: Record<string, Ssowebpage>;")
+	assert.Regexp(t, `parent: [A-Za-z |]*\bAssetWrapper\b[A-Za-z |]*;`, source)
+}
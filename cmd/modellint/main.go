@@ -0,0 +1,115 @@
+// Command modellint checks every registered model against the tag conventions of modellint.Rules,
+// reporting each violation with its file and line as text or, with -format json, as JSON. -rules
+// limits the check to some of the rules. It exits with status 1 on any violation.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	_ "github.com/praetorian-inc/tabularium/pkg/capmodel"    // Register converters and extractors
+	_ "github.com/praetorian-inc/tabularium/pkg/model/model" // Ensure init() functions run
+	"github.com/praetorian-inc/tabularium/pkg/modellint"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// Exit codes, so that CI can tell violations apart from a failure to run
+const (
+	exitViolations = 1
+	exitError      = 2
+)
+
+func main() {
+	rulesFlag := flag.String("rules", "", "Comma separated rules to check (default all): "+joinRules(modellint.Rules))
+	format := flag.String("format", "text", "Output format: text or json")
+	flag.Parse()
+
+	rules, err := parseRules(*rulesFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitError)
+	}
+
+	violations := modellint.Lint(registry.Registry, rules...)
+	if wd, err := os.Getwd(); err == nil {
+		relativize(violations, wd)
+	}
+
+	switch *format {
+	case "text":
+		err = writeText(os.Stdout, violations)
+	case "json":
+		err = writeJSON(os.Stdout, violations)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q, expected text or json\n", *format)
+		os.Exit(exitError)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing violations: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	if len(violations) > 0 {
+		fmt.Fprintf(os.Stderr, "%d violations\n", len(violations))
+		os.Exit(exitViolations)
+	}
+}
+
+func parseRules(s string) ([]modellint.Rule, error) {
+	var rules []modellint.Rule
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		rule := modellint.Rule(name)
+		if !slices.Contains(modellint.Rules, rule) {
+			return nil, fmt.Errorf("unknown rule %q, expected one of %s", name, joinRules(modellint.Rules))
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func joinRules(rules []modellint.Rule) string {
+	names := make([]string, len(rules))
+	for i, rule := range rules {
+		names[i] = string(rule)
+	}
+	return strings.Join(names, ", ")
+}
+
+// relativize makes the filenames of violations relative to dir, where they are within it
+func relativize(violations []modellint.Violation, dir string) {
+	for i, v := range violations {
+		if rel, err := filepath.Rel(dir, v.Pos.Filename); err == nil && !strings.HasPrefix(rel, "..") {
+			violations[i].Pos.Filename = rel
+		}
+	}
+}
+
+func writeText(w io.Writer, violations []modellint.Violation) error {
+	for _, v := range violations {
+		if _, err := fmt.Fprintln(w, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, violations []modellint.Violation) error {
+	if violations == nil {
+		violations = []modellint.Violation{}
+	}
+	bytes, err := json.MarshalIndent(violations, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(bytes))
+	return err
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"go/token"
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/modellint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := parseRules("desc, example-type")
+	require.NoError(t, err)
+	assert.Equal(t, []modellint.Rule{modellint.RuleDesc, modellint.RuleExampleType}, rules)
+
+	rules, err = parseRules("")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	_, err = parseRules("desc,spelling")
+	assert.ErrorContains(t, err, `unknown rule "spelling"`)
+}
+
+func TestWriteText(t *testing.T) {
+	violations := []modellint.Violation{
+		{Rule: modellint.RuleDesc, Type: "model.Asset", Field: "DNS", Message: "missing desc tag",
+			Pos: token.Position{Filename: "/src/pkg/model/model/asset.go", Line: 12, Column: 2}},
+		{Rule: modellint.RuleExample, Type: "model.Asset", Field: "Name", Message: "missing example tag"},
+	}
+	relativize(violations, "/src")
+
+	var buf bytes.Buffer
+	require.NoError(t, writeText(&buf, violations))
+	expected := `pkg/model/model/asset.go:12:2: model.Asset.DNS: missing desc tag (desc)
+model.Asset.Name: missing example tag (example)
+`
+	assert.Equal(t, expected, buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeJSON(&buf, nil))
+	assert.Equal(t, "[]\n", buf.String())
+}
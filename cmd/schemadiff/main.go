@@ -0,0 +1,81 @@
+// Command schemadiff compares the OpenAPI schema in -base with the one in -head, or with the schema
+// generated from the registry when -head is not given, and prints a JSON report of the changes.
+// Removed models, aliases, properties, enum values and wrapper variants, changed types, and newly
+// required properties are breaking; other changes are additive. It exits with status 1 when any
+// change is breaking and 2 when the schemas cannot be compared, so that it can gate merges.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/praetorian-inc/tabularium/pkg/schema"
+)
+
+// Exit codes, so that CI can tell a breaking change apart from a failure to run
+const (
+	exitBreaking = 1
+	exitError    = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run compares the schemas named by args, writes the report, and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("schemadiff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	baseFile := flags.String("base", "", "Path to the baseline OpenAPI schema, e.g. client/api.yaml from the target branch (required)")
+	headFile := flags.String("head", "", "Path to the OpenAPI schema to compare (if not specified, the schema is generated from the current registry)")
+	outputFile := flags.String("output", "", "Output file path for the JSON report (if not specified, prints to stdout)")
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	if *baseFile == "" {
+		fmt.Fprintln(stderr, "-base flag is required")
+		return exitError
+	}
+
+	base, err := schema.LoadOpenAPISchema(*baseFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading base schema: %v\n", err)
+		return exitError
+	}
+
+	var head *openapi3.T
+	if *headFile == "" {
+		head, err = schema.GenerateOpenAPISchema()
+	} else {
+		head, err = schema.LoadOpenAPISchema(*headFile)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading head schema: %v\n", err)
+		return exitError
+	}
+
+	report := schema.DiffOpenAPISchemas(base, head)
+	bytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(stderr, "Error marshaling report: %v\n", err)
+		return exitError
+	}
+
+	if *outputFile == "" {
+		fmt.Fprintln(stdout, string(bytes))
+	} else if err := os.WriteFile(*outputFile, append(bytes, '\n'), 0644); err != nil {
+		fmt.Fprintf(stderr, "Error writing report to %s: %v\n", *outputFile, err)
+		return exitError
+	}
+
+	if report.Breaking {
+		fmt.Fprintln(stderr, "Breaking schema changes detected")
+		return exitBreaking
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("breaking", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-base", "testdata/base.yaml", "-head", "testdata/breaking.yaml"}, &stdout, &stderr)
+		assert.Equal(t, exitBreaking, code)
+		assert.Contains(t, stderr.String(), "Breaking schema changes detected")
+
+		var report schema.DiffReport
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+		assert.True(t, report.Breaking)
+		assert.ElementsMatch(t, []schema.Change{
+			{Severity: schema.SeverityBreaking, Kind: schema.ChangePropertyRemoved, Model: "asset", Path: "name", Message: "asset.name was removed"},
+			{Severity: schema.SeverityBreaking, Kind: schema.ChangeRequiredAdded, Model: "asset", Path: "status", Message: "asset.status is now required"},
+			{Severity: schema.SeverityBreaking, Kind: schema.ChangeEnumRemoved, Model: "asset", Path: "status", Message: `asset.status no longer allows "F"`},
+		}, report.Changes)
+	})
+
+	t.Run("additive", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "report.json")
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-base", "testdata/base.yaml", "-head", "testdata/additive.yaml", "-output", output}, &stdout, &stderr)
+		assert.Equal(t, 0, code, stderr.String())
+		assert.Empty(t, stdout.String())
+
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		var report schema.DiffReport
+		require.NoError(t, json.Unmarshal(data, &report))
+		assert.False(t, report.Breaking)
+		require.Len(t, report.Changes, 2)
+		for _, change := range report.Changes {
+			assert.Equal(t, schema.SeverityAdditive, change.Severity)
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-base", "testdata/base.yaml", "-head", "testdata/base.yaml"}, &stdout, &stderr)
+		assert.Equal(t, 0, code)
+		assert.JSONEq(t, `{"breaking": false, "changes": []}`, stdout.String())
+	})
+
+	errors := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"missing base", []string{"-head", "testdata/base.yaml"}, "-base flag is required"},
+		{"unreadable base", []string{"-base", "testdata/missing.yaml"}, "Error loading base schema"},
+		{"unreadable head", []string{"-base", "testdata/base.yaml", "-head", "testdata/missing.yaml"}, "Error loading head schema"},
+		{"unknown flag", []string{"-bogus"}, "flag provided but not defined"},
+	}
+	for _, tt := range errors {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, exitError, run(tt.args, &stdout, &stderr))
+			assert.Contains(t, stderr.String(), tt.err)
+		})
+	}
+}
@@ -1,19 +1,43 @@
+// Command schemagen writes the OpenAPI 3.0 schema of every registered model, as checked in at
+// client/api.yaml. With -format jsonschema it writes standalone JSON Schema (draft 2020-12)
+// documents instead: one for the model named by -model, or one per registered model into the
+// -output directory.
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/praetorian-inc/tabularium/pkg/schema"
 	"gopkg.in/yaml.v2"
 )
 
 func main() {
-	outputFile := flag.String("output", "", "Output file path for the OpenAPI schema (if not specified, prints to stdout)")
+	outputFile := flag.String("output", "", "Output file path for the OpenAPI schema (if not specified, prints to stdout). With -format jsonschema and no -model, the output directory for one schema per model")
+	format := flag.String("format", "openapi", "Schema format: openapi or jsonschema")
+	modelName := flag.String("model", "", "Registered model to generate a JSON Schema for (jsonschema format only)")
 	flag.Parse()
 
+	switch *format {
+	case "openapi":
+		generateOpenAPI(*outputFile)
+	case "jsonschema":
+		if *modelName == "" {
+			generateJSONSchemas(*outputFile)
+			return
+		}
+		generateJSONSchema(*modelName, *outputFile)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q, expected openapi or jsonschema\n", *format)
+		os.Exit(1)
+	}
+}
+
+func generateOpenAPI(outputFile string) {
 	// Generate the OpenAPI document using the library
 	doc, err := schema.GenerateOpenAPISchema()
 	if err != nil {
@@ -29,23 +53,78 @@ func main() {
 	}
 
 	// Handle output
-	if *outputFile == "" {
+	if outputFile == "" {
 		fmt.Print(string(bytes))
 		return
 	}
 
+	write(outputFile, bytes)
+	fmt.Printf("Generated OpenAPI schema at %s\n", outputFile)
+}
+
+func generateJSONSchema(name, outputFile string) {
+	doc, err := schema.GenerateJSONSchema(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating JSON Schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	bytes, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling JSON Schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	if outputFile == "" {
+		fmt.Println(string(bytes))
+		return
+	}
+
+	write(outputFile, append(bytes, '\n'))
+	fmt.Printf("Generated JSON Schema for %s at %s\n", name, outputFile)
+}
+
+func generateJSONSchemas(outputDir string) {
+	if outputDir == "" {
+		fmt.Fprintln(os.Stderr, "Either -model or an -output directory is required with -format jsonschema")
+		os.Exit(1)
+	}
+
+	docs, err := schema.GenerateJSONSchemas()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating JSON Schemas: %v\n", err)
+		os.Exit(1)
+	}
+
+	names := make([]string, 0, len(docs))
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		bytes, err := json.MarshalIndent(docs[name], "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error marshaling JSON Schema for %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		write(filepath.Join(outputDir, name+".schema.json"), append(bytes, '\n'))
+	}
+
+	fmt.Printf("Generated %d JSON Schemas in %s\n", len(names), outputDir)
+}
+
+func write(outputFile string, bytes []byte) {
 	// Create output directory if it doesn't exist
-	outputDir := filepath.Dir(*outputFile)
+	outputDir := filepath.Dir(outputFile)
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating output directory %s: %v\n", outputDir, err)
 		os.Exit(1)
 	}
 
 	// Write to file
-	if err := os.WriteFile(*outputFile, bytes, 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing schema to %s: %v\n", *outputFile, err)
+	if err := os.WriteFile(outputFile, bytes, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing schema to %s: %v\n", outputFile, err)
 		os.Exit(1)
 	}
-
-	fmt.Printf("Generated OpenAPI schema at %s\n", *outputFile)
 }
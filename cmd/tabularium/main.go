@@ -0,0 +1,126 @@
+// Command tabularium inspects the registry. "describe [-format table|json] <model>" prints the
+// description returned by registry.Describe: the model's fields with their tags, desc and example
+// values, its aliases, labels and hooks, the well-known interfaces it implements, and its
+// registered converters and extractors.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	_ "github.com/praetorian-inc/tabularium/pkg/capmodel"    // Register converters and extractors
+	_ "github.com/praetorian-inc/tabularium/pkg/model/model" // Ensure init() functions run
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+const usage = `Usage: tabularium <command> [flags]
+
+Commands:
+  describe [-format table|json] <model>   Describe a registered model
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "describe":
+		describe(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+func describe(args []string) {
+	flags := flag.NewFlagSet("describe", flag.ExitOnError)
+	format := flags.String("format", "table", "Output format: table or json")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "describe takes exactly one model name")
+		os.Exit(2)
+	}
+
+	d, err := registry.Describe(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error describing model: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch *format {
+	case "table":
+		err = writeTable(os.Stdout, d)
+	case "json":
+		err = writeJSON(os.Stdout, d)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q, expected table or json\n", *format)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing descriptor: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func writeJSON(w io.Writer, d *registry.Descriptor) error {
+	bytes, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(bytes))
+	return err
+}
+
+// writeTable prints the model's summary followed by a table of its fields
+func writeTable(w io.Writer, d *registry.Descriptor) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	summary := []struct {
+		label string
+		value string
+	}{
+		{"Name", d.Name},
+		{"Type", d.Type},
+		{"Description", d.Description},
+		{"Aliases", strings.Join(d.Aliases, ", ")},
+		{"Interfaces", strings.Join(d.Interfaces, ", ")},
+		{"Labels", strings.Join(d.Labels, ", ")},
+		{"Hooks", strings.Join(d.Hooks, "; ")},
+		{"Capmodel", strings.Join(d.Capmodel, ", ")},
+		{"Converters", strings.Join(d.Converters, ", ")},
+		{"Extractors", strings.Join(d.Extractors, ", ")},
+	}
+	for _, line := range summary {
+		if line.value != "" {
+			fmt.Fprintf(tw, "%s:\t%s\n", line.label, line.value)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tTYPE\tEMBEDDED\tJSON\tNEO4J\tDYNAMODBAV\tCAPMODEL\tDESC")
+	for _, f := range d.Fields {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			f.Name, f.Type, dash(f.Embedded), dash(f.JSON), dash(f.Neo4j), dash(f.DynamoDBAV), dash(f.Capmodel), dash(f.Desc))
+	}
+	return tw.Flush()
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTable(t *testing.T) {
+	d := &registry.Descriptor{
+		Name:        "asset",
+		Type:        "model.Asset",
+		Description: "An asset",
+		Interfaces:  []string{"GraphModel", "Target"},
+		Fields: []registry.FieldDescriptor{
+			{Name: "Key", Type: "string", JSON: "key", Neo4j: "key", Desc: "The key"},
+			{Name: "History", Type: "[]model.HistoryRecord", Embedded: "BaseAsset.History", JSON: "history,omitempty"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeTable(&buf, d))
+
+	expected := `Name:         asset
+Type:         model.Asset
+Description:  An asset
+Interfaces:   GraphModel, Target
+
+FIELD    TYPE                   EMBEDDED           JSON               NEO4J  DYNAMODBAV  CAPMODEL  DESC
+Key      string                 -                  key                key    -           -         The key
+History  []model.HistoryRecord  BaseAsset.History  history,omitempty  -      -           -         -
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+	d, err := registry.Describe("asset")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeJSON(&buf, d))
+
+	var decoded registry.Descriptor
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, *d, decoded)
+	assert.Contains(t, decoded.Interfaces, "Assetlike")
+	assert.Contains(t, decoded.Converters, "Asset")
+	assert.True(t, strings.HasSuffix(buf.String(), "}\n"))
+}
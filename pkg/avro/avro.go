@@ -0,0 +1,148 @@
+// Package avro maps registered models to Avro record schemas and writes them to Avro object
+// container files (OCF) for data-lake ingestion.
+//
+// Fields follow the models' json tags. Embedded structs such as History, Metadata and
+// OriginationData become nested records, except for Base types such as BaseAsset, which hold
+// the model's own fields and are flattened as in JSON. Pointers are unions with null, and values
+// with no Avro equivalent, such as wrappers and interfaces, are carried as JSON strings.
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// Namespace prefixes the namespace of every generated record
+const Namespace = "tabularium"
+
+// Avro primitive and complex type names
+const (
+	TypeNull    = "null"
+	TypeBoolean = "boolean"
+	TypeInt     = "int"
+	TypeLong    = "long"
+	TypeFloat   = "float"
+	TypeDouble  = "double"
+	TypeBytes   = "bytes"
+	TypeString  = "string"
+	TypeRecord  = "record"
+	TypeEnum    = "enum"
+	TypeArray   = "array"
+	TypeMap     = "map"
+	TypeFixed   = "fixed"
+)
+
+// Schema is an Avro schema. Primitive types and references to named types are encoded as just
+// their type name, and unions as the list of their branches.
+type Schema struct {
+	Type      string    `json:"type"`
+	Name      string    `json:"name,omitempty"`
+	Namespace string    `json:"namespace,omitempty"`
+	Doc       string    `json:"doc,omitempty"`
+	Fields    []*Field  `json:"fields,omitempty"`
+	Symbols   []string  `json:"symbols,omitempty"`
+	Items     *Schema   `json:"items,omitempty"`
+	Values    *Schema   `json:"values,omitempty"`
+	Size      int       `json:"size,omitempty"`
+	Union     []*Schema `json:"-"`
+}
+
+// Field is a field of an Avro record
+type Field struct {
+	Name    string          `json:"name"`
+	Doc     string          `json:"doc,omitempty"`
+	Type    *Schema         `json:"type"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+type schemaObject Schema
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.Union != nil {
+		return json.Marshal(s.Union)
+	}
+	if s.isName() {
+		return json.Marshal(s.Type)
+	}
+	return json.Marshal((*schemaObject)(s))
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) > 0 && data[0] == '"':
+		*s = Schema{}
+		return json.Unmarshal(data, &s.Type)
+	case len(data) > 0 && data[0] == '[':
+		*s = Schema{}
+		return json.Unmarshal(data, &s.Union)
+	}
+	return json.Unmarshal(data, (*schemaObject)(s))
+}
+
+// isName reports whether s is just a type name: a primitive or a reference to a named type
+func (s *Schema) isName() bool {
+	return s.Name == "" && s.Doc == "" && len(s.Fields) == 0 && len(s.Symbols) == 0 && s.Items == nil && s.Values == nil && s.Size == 0
+}
+
+// FullName returns the name a named schema is referenced by
+func (s *Schema) FullName() string {
+	if s.Namespace == "" || strings.Contains(s.Name, ".") {
+		return s.Name
+	}
+	return s.Namespace + "." + s.Name
+}
+
+// Definitions returns the records, enums and fixed types defined within s, keyed by full name.
+// References to them elsewhere in s are by full name, as GenerateSchema writes them.
+func (s *Schema) Definitions() map[string]*Schema {
+	defs := make(map[string]*Schema)
+	s.collectDefinitions(defs)
+	return defs
+}
+
+func (s *Schema) collectDefinitions(defs map[string]*Schema) {
+	if s == nil {
+		return
+	}
+	switch s.Type {
+	case TypeRecord, TypeEnum, TypeFixed:
+		defs[s.FullName()] = s
+	}
+	for _, f := range s.Fields {
+		f.Type.collectDefinitions(defs)
+	}
+	for _, branch := range s.Union {
+		branch.collectDefinitions(defs)
+	}
+	s.Items.collectDefinitions(defs)
+	s.Values.collectDefinitions(defs)
+}
+
+// GenerateSchema creates the Avro record schema of the registered model name
+func GenerateSchema(name string) (*Schema, error) {
+	typ, ok := registry.Registry.GetType(strings.ToLower(name))
+	if !ok {
+		return nil, fmt.Errorf("type %q is not registered", name)
+	}
+	s, _, err := modelSchema(typ)
+	return s, err
+}
+
+// modelSchema returns the record schema and encoder of a registered model type
+func modelSchema(typ reflect.Type) (*Schema, encoder, error) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("%s is not a struct", typ)
+	}
+	g := newGenerator()
+	rec := g.record(typ, typ.Name())
+	return rec.schema, rec.encode, nil
+}
@@ -0,0 +1,181 @@
+package avro
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+)
+
+var errTruncated = errors.New("avro: truncated data")
+
+func encodeBool(b []byte, v reflect.Value) ([]byte, error) {
+	if v.Bool() {
+		return append(b, 1), nil
+	}
+	return append(b, 0), nil
+}
+
+func encodeInt(b []byte, v reflect.Value) ([]byte, error) {
+	return appendLong(b, v.Int()), nil
+}
+
+func encodeUint(b []byte, v reflect.Value) ([]byte, error) {
+	return appendLong(b, int64(v.Uint())), nil
+}
+
+func encodeFloat(b []byte, v reflect.Value) ([]byte, error) {
+	return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(v.Float()))), nil
+}
+
+func encodeDouble(b []byte, v reflect.Value) ([]byte, error) {
+	return binary.LittleEndian.AppendUint64(b, math.Float64bits(v.Float())), nil
+}
+
+func encodeString(b []byte, v reflect.Value) ([]byte, error) {
+	return appendString(b, v.String()), nil
+}
+
+func encodeBytes(b []byte, v reflect.Value) ([]byte, error) {
+	return appendBytes(b, v.Bytes()), nil
+}
+
+// encodeJSON carries values with no Avro equivalent as their JSON encoding
+func encodeJSON(b []byte, v reflect.Value) ([]byte, error) {
+	data, err := json.Marshal(addressable(v).Interface())
+	if err != nil {
+		return nil, err
+	}
+	return appendBytes(b, data), nil
+}
+
+func encodeText(b []byte, v reflect.Value) ([]byte, error) {
+	text, err := addressable(v).Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return appendBytes(b, text), nil
+}
+
+// optionalEncoder encodes a pointer as the ["null", T] union
+func optionalEncoder(encode encoder) encoder {
+	return func(b []byte, v reflect.Value) ([]byte, error) {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return appendLong(b, 0), nil
+			}
+			v = v.Elem()
+		}
+		return encode(appendLong(b, 1), v)
+	}
+}
+
+// arrayEncoder writes slices as a single block of items
+func arrayEncoder(encode encoder) encoder {
+	return func(b []byte, v reflect.Value) ([]byte, error) {
+		if v.Len() == 0 {
+			return appendLong(b, 0), nil
+		}
+		b = appendLong(b, int64(v.Len()))
+		var err error
+		for i := 0; i < v.Len(); i++ {
+			if b, err = encode(b, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return appendLong(b, 0), nil
+	}
+}
+
+// mapEncoder writes maps as a single block of entries, in key order
+func mapEncoder(key func(reflect.Value) (string, error), encode encoder) encoder {
+	return func(b []byte, v reflect.Value) ([]byte, error) {
+		if v.Len() == 0 {
+			return appendLong(b, 0), nil
+		}
+		names, keys, err := sortedKeys(v, key)
+		if err != nil {
+			return nil, err
+		}
+		b = appendLong(b, int64(len(keys)))
+		for i, k := range keys {
+			if b, err = encode(appendString(b, names[i]), v.MapIndex(k)); err != nil {
+				return nil, err
+			}
+		}
+		return appendLong(b, 0), nil
+	}
+}
+
+// addressable returns a pointer to v, so that methods with pointer receivers are callable, as
+// they are when encoding/json marshals a field
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v.Addr()
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p
+}
+
+// appendLong appends a zig-zag encoded variable-length integer
+func appendLong(b []byte, n int64) []byte {
+	return binary.AppendUvarint(b, uint64(n<<1)^uint64(n>>63))
+}
+
+func appendBytes(b, data []byte) []byte {
+	return append(appendLong(b, int64(len(data))), data...)
+}
+
+func appendString(b []byte, s string) []byte {
+	return append(appendLong(b, int64(len(s))), s...)
+}
+
+// decoder reads Avro binary values from a buffer
+type decoder struct {
+	data []byte
+}
+
+func (d *decoder) long() (int64, error) {
+	u, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	d.data = d.data[n:]
+	return int64(u>>1) ^ -int64(u&1), nil
+}
+
+func (d *decoder) fixed(n int) ([]byte, error) {
+	if n < 0 || len(d.data) < n {
+		return nil, errTruncated
+	}
+	out := d.data[:n]
+	d.data = d.data[n:]
+	return out, nil
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.long()
+	if err != nil {
+		return nil, err
+	}
+	if n > int64(len(d.data)) {
+		return nil, errTruncated
+	}
+	return d.fixed(int(n))
+}
+
+// blockCount reads the item count of an array or map block. Negative counts are followed by the
+// block's size in bytes, which is not needed to read it.
+func (d *decoder) blockCount() (int64, error) {
+	n, err := d.long()
+	if err != nil || n >= 0 {
+		return n, err
+	}
+	if _, err := d.long(); err != nil {
+		return 0, err
+	}
+	return -n, nil
+}
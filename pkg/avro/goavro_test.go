@@ -0,0 +1,69 @@
+package avro_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/praetorian-inc/tabularium/pkg/avro"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWriteOCF_Goavro reads files written by this package with goavro and checks that goavro encodes the
+// records it decodes to the same bytes
+func TestWriteOCF_Goavro(t *testing.T) {
+	asset := model.NewAsset("example.com", "1.2.3.4")
+	asset.Metadata.ASName = "EXAMPLE"
+	risk := model.NewRisk(&asset, "cve-2024-1234", model.TriageHigh)
+	risk.Capability = []string{"nuclei"}
+	risk.History.Update("", model.TriageHigh, "user@example.com", "found", model.History{})
+	other := model.NewRisk(&asset, "cve-2024-5678", model.TriageLow)
+
+	for _, codec := range []avro.Codec{avro.CodecNull, avro.CodecDeflate} {
+		t.Run(string(codec), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, avro.WriteOCF(&buf, []*model.Risk{&risk, &other}, codec))
+
+			reader, err := goavro.NewOCFReader(bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+			assert.Equal(t, string(codec), reader.CompressionName())
+
+			var natives []any
+			for reader.Scan() {
+				native, err := reader.Read()
+				require.NoError(t, err)
+				natives = append(natives, native)
+			}
+			require.NoError(t, reader.Err())
+			require.Len(t, natives, 2)
+			assert.Equal(t, risk.Key, natives[0].(map[string]any)["key"])
+			assert.Equal(t, other.Key, natives[1].(map[string]any)["key"])
+
+			if codec != avro.CodecNull {
+				return
+			}
+			// an uncompressed block holds the record encodings back to back
+			var encoded []byte
+			for _, native := range natives {
+				encoded, err = reader.Codec().BinaryFromNative(encoded, native)
+				require.NoError(t, err)
+			}
+			assert.True(t, bytes.Contains(buf.Bytes(), encoded), "block differs from goavro's encoding")
+		})
+	}
+}
+
+func TestGenerateSchema_Goavro(t *testing.T) {
+	for _, name := range []string{"asset", "risk", "job", "attribute"} {
+		t.Run(name, func(t *testing.T) {
+			schema, err := avro.GenerateSchema(name)
+			require.NoError(t, err)
+			data, err := schema.MarshalJSON()
+			require.NoError(t, err)
+			_, err = goavro.NewCodec(string(data))
+			assert.NoError(t, err)
+		})
+	}
+}
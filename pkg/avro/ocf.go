@@ -0,0 +1,413 @@
+package avro
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// Codec is the compression applied to the blocks of an object container file
+type Codec string
+
+const (
+	CodecNull    Codec = "null"
+	CodecDeflate Codec = "deflate"
+)
+
+const (
+	magic = "Obj\x01"
+	// blockSize is the encoded size at which a writer flushes its pending records as a block
+	blockSize = 64 << 10
+)
+
+// Writer streams registered models of type T into an Avro object container file
+type Writer[T registry.Model] struct {
+	w      io.Writer
+	codec  Codec
+	sync   [16]byte
+	encode encoder
+	block  []byte
+	count  int64
+}
+
+// NewWriter writes the file header, holding the record schema of T, and returns a writer for its
+// records. Records are buffered into blocks; Close writes the last of them.
+func NewWriter[T registry.Model](w io.Writer, codec Codec) (*Writer[T], error) {
+	if codec != CodecNull && codec != CodecDeflate {
+		return nil, fmt.Errorf("unsupported avro codec %q", codec)
+	}
+	schema, encode, err := modelSchema(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal avro schema: %w", err)
+	}
+
+	out := &Writer[T]{w: w, codec: codec, encode: encode}
+	if _, err := rand.Read(out.sync[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate sync marker: %w", err)
+	}
+
+	header := []byte(magic)
+	header = appendLong(header, 2)
+	header = appendBytes(appendString(header, "avro.codec"), []byte(codec))
+	header = appendBytes(appendString(header, "avro.schema"), schemaJSON)
+	header = appendLong(header, 0)
+	header = append(header, out.sync[:]...)
+	if _, err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write avro header: %w", err)
+	}
+	return out, nil
+}
+
+// Write appends a record to the current block, flushing the block once it is large enough
+func (w *Writer[T]) Write(m T) error {
+	v := reflect.ValueOf(m)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return fmt.Errorf("cannot write nil %T", m)
+		}
+		v = v.Elem()
+	}
+
+	block, err := w.encode(w.block, v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %T: %w", m, err)
+	}
+	w.block = block
+	w.count++
+	if len(w.block) >= blockSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes the pending records as a block
+func (w *Writer[T]) Flush() error {
+	if w.count == 0 {
+		return nil
+	}
+
+	data := w.block
+	if w.codec == CodecDeflate {
+		var buf bytes.Buffer
+		fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return fmt.Errorf("failed to compress avro block: %w", err)
+		}
+		if err := fw.Close(); err != nil {
+			return fmt.Errorf("failed to compress avro block: %w", err)
+		}
+		data = buf.Bytes()
+	}
+
+	out := appendBytes(appendLong(nil, w.count), data)
+	out = append(out, w.sync[:]...)
+	if _, err := w.w.Write(out); err != nil {
+		return fmt.Errorf("failed to write avro block: %w", err)
+	}
+	w.block, w.count = w.block[:0], 0
+	return nil
+}
+
+// Close flushes the pending records. It does not close the underlying writer.
+func (w *Writer[T]) Close() error {
+	return w.Flush()
+}
+
+// WriteOCF writes models to w as a complete Avro object container file
+func WriteOCF[T registry.Model](w io.Writer, models []T, codec Codec) error {
+	writer, err := NewWriter[T](w, codec)
+	if err != nil {
+		return err
+	}
+	for _, m := range models {
+		if err := writer.Write(m); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// Reader reads the records of an Avro object container file as generic values: records are
+// map[string]any, arrays []any, maps map[string]any, and unions the value of their branch.
+type Reader struct {
+	r         *bufio.Reader
+	schema    *Schema
+	defs      map[string]*Schema
+	codec     Codec
+	sync      [16]byte
+	block     decoder
+	remaining int64
+}
+
+// NewReader reads the header of an object container file
+func NewReader(r io.Reader) (*Reader, error) {
+	out := &Reader{r: bufio.NewReader(r)}
+
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(out.r, header); err != nil {
+		return nil, fmt.Errorf("failed to read avro header: %w", err)
+	}
+	if string(header) != magic {
+		return nil, errors.New("not an avro object container file")
+	}
+
+	metadata := make(map[string][]byte)
+	for {
+		count, err := out.readLong()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read avro metadata: %w", err)
+		}
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			count = -count
+			if _, err := out.readLong(); err != nil {
+				return nil, fmt.Errorf("failed to read avro metadata: %w", err)
+			}
+		}
+		for ; count > 0; count-- {
+			key, err := out.readBytes()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read avro metadata: %w", err)
+			}
+			value, err := out.readBytes()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read avro metadata: %w", err)
+			}
+			metadata[string(key)] = value
+		}
+	}
+	if _, err := io.ReadFull(out.r, out.sync[:]); err != nil {
+		return nil, fmt.Errorf("failed to read avro sync marker: %w", err)
+	}
+
+	out.schema = &Schema{}
+	if err := json.Unmarshal(metadata["avro.schema"], out.schema); err != nil {
+		return nil, fmt.Errorf("failed to parse avro schema: %w", err)
+	}
+	out.defs = out.schema.Definitions()
+	out.codec = CodecNull
+	if codec, ok := metadata["avro.codec"]; ok && len(codec) > 0 {
+		out.codec = Codec(codec)
+	}
+	if out.codec != CodecNull && out.codec != CodecDeflate {
+		return nil, fmt.Errorf("unsupported avro codec %q", out.codec)
+	}
+	return out, nil
+}
+
+// Schema returns the schema the file was written with
+func (r *Reader) Schema() *Schema {
+	return r.schema
+}
+
+// Read returns the next record, or io.EOF at the end of the file
+func (r *Reader) Read() (any, error) {
+	for r.remaining == 0 {
+		if err := r.nextBlock(); err != nil {
+			return nil, err
+		}
+	}
+
+	value, err := decodeValue(&r.block, r.schema, r.defs)
+	if err != nil {
+		return nil, err
+	}
+	r.remaining--
+	if r.remaining == 0 && len(r.block.data) > 0 {
+		return nil, errors.New("avro block has trailing data")
+	}
+	return value, nil
+}
+
+func (r *Reader) nextBlock() error {
+	count, err := r.readLong()
+	if errors.Is(err, io.EOF) {
+		return io.EOF
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read avro block: %w", err)
+	}
+	data, err := r.readBytes()
+	if err != nil {
+		return fmt.Errorf("failed to read avro block: %w", err)
+	}
+	var sync [16]byte
+	if _, err := io.ReadFull(r.r, sync[:]); err != nil {
+		return fmt.Errorf("failed to read avro sync marker: %w", err)
+	}
+	if sync != r.sync {
+		return errors.New("avro sync marker mismatch")
+	}
+
+	if r.codec == CodecDeflate {
+		if data, err = io.ReadAll(flate.NewReader(bytes.NewReader(data))); err != nil {
+			return fmt.Errorf("failed to decompress avro block: %w", err)
+		}
+	}
+	r.block = decoder{data: data}
+	r.remaining = count
+	return nil
+}
+
+func (r *Reader) readLong() (int64, error) {
+	u, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return 0, err
+	}
+	return int64(u>>1) ^ -int64(u&1), nil
+}
+
+func (r *Reader) readBytes() ([]byte, error) {
+	n, err := r.readLong()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, errTruncated
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func decodeValue(d *decoder, s *Schema, defs map[string]*Schema) (any, error) {
+	if s.Union != nil {
+		i, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(s.Union)) {
+			return nil, fmt.Errorf("union branch %d out of range", i)
+		}
+		return decodeValue(d, s.Union[i], defs)
+	}
+
+	switch s.Type {
+	case TypeNull:
+		return nil, nil
+	case TypeBoolean:
+		b, err := d.fixed(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case TypeInt:
+		n, err := d.long()
+		return int32(n), err
+	case TypeLong:
+		return d.long()
+	case TypeFloat:
+		b, err := d.fixed(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+	case TypeDouble:
+		b, err := d.fixed(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case TypeBytes:
+		b, err := d.bytes()
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, b...), nil
+	case TypeString:
+		b, err := d.bytes()
+		return string(b), err
+	case TypeFixed:
+		b, err := d.fixed(s.Size)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, b...), nil
+	case TypeEnum:
+		i, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(s.Symbols)) {
+			return nil, fmt.Errorf("enum symbol %d out of range", i)
+		}
+		return s.Symbols[i], nil
+	case TypeRecord:
+		out := make(map[string]any, len(s.Fields))
+		for _, f := range s.Fields {
+			value, err := decodeValue(d, f.Type, defs)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", s.Name, f.Name, err)
+			}
+			out[f.Name] = value
+		}
+		return out, nil
+	case TypeArray:
+		out := []any{}
+		for {
+			count, err := d.blockCount()
+			if err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return out, nil
+			}
+			for ; count > 0; count-- {
+				item, err := decodeValue(d, s.Items, defs)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, item)
+			}
+		}
+	case TypeMap:
+		out := map[string]any{}
+		for {
+			count, err := d.blockCount()
+			if err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return out, nil
+			}
+			for ; count > 0; count-- {
+				key, err := d.bytes()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeValue(d, s.Values, defs)
+				if err != nil {
+					return nil, err
+				}
+				out[string(key)] = value
+			}
+		}
+	}
+
+	def, ok := defs[s.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown avro type %q", s.Type)
+	}
+	return decodeValue(d, def, defs)
+}
@@ -0,0 +1,192 @@
+package avro_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/avro"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll reads every record of an object container file
+func readAll(t *testing.T, data []byte) (*avro.Schema, []map[string]any) {
+	reader, err := avro.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	var records []map[string]any
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		records = append(records, record.(map[string]any))
+	}
+	return reader.Schema(), records
+}
+
+func TestWriteOCF_Risk(t *testing.T) {
+	asset := model.NewAsset("example.com", "1.2.3.4")
+	risk := model.NewRisk(&asset, "cve-2024-1234", model.TriageHigh)
+	risk.Capability = []string{"nuclei"}
+	risk.History.Update("", model.TriageHigh, "user@example.com", "found", model.History{})
+	other := model.NewRisk(&asset, "cve-2024-5678", model.TriageLow)
+
+	for _, codec := range []avro.Codec{avro.CodecNull, avro.CodecDeflate} {
+		t.Run(string(codec), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, avro.WriteOCF(&buf, []*model.Risk{&risk, &other}, codec))
+
+			schema, records := readAll(t, buf.Bytes())
+			expected, err := avro.GenerateSchema("risk")
+			require.NoError(t, err)
+			assert.Equal(t, expected, schema)
+			require.Len(t, records, 2)
+
+			record := records[0]
+			assert.Equal(t, risk.Key, record["key"])
+			assert.Equal(t, model.TriageHigh, record["status"])
+			assert.Equal(t, int64(risk.Priority), record["priority"])
+			assert.Equal(t, []any{"nuclei"}, record["originationData"].(map[string]any)["capability"])
+
+			history := record["history"].(map[string]any)
+			require.Len(t, history["history"], 1)
+			event := history["history"].([]any)[0].(map[string]any)
+			assert.Equal(t, model.TriageHigh, event["to"])
+			assert.Equal(t, "user@example.com", event["by"])
+			assert.Nil(t, event["base"])
+			assert.Nil(t, history["remove"])
+
+			assert.Equal(t, other.Key, records[1]["key"])
+		})
+	}
+}
+
+func TestWriteOCF_Asset(t *testing.T) {
+	asset := model.NewAsset("example.com", "1.2.3.4")
+	asset.Metadata.ASName = "EXAMPLE"
+	secret := "#secret"
+	asset.Secret = &secret
+
+	var buf bytes.Buffer
+	require.NoError(t, avro.WriteOCF(&buf, []*model.Asset{&asset}, avro.CodecDeflate))
+
+	_, records := readAll(t, buf.Bytes())
+	require.Len(t, records, 1)
+	record := records[0]
+
+	// BaseAsset's fields are the asset's own, while Metadata is nested
+	assert.Equal(t, asset.Key, record["key"])
+	assert.Equal(t, "1.2.3.4", record["name"])
+	assert.Equal(t, "#secret", record["secret"])
+	assert.Equal(t, "EXAMPLE", record["metadata"].(map[string]any)["asname"])
+	assert.NotContains(t, record, "asname")
+}
+
+func TestWriteOCF_Statistic(t *testing.T) {
+	statistic := model.NewStatistic("asset_count", "global", "all", "2024-01-01T00:00:00Z")
+	statistic.Data = model.Data{Count: 150, Counts: map[string]int{"active": 100}, Values: map[string]float64{"score": 8.5}}
+
+	var buf bytes.Buffer
+	require.NoError(t, avro.WriteOCF(&buf, []*model.Statistic{&statistic}, avro.CodecNull))
+
+	_, records := readAll(t, buf.Bytes())
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string]any{
+		"count":  int64(150),
+		"counts": map[string]any{"active": int64(100)},
+		"values": map[string]any{"score": 8.5},
+	}, records[0]["data"])
+	assert.Equal(t, statistic.TTL, records[0]["ttl"])
+}
+
+func TestWriteOCF_Job(t *testing.T) {
+	asset := model.NewAsset("example.com", "1.2.3.4")
+	job := model.NewJob("portscan", &asset)
+	job.Config = map[string]string{"test": "value"}
+
+	var buf bytes.Buffer
+	require.NoError(t, avro.WriteOCF(&buf, []*model.Job{&job}, avro.CodecDeflate))
+
+	_, records := readAll(t, buf.Bytes())
+	require.Len(t, records, 1)
+	record := records[0]
+	assert.Equal(t, job.Key, record["key"])
+	assert.Equal(t, map[string]any{"test": "value"}, record["config"])
+
+	// wrappers are carried as their JSON encoding
+	var target model.TargetWrapper
+	require.NoError(t, json.Unmarshal([]byte(record["target"].(string)), &target))
+	assert.Equal(t, asset.Key, target.Model.GetKey())
+}
+
+func TestWriter_Blocks(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := avro.NewWriter[*model.Asset](&buf, avro.CodecDeflate)
+	require.NoError(t, err)
+
+	// enough records to span several blocks
+	const count = 2000
+	for i := 0; i < count; i++ {
+		asset := model.NewAsset(fmt.Sprintf("host-%d.example.com", i), fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+		require.NoError(t, writer.Write(&asset))
+	}
+	require.NoError(t, writer.Close())
+
+	_, records := readAll(t, buf.Bytes())
+	require.Len(t, records, count)
+	assert.Equal(t, "host-1999.example.com", records[count-1]["dns"])
+}
+
+func TestWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, avro.WriteOCF(&buf, []*model.Asset{}, avro.CodecNull))
+
+	schema, records := readAll(t, buf.Bytes())
+	assert.Equal(t, "Asset", schema.Name)
+	assert.Empty(t, records)
+}
+
+func TestNewWriter_UnsupportedCodec(t *testing.T) {
+	_, err := avro.NewWriter[*model.Asset](io.Discard, "snappy")
+	assert.Error(t, err)
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	_, err := avro.NewReader(bytes.NewReader([]byte("not avro")))
+	assert.Error(t, err)
+
+	asset := model.NewAsset("example.com", "1.2.3.4")
+	var buf bytes.Buffer
+	require.NoError(t, avro.WriteOCF(&buf, []*model.Asset{&asset}, avro.CodecNull))
+
+	// corrupting the block's sync marker is detected
+	data := buf.Bytes()
+	data[len(data)-1] ^= 0xff
+	reader, err := avro.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	_, err = reader.Read()
+	assert.ErrorContains(t, err, "sync marker")
+}
+
+func TestGenerateSchema(t *testing.T) {
+	_, err := avro.GenerateSchema("notamodel")
+	assert.Error(t, err)
+
+	s, err := avro.GenerateSchema("asset")
+	require.NoError(t, err)
+	names := make(map[string]bool)
+	for _, f := range s.Fields {
+		names[f.Name] = true
+	}
+	assert.True(t, names["key"])
+	assert.True(t, names["metadata"])
+	assert.True(t, names["history"])
+	assert.False(t, names["asname"])
+	assert.Contains(t, s.Definitions(), "tabularium.model.HistoryRecord")
+}
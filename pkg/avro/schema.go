@@ -0,0 +1,381 @@
+package avro
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+var (
+	jsonMarshaler = reflect.TypeFor[json.Marshaler]()
+	textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+	modelType     = reflect.TypeFor[registry.Model]()
+
+	// nonName matches runs of characters that cannot appear in Avro names
+	nonName = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// encoder appends the Avro binary encoding of v
+type encoder func(b []byte, v reflect.Value) ([]byte, error)
+
+type generator struct {
+	records map[reflect.Type]*record
+	names   map[string]reflect.Type
+}
+
+func newGenerator() *generator {
+	return &generator{records: make(map[reflect.Type]*record), names: make(map[string]reflect.Type)}
+}
+
+// record is a struct type mapped to an Avro record
+type record struct {
+	schema *Schema
+	fields []recordField
+}
+
+type recordField struct {
+	index  []int
+	typ    reflect.Type
+	encode encoder
+}
+
+func (r *record) encode(b []byte, v reflect.Value) ([]byte, error) {
+	var err error
+	for i, f := range r.fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok {
+			// promoted through a nil embedded pointer, as encoding/json skips it
+			fv = reflect.Zero(f.typ)
+		}
+		if b, err = f.encode(b, fv); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", r.schema.Name, r.schema.Fields[i].Name, err)
+		}
+	}
+	return b, nil
+}
+
+// record generates the record of a struct type. Records are defined where they first appear in a
+// schema and referenced by full name afterwards.
+func (g *generator) record(typ reflect.Type, fallback string) *record {
+	name := typeName(typ.Name())
+	if name == "" {
+		name = typeName(fallback)
+	}
+	namespace := Namespace
+	if typ.PkgPath() != "" {
+		namespace += "." + nonName.ReplaceAllString(path.Base(typ.PkgPath()), "_")
+	}
+	for i := 2; ; i++ {
+		if _, taken := g.names[namespace+"."+name]; !taken {
+			break
+		}
+		name = fmt.Sprintf("%s%d", strings.TrimRight(name, "0123456789"), i)
+	}
+	g.names[namespace+"."+name] = typ
+
+	rec := &record{schema: &Schema{Type: TypeRecord, Name: name, Namespace: namespace, Fields: []*Field{}}}
+	// registered before its fields are generated, so recursive types reference it by name
+	g.records[typ] = rec
+	if reflect.PointerTo(typ).Implements(modelType) {
+		rec.schema.Doc = reflect.New(typ).Interface().(registry.Model).GetDescription()
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range avroFields(typ) {
+		s, encode, ok := g.value(f.typ, name+typeName(f.name))
+		if !ok {
+			continue
+		}
+		field := &Field{Name: uniqueName(fieldName(f.name), seen), Doc: f.doc, Type: s}
+		if s.Union != nil {
+			field.Default = json.RawMessage("null")
+		}
+		rec.schema.Fields = append(rec.schema.Fields, field)
+		rec.fields = append(rec.fields, recordField{index: f.index, typ: f.typ, encode: encode})
+	}
+	return rec
+}
+
+// value returns the schema and encoder of a Go type. It reports false for types that cannot be
+// encoded, such as channels and functions.
+func (g *generator) value(typ reflect.Type, fallback string) (*Schema, encoder, bool) {
+	if _, ok := wrappedInterface(typ); ok {
+		return &Schema{Type: TypeString}, encodeJSON, true
+	}
+	if typ.Kind() != reflect.Ptr && typ.Kind() != reflect.Interface {
+		// text is preferred, so that values such as time.Time are not quoted twice
+		if implements(typ, textMarshaler) {
+			return &Schema{Type: TypeString}, encodeText, true
+		}
+		if implements(typ, jsonMarshaler) {
+			return &Schema{Type: TypeString}, encodeJSON, true
+		}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}, encodeBool, true
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: TypeInt}, encodeInt, true
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: TypeLong}, encodeInt, true
+	case reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: TypeInt}, encodeUint, true
+	case reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: TypeLong}, encodeUint, true
+	case reflect.Float32:
+		return &Schema{Type: TypeFloat}, encodeFloat, true
+	case reflect.Float64:
+		return &Schema{Type: TypeDouble}, encodeDouble, true
+	case reflect.String:
+		return &Schema{Type: TypeString}, encodeString, true
+	case reflect.Interface:
+		return &Schema{Type: TypeString}, encodeJSON, true
+	case reflect.Slice, reflect.Array:
+		if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: TypeBytes}, encodeBytes, true
+		}
+		items, encodeItem, ok := g.value(typ.Elem(), fallback+"Item")
+		if !ok {
+			return nil, nil, false
+		}
+		return &Schema{Type: TypeArray, Items: items}, arrayEncoder(encodeItem), true
+	case reflect.Map:
+		key, ok := mapKey(typ.Key())
+		if !ok {
+			return &Schema{Type: TypeString}, encodeJSON, true
+		}
+		values, encodeValue, ok := g.value(typ.Elem(), fallback+"Value")
+		if !ok {
+			return nil, nil, false
+		}
+		return &Schema{Type: TypeMap, Values: values}, mapEncoder(key, encodeValue), true
+	case reflect.Struct:
+		if rec, ok := g.records[typ]; ok {
+			return &Schema{Type: rec.schema.FullName()}, rec.encode, true
+		}
+		rec := g.record(typ, fallback)
+		return rec.schema, rec.encode, true
+	case reflect.Ptr:
+		elem := typ.Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		s, encode, ok := g.value(elem, fallback)
+		if !ok {
+			return nil, nil, false
+		}
+		return &Schema{Union: []*Schema{{Type: TypeNull}, s}}, optionalEncoder(encode), true
+	}
+	return nil, nil, false
+}
+
+// implements reports whether typ, or a pointer to it, implements iface. encoding/json calls
+// pointer methods on addressable values, which every field of a model is.
+func implements(typ, iface reflect.Type) bool {
+	return typ.Implements(iface) || reflect.PointerTo(typ).Implements(iface)
+}
+
+// mapKey returns how map keys of typ are converted to strings, following encoding/json
+func mapKey(typ reflect.Type) (func(reflect.Value) (string, error), bool) {
+	switch {
+	case typ.Kind() == reflect.String:
+		return func(v reflect.Value) (string, error) { return v.String(), nil }, true
+	case implements(typ, textMarshaler):
+		return func(v reflect.Value) (string, error) {
+			text, err := addressable(v).Interface().(encoding.TextMarshaler).MarshalText()
+			return string(text), err
+		}, true
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) (string, error) { return strconv.FormatInt(v.Int(), 10), nil }, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(v reflect.Value) (string, error) { return strconv.FormatUint(v.Uint(), 10), nil }, true
+	}
+	return nil, false
+}
+
+// wrappedInterface returns the interface wrapped by a registry.Wrapper, or a type defined from one
+func wrappedInterface(typ reflect.Type) (reflect.Type, bool) {
+	if typ.Kind() != reflect.Struct || typ.NumField() < 3 {
+		return nil, false
+	}
+	model, typeField, skip := typ.Field(0), typ.Field(1), typ.Field(2)
+	if model.Name != "Model" || typeField.Name != "Type" || skip.Name != "SkipDefaulting" {
+		return nil, false
+	}
+	if model.Type.Kind() != reflect.Interface || !model.Type.Implements(modelType) {
+		return nil, false
+	}
+	return model.Type, true
+}
+
+type structField struct {
+	name  string
+	doc   string
+	typ   reflect.Type
+	index []int
+}
+
+// avroFields lists the fields of typ. Fields are named by their json tags, and shallower fields
+// take precedence over promoted ones as in encoding/json.
+func avroFields(typ reflect.Type) []structField {
+	var all []structField
+	collectFields(typ, nil, &all)
+
+	byName := make(map[string]int)
+	var out []structField
+	for _, f := range all {
+		if i, ok := byName[f.name]; ok {
+			if len(f.index) < len(out[i].index) {
+				out[i] = f
+			}
+			continue
+		}
+		byName[f.name] = len(out)
+		out = append(out, f)
+	}
+	return out
+}
+
+func collectFields(typ reflect.Type, index []int, out *[]structField) {
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if sf.Anonymous && name == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Ptr {
+				if !sf.IsExported() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if !sf.IsExported() || strings.HasPrefix(embedded.Name(), "Base") {
+					collectFields(embedded, fieldIndex, out)
+					continue
+				}
+				if len(avroFields(embedded)) == 0 {
+					continue
+				}
+				*out = append(*out, structField{name: lowerCamel(embedded.Name()), typ: sf.Type, index: fieldIndex})
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		*out = append(*out, structField{name: name, doc: sf.Tag.Get("desc"), typ: sf.Type, index: fieldIndex})
+	}
+}
+
+// fieldByIndex returns the field at index, reporting false if it is promoted through a nil pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldName converts a JSON field name into an Avro name
+func fieldName(name string) string {
+	name = nonName.ReplaceAllString(name, "_")
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "_" + name
+	}
+	return name
+}
+
+// typeName converts a Go type name into an Avro record name
+func typeName(name string) string {
+	var b strings.Builder
+	for _, part := range nonName.Split(name, -1) {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	if b.Len() > 0 && unicode.IsDigit(rune(b.String()[0])) {
+		return "_" + b.String()
+	}
+	return b.String()
+}
+
+// lowerCamel lowercases the leading word of a type name, keeping acronyms together:
+// MLProperties becomes mlProperties
+func lowerCamel(name string) string {
+	upper := 0
+	for upper < len(name) && unicode.IsUpper(rune(name[upper])) {
+		upper++
+	}
+	switch {
+	case upper == 0:
+		return name
+	case upper == len(name):
+		return strings.ToLower(name)
+	case upper > 1:
+		upper--
+	}
+	return strings.ToLower(name[:upper]) + name[upper:]
+}
+
+func uniqueName(name string, seen map[string]bool) string {
+	unique := name
+	for i := 2; seen[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	seen[unique] = true
+	return unique
+}
+
+// sortedKeys returns the keys of a map value converted to strings, in order
+func sortedKeys(v reflect.Value, key func(reflect.Value) (string, error)) ([]string, []reflect.Value, error) {
+	keys := v.MapKeys()
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		name, err := key(k)
+		if err != nil {
+			return nil, nil, err
+		}
+		names[i] = name
+	}
+	sort.Sort(byName{names, keys})
+	return names, keys, nil
+}
+
+type byName struct {
+	names []string
+	keys  []reflect.Value
+}
+
+func (b byName) Len() int           { return len(b.names) }
+func (b byName) Less(i, j int) bool { return b.names[i] < b.names[j] }
+func (b byName) Swap(i, j int) {
+	b.names[i], b.names[j] = b.names[j], b.names[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
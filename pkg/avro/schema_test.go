@@ -0,0 +1,170 @@
+package avro
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type BaseSample struct {
+	Key string `json:"key" desc:"The sample key"`
+}
+
+type Audit struct {
+	By   string    `json:"by"`
+	When time.Time `json:"when"`
+}
+
+type Node struct {
+	Value string `json:"value"`
+	Next  *Node  `json:"next"`
+}
+
+type Sample struct {
+	registry.BaseModel
+	BaseSample
+	Audit
+	Name     string                           `json:"name" desc:"The sample name"`
+	Count    int                              `json:"count"`
+	Small    int32                            `json:"small"`
+	Ratio    float64                          `json:"ratio"`
+	Enabled  bool                             `json:"enabled"`
+	Tags     []string                         `json:"tags"`
+	Labels   map[string]int                   `json:"labels"`
+	Ports    map[int]string                   `json:"ports"`
+	Raw      []byte                           `json:"raw"`
+	Any      any                              `json:"any"`
+	Optional *float32                         `json:"optional"`
+	Head     *Node                            `json:"head"`
+	Nodes    []Node                           `json:"nodes"`
+	Parent   registry.Wrapper[registry.Model] `json:"parent"`
+	Invalid  string                           `json:"invalid-name"`
+	Ignored  string                           `json:"-"`
+	hidden   string
+}
+
+func (s *Sample) GetDescription() string { return "A sample model" }
+
+func TestModelSchema(t *testing.T) {
+	s, _, err := modelSchema(reflect.TypeFor[*Sample]())
+	require.NoError(t, err)
+
+	expected := `{
+		"type": "record", "name": "Sample", "namespace": "tabularium.avro", "doc": "A sample model",
+		"fields": [
+			{"name": "key", "doc": "The sample key", "type": "string"},
+			{"name": "audit", "type": {
+				"type": "record", "name": "Audit", "namespace": "tabularium.avro",
+				"fields": [
+					{"name": "by", "type": "string"},
+					{"name": "when", "type": "string"}
+				]
+			}},
+			{"name": "name", "doc": "The sample name", "type": "string"},
+			{"name": "count", "type": "long"},
+			{"name": "small", "type": "int"},
+			{"name": "ratio", "type": "double"},
+			{"name": "enabled", "type": "boolean"},
+			{"name": "tags", "type": {"type": "array", "items": "string"}},
+			{"name": "labels", "type": {"type": "map", "values": "long"}},
+			{"name": "ports", "type": {"type": "map", "values": "string"}},
+			{"name": "raw", "type": "bytes"},
+			{"name": "any", "type": "string"},
+			{"name": "optional", "type": ["null", "float"], "default": null},
+			{"name": "head", "type": ["null", {
+				"type": "record", "name": "Node", "namespace": "tabularium.avro",
+				"fields": [
+					{"name": "value", "type": "string"},
+					{"name": "next", "type": ["null", "tabularium.avro.Node"], "default": null}
+				]
+			}], "default": null},
+			{"name": "nodes", "type": {"type": "array", "items": "tabularium.avro.Node"}},
+			{"name": "parent", "type": "string"},
+			{"name": "invalid_name", "type": "string"}
+		]
+	}`
+	actual, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.JSONEq(t, expected, string(actual))
+
+	var parsed Schema
+	require.NoError(t, json.Unmarshal(actual, &parsed))
+	assert.Equal(t, s, &parsed)
+	assert.Contains(t, parsed.Definitions(), "tabularium.avro.Node")
+	assert.Contains(t, parsed.Definitions(), "tabularium.avro.Audit")
+}
+
+func TestEncode(t *testing.T) {
+	logit := float32(0.5)
+	sample := Sample{
+		BaseSample: BaseSample{Key: "#sample#1"},
+		Audit:      Audit{By: "user@example.com", When: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		Name:       "sample",
+		Count:      -42,
+		Small:      7,
+		Ratio:      0.25,
+		Enabled:    true,
+		Tags:       []string{"a", "b"},
+		Labels:     map[string]int{"y": 2, "x": 1},
+		Ports:      map[int]string{443: "https"},
+		Raw:        []byte{0, 1, 2},
+		Any:        map[string]any{"nested": true},
+		Optional:   &logit,
+		Head:       &Node{Value: "first", Next: &Node{Value: "second"}},
+		Parent:     registry.Wrapper[registry.Model]{},
+		Invalid:    "invalid",
+	}
+
+	s, encode, err := modelSchema(reflect.TypeFor[*Sample]())
+	require.NoError(t, err)
+	data, err := encode(nil, reflect.ValueOf(sample))
+	require.NoError(t, err)
+
+	d := &decoder{data: data}
+	decoded, err := decodeValue(d, s, s.Definitions())
+	require.NoError(t, err)
+	assert.Empty(t, d.data)
+
+	parent, err := json.Marshal(sample.Parent)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"key":      "#sample#1",
+		"audit":    map[string]any{"by": "user@example.com", "when": "2024-01-02T03:04:05Z"},
+		"name":     "sample",
+		"count":    int64(-42),
+		"small":    int32(7),
+		"ratio":    0.25,
+		"enabled":  true,
+		"tags":     []any{"a", "b"},
+		"labels":   map[string]any{"x": int64(1), "y": int64(2)},
+		"ports":    map[string]any{"443": "https"},
+		"raw":      []byte{0, 1, 2},
+		"any":      `{"nested":true}`,
+		"optional": float32(0.5),
+		"head": map[string]any{
+			"value": "first",
+			"next":  map[string]any{"value": "second", "next": nil},
+		},
+		"nodes":        []any{},
+		"parent":       string(parent),
+		"invalid_name": "invalid",
+	}, decoded)
+}
+
+func TestLowerCamel(t *testing.T) {
+	tests := map[string]string{
+		"History":         "history",
+		"OriginationData": "originationData",
+		"MLProperties":    "mlProperties",
+		"ID":              "id",
+		"already":         "already",
+	}
+	for name, expected := range tests {
+		assert.Equal(t, expected, lowerCamel(name), name)
+	}
+}
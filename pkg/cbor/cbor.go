@@ -0,0 +1,231 @@
+// Package cbor encodes registered models as CBOR (RFC 8949) with the data model of their JSON
+// encoding, using github.com/fxamacker/cbor/v2. Structs become maps keyed by their json field
+// names, honoring omitempty, omitzero and "-", times become RFC 3339 text, and types implementing
+// json.Marshaler, such as wrappers, are encoded as their JSON is, transcoded to CBOR. Any CBOR
+// library therefore decodes a payload into the values a JSON library would decode its JSON into,
+// except that byte slices outside wrappers are byte strings rather than base64 text, integers
+// stay integers, and maps keep the types of their keys. Unlike gob, the encoding does not depend
+// on Go types, so models may gain and lose fields between encoder and decoder as they may with
+// JSON.
+package cbor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// transcoder converts a single data item between JSON and CBOR
+type transcoder func(dst io.Writer, src io.Reader) error
+
+func (t transcoder) Transcode(dst io.Writer, src io.Reader) error { return t(dst, src) }
+
+var encOptions = cbor.EncOptions{
+	Sort:          cbor.SortCoreDeterministic,
+	ShortestFloat: cbor.ShortestFloat16,
+	NaNConvert:    cbor.NaNConvertReject,
+	InfConvert:    cbor.InfConvertReject,
+	Time:          cbor.TimeRFC3339Nano,
+	OmitEmpty:     cbor.OmitEmptyGoValue,
+	TextMarshaler: cbor.TextMarshalerTextString,
+}
+
+// decOptions decode the content of tags JSON has no counterpart for, as a JSON library would
+// decode a JSON encoding of it
+var decOptions = cbor.DecOptions{
+	DefaultMapType:       reflect.TypeFor[map[string]any](),
+	TextUnmarshaler:      cbor.TextUnmarshalerTextString,
+	UnrecognizedTagToAny: cbor.UnrecognizedTagContentToAny,
+}
+
+var (
+	// valueEnc and valueDec encode and decode the generic values transcoded between JSON and CBOR
+	valueEnc = mustEncMode(encOptions)
+	valueDec = mustDecMode(cbor.DecOptions{UnrecognizedTagToAny: cbor.UnrecognizedTagContentToAny})
+
+	encMode = mustEncMode(withJSONMarshaler(encOptions))
+	decMode = mustDecMode(withJSONUnmarshaler(decOptions))
+)
+
+func withJSONMarshaler(opts cbor.EncOptions) cbor.EncOptions {
+	opts.JSONMarshalerTranscoder = transcoder(jsonToCBOR)
+	return opts
+}
+
+func withJSONUnmarshaler(opts cbor.DecOptions) cbor.DecOptions {
+	opts.JSONUnmarshalerTranscoder = transcoder(cborToJSON)
+	return opts
+}
+
+func mustEncMode(opts cbor.EncOptions) cbor.EncMode {
+	mode, err := opts.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
+
+func mustDecMode(opts cbor.DecOptions) cbor.DecMode {
+	mode, err := opts.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
+
+// Codec encodes and decodes values, resolving the types of wrappers with a registry
+type Codec struct {
+	registry *registry.TypeRegistry
+}
+
+// NewCodec returns a codec resolving the types of wrappers with r
+func NewCodec(r *registry.TypeRegistry) *Codec {
+	return &Codec{registry: r}
+}
+
+var defaultCodec = NewCodec(registry.Registry)
+
+// Marshal encodes v, resolving the types of wrappers with registry.Registry
+func Marshal(v any) ([]byte, error) {
+	return defaultCodec.Marshal(v)
+}
+
+// Unmarshal decodes data into v, resolving the types of wrappers with registry.Registry
+func Unmarshal(data []byte, v any) error {
+	return defaultCodec.Unmarshal(data, v)
+}
+
+// Marshal encodes v as encoding/json would encode it, in CBOR
+func (c *Codec) Marshal(v any) ([]byte, error) {
+	return encMode.Marshal(v)
+}
+
+// Unmarshal decodes data into the non-nil pointer v as encoding/json would decode its JSON. The
+// models of wrappers are defaulted unless the wrapper skips defaulting, but as with json.Unmarshal,
+// hooks are not called.
+func (c *Codec) Unmarshal(data []byte, v any) error {
+	if c.registry == registry.Registry {
+		return decMode.Unmarshal(data, v)
+	}
+
+	// wrappers created while decoding resolve their types with the process registry, so decode
+	// the JSON of data as the registry binds wrappers to itself
+	encoded, err := toJSON(data)
+	if err != nil {
+		return err
+	}
+	return c.registry.Unmarshal(encoded, v)
+}
+
+// jsonToCBOR transcodes the JSON of a json.Marshaler, keeping integers apart from floats
+func jsonToCBOR(dst io.Writer, src io.Reader) error {
+	d := json.NewDecoder(src)
+	d.UseNumber()
+	var value any
+	if err := d.Decode(&value); err != nil {
+		return err
+	}
+	value, err := fromNumbers(value)
+	if err != nil {
+		return err
+	}
+	data, err := valueEnc.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = dst.Write(data)
+	return err
+}
+
+// cborToJSON transcodes a data item for a json.Unmarshaler
+func cborToJSON(dst io.Writer, src io.Reader) error {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	encoded, err := toJSON(data)
+	if err != nil {
+		return err
+	}
+	_, err = dst.Write(encoded)
+	return err
+}
+
+func toJSON(data []byte) ([]byte, error) {
+	var value any
+	if err := valueDec.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	value, err := toJSONValue(value)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// fromNumbers replaces the json.Numbers in value with integers where they are integral
+func fromNumbers(value any) (any, error) {
+	var err error
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return i, nil
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return u, nil
+		}
+		return v.Float64()
+	case []any:
+		for i := range v {
+			if v[i], err = fromNumbers(v[i]); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]any:
+		for key := range v {
+			if v[key], err = fromNumbers(v[key]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return value, nil
+}
+
+// toJSONValue replaces the maps in value with maps keyed by text, as JSON keys them
+func toJSONValue(value any) (any, error) {
+	var err error
+	switch v := value.(type) {
+	case []any:
+		for i := range v {
+			if v[i], err = toJSONValue(v[i]); err != nil {
+				return nil, err
+			}
+		}
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, elem := range v {
+			switch key.(type) {
+			case string, int64, uint64:
+			default:
+				return nil, fmt.Errorf("cbor: cannot transcode map key of type %T to JSON", key)
+			}
+			if m[fmt.Sprint(key)], err = toJSONValue(elem); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	return value, nil
+}
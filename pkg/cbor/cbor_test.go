@@ -0,0 +1,537 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	fxcbor "github.com/fxamacker/cbor/v2"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/praetorian-inc/tabularium/pkg/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type level string
+
+func (l level) MarshalText() ([]byte, error) { return []byte(strings.ToUpper(string(l))), nil }
+
+func (l *level) UnmarshalText(text []byte) error {
+	*l = level(strings.ToLower(string(text)))
+	return nil
+}
+
+// either is a string or a list of strings in JSON, like filters.SliceOrValue
+type either []string
+
+func (e either) MarshalJSON() ([]byte, error) {
+	if len(e) == 1 {
+		return json.Marshal(e[0])
+	}
+	return json.Marshal([]string(e))
+}
+
+func (e *either) UnmarshalJSON(data []byte) error {
+	var one string
+	if json.Unmarshal(data, &one) == nil {
+		*e = either{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(e))
+}
+
+type common struct {
+	A       int    `json:"a"`
+	Key     string `json:"key"`
+	Created string `json:"created"`
+}
+
+type Details struct {
+	Notes string `json:"notes"`
+}
+
+type sample struct {
+	registry.BaseModel
+	common
+	*Details
+	Name     string                             `json:"name"`
+	Count    int                                `json:"count,omitempty"`
+	Ratio    float64                            `json:"ratio"`
+	Small    float32                            `json:"small"`
+	Enabled  bool                               `json:"enabled"`
+	Tags     []string                           `json:"tags"`
+	Raw      []byte                             `json:"raw"`
+	Ports    map[int]string                     `json:"ports"`
+	Labels   map[level]int                      `json:"labels,omitempty"`
+	Level    level                              `json:"level"`
+	Either   either                             `json:"either"`
+	Any      any                                `json:"any"`
+	When     time.Time                          `json:"when"`
+	Zero     time.Time                          `json:"zero,omitzero"`
+	Optional *int64                             `json:"optional"`
+	Child    *sample                            `json:"child,omitempty"`
+	Parent   registry.Wrapper[registry.Model]   `json:"parent"`
+	Related  []registry.Wrapper[registry.Model] `json:"related,omitempty"`
+	Untagged string
+	Ignored  string `json:"-"`
+	internal string
+}
+
+func (s *sample) GetDescription() string { return "A model of every field shape" }
+func (s *sample) Defaulted()             { s.Enabled = true }
+
+type other struct {
+	registry.BaseModel
+	Key   string `json:"key"`
+	Value int    `json:"value"`
+}
+
+func (o *other) GetDescription() string { return "A model to wrap" }
+
+func testRegistry() *registry.TypeRegistry {
+	r := registry.NewTypeRegistry()
+	r.MustRegisterModel(&sample{})
+	r.MustRegisterModel(&other{}, "alias")
+	return r
+}
+
+func fullSample() *sample {
+	optional := int64(-7)
+	return &sample{
+		common:   common{Key: "#sample#a", Created: "2024-01-02T03:04:05Z"},
+		Details:  &Details{Notes: "notes"},
+		Name:     "a",
+		Count:    -42,
+		Ratio:    0.1,
+		Small:    1.5,
+		Enabled:  true,
+		Tags:     []string{"x", ""},
+		Raw:      []byte{0, 1, 2},
+		Ports:    map[int]string{443: "https", 80: "http"},
+		Labels:   map[level]int{"high": 1},
+		Level:    "low",
+		Either:   either{"one"},
+		Any:      map[string]any{"nested": []any{"value", 1.5, true, nil}},
+		When:     time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		Optional: &optional,
+		Child:    &sample{Name: "child", Either: either{"a", "b"}},
+		Parent:   registry.Wrapper[registry.Model]{Type: "alias", Model: &other{Key: "#other#b", Value: 1}},
+		Related: []registry.Wrapper[registry.Model]{
+			{Model: &other{Key: "#other#c"}},
+			{Model: &sample{Name: "wrapped"}},
+		},
+		Untagged: "untagged",
+		Ignored:  "ignored",
+		internal: "internal",
+	}
+}
+
+func TestMarshal_Encoding(t *testing.T) {
+	tests := []struct {
+		value any
+		hex   string
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{1000, "1903e8"},
+		{-1, "20"},
+		{-1000, "3903e7"},
+		{uint64(1) << 40, "1b0000010000000000"},
+		{1.5, "f93e00"},
+		{100000.0, "fa47c35000"},
+		{0.1, "fb3fb999999999999a"},
+		{true, "f5"},
+		{"a", "6161"},
+		{[]byte{1, 2}, "420102"},
+		{[]int(nil), "f6"},
+		{[]int{1, 2}, "820102"},
+		{map[string]int{"b": 2, "a": 1}, "a2616101616202"},
+		{struct {
+			A int    `json:"a"`
+			B string `json:"b,omitempty"`
+			C bool   `json:"-"`
+			d int
+		}{A: 1, C: true, d: 2}, "a1616101"},
+	}
+	for _, tt := range tests {
+		data, err := Marshal(tt.value)
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.hex, hex.EncodeToString(data), "%#v", tt.value)
+	}
+
+	// wrappers name the type of their model
+	data, err := Marshal(registry.Wrapper[registry.Model]{Model: &other{Key: "#other#1"}})
+	require.NoError(t, err)
+	var decoded any
+	require.NoError(t, Unmarshal(data, &decoded))
+	assert.Equal(t, map[string]any{"type": "other", "model": map[string]any{"key": "#other#1", "value": uint64(0)}}, decoded)
+
+	for _, value := range []any{func() {}, struct{ F float64 }{F: 1 / zero()}} {
+		_, err := Marshal(value)
+		assert.Error(t, err, "%T", value)
+	}
+}
+
+func zero() float64 { return 0 }
+
+func TestRoundTrip_MatchesJSON(t *testing.T) {
+	r := testRegistry()
+	c := NewCodec(r)
+	original := fullSample()
+
+	data, err := c.Marshal(original)
+	require.NoError(t, err)
+	fromCBOR := &sample{}
+	require.NoError(t, c.Unmarshal(data, fromCBOR))
+
+	encoded, err := json.Marshal(original)
+	require.NoError(t, err)
+	fromJSON := &sample{}
+	require.NoError(t, r.Unmarshal(encoded, fromJSON))
+
+	assert.Equal(t, fromJSON, fromCBOR)
+	assert.Equal(t, "#other#b", fromCBOR.Parent.Model.(*other).Key)
+	assert.Equal(t, "alias", fromCBOR.Parent.Type)
+	assert.Empty(t, fromCBOR.Ignored)
+}
+
+// A CBOR library decodes a payload into the values a JSON library decodes its JSON into, which is
+// what lets consumers in other languages use either
+func TestUnmarshal_GenericMatchesJSON(t *testing.T) {
+	values := []any{testutils.Example[*model.Job](), testutils.Example[*model.Webpage](), testutils.Example[*model.File]()}
+	for _, value := range values {
+		data, err := Marshal(value)
+		require.NoError(t, err)
+		var fromCBOR any
+		require.NoError(t, Unmarshal(data, &fromCBOR))
+
+		encoded, err := json.Marshal(value)
+		require.NoError(t, err)
+		var fromJSON any
+		require.NoError(t, json.Unmarshal(encoded, &fromJSON))
+
+		// integers decode as integers rather than floats, and byte strings as byte slices
+		assert.Equal(t, fromJSON, viaJSON(t, fromCBOR), "%T", value)
+	}
+}
+
+func viaJSON(t *testing.T, value any) any {
+	encoded, err := json.Marshal(value)
+	require.NoError(t, err)
+	var decoded any
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	return decoded
+}
+
+type event struct {
+	Key    string                           `json:"key"`
+	When   time.Time                        `json:"when"`
+	Body   []byte                           `json:"body,omitempty"`
+	Count  int                              `json:"count"`
+	Ratio  float64                          `json:"ratio,omitzero"`
+	Target registry.Wrapper[registry.Model] `json:"target"`
+}
+
+// eventHex is the encoding of the event in TestMarshal_Interop, which in diagnostic notation is
+// {"key": "#event#1", "body": h'0001', "when": "2024-01-02T03:04:05Z", "count": 3, "ratio": 1.5,
+// "target": {"type": "other", "model": {"key": "#other#1", "value": 1}}}
+const eventHex = "a6636b657968236576656e742331646" +
+	"26f6479420001647768656e74323032342d30312d30325430333a30343a30355a65636f756e7403657261" +
+	"74696ff93e0066746172676574a2647479706565" +
+	"6f74686572656d6f64656ca2636b65796823" +
+	"6f7468657223316576616c756501"
+
+// TestMarshal_Interop pins the bytes of a model, so that a change in the encoding is caught here
+// rather than by consumers, and checks them against a decoder that shares none of our options
+func TestMarshal_Interop(t *testing.T) {
+	original := event{
+		Key:    "#event#1",
+		When:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Body:   []byte{0, 1},
+		Count:  3,
+		Ratio:  1.5,
+		Target: registry.Wrapper[registry.Model]{Model: &other{Key: "#other#1", Value: 1}},
+	}
+	data, err := Marshal(original)
+	require.NoError(t, err)
+	assert.Equal(t, eventHex, hex.EncodeToString(data))
+
+	golden, err := hex.DecodeString(eventHex)
+	require.NoError(t, err)
+	var reference any
+	require.NoError(t, fxcbor.Unmarshal(golden, &reference))
+	assert.Equal(t, map[any]any{
+		"key":   "#event#1",
+		"when":  "2024-01-02T03:04:05Z",
+		"body":  []byte{0, 1},
+		"count": uint64(3),
+		"ratio": 1.5,
+		"target": map[any]any{
+			"type":  "other",
+			"model": map[any]any{"key": "#other#1", "value": uint64(1)},
+		},
+	}, reference)
+
+	var decoded event
+	require.NoError(t, NewCodec(testRegistry()).Unmarshal(golden, &decoded))
+	assert.Equal(t, "other", decoded.Target.Type)
+	assert.Equal(t, original.Target.Model, decoded.Target.Model)
+	original.Target, decoded.Target = registry.Wrapper[registry.Model]{}, registry.Wrapper[registry.Model]{}
+	assert.Equal(t, original, decoded)
+}
+
+func TestUnmarshal_Wrappers(t *testing.T) {
+	c := NewCodec(testRegistry())
+	tests := []struct {
+		name    string
+		payload any
+		tipe    string
+		key     string
+	}{
+		{"type", map[string]any{"type": "other", "model": map[string]any{"key": "#other#1"}}, "other", "#other#1"},
+		{"alias", map[string]any{"type": "alias", "model": map[string]any{"key": "#other#1"}}, "alias", "#other#1"},
+		{"Key", map[string]any{"Key": "#other#2", "model": map[string]any{"key": "#other#2"}}, "other", "#other#2"},
+		{"key", map[string]any{"key": "#other#3", "type": "sample", "model": map[string]any{"key": "#other#3"}}, "other", "#other#3"},
+		{"nested key", map[string]any{"model": map[string]any{"key": "#other#4"}}, "other", "#other#4"},
+		{"flattened", map[string]any{"key": "#other#5", "value": 5}, "other", "#other#5"},
+		{"titled model", map[string]any{"type": "other", "Model": map[string]any{"key": "#other#6"}}, "other", "#other#6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := c.Marshal(tt.payload)
+			require.NoError(t, err)
+			var wrapper registry.Wrapper[registry.Model]
+			require.NoError(t, c.Unmarshal(data, &wrapper))
+			assert.Equal(t, tt.tipe, wrapper.Type)
+			assert.Equal(t, tt.key, wrapper.Model.(*other).Key)
+
+			encoded, err := json.Marshal(tt.payload)
+			require.NoError(t, err)
+			var expected registry.Wrapper[registry.Model]
+			expected.Bind(testRegistry())
+			require.NoError(t, json.Unmarshal(encoded, &expected))
+			assert.Equal(t, expected.Model, wrapper.Model)
+		})
+	}
+
+	for _, payload := range []any{
+		map[string]any{"type": "unknown", "model": map[string]any{}},
+		map[string]any{"name": "untyped"},
+		"not a wrapper",
+	} {
+		data, err := c.Marshal(payload)
+		require.NoError(t, err)
+		var wrapper registry.Wrapper[registry.Model]
+		assert.Error(t, c.Unmarshal(data, &wrapper), "%v", payload)
+	}
+
+	// the registry decides which types are known
+	data, err := Marshal(map[string]any{"type": "sample", "model": map[string]any{}})
+	require.NoError(t, err)
+	var wrapper registry.Wrapper[registry.Model]
+	assert.ErrorContains(t, NewCodec(testRegistry().View("other")).Unmarshal(data, &wrapper), "not known")
+	var concrete registry.Wrapper[*other]
+	assert.ErrorContains(t, NewCodec(testRegistry()).Unmarshal(data, &concrete), "does not implement")
+
+	// as with JSON, an empty wrapper is left empty
+	for _, payload := range []any{map[string]any{}, map[string]any{"model": nil}, nil} {
+		data, err := Marshal(payload)
+		require.NoError(t, err)
+		var wrapper registry.Wrapper[registry.Model]
+		require.NoError(t, c.Unmarshal(data, &wrapper))
+		assert.Nil(t, wrapper.Model)
+	}
+
+	skip := registry.Wrapper[registry.Model]{SkipDefaulting: true}
+	data, err = Marshal(map[string]any{"type": "sample", "model": map[string]any{}})
+	require.NoError(t, err)
+	require.NoError(t, c.Unmarshal(data, &skip))
+	assert.False(t, skip.Model.(*sample).Enabled)
+}
+
+type versioned struct {
+	registry.BaseModel
+	Name          string `json:"name"`
+	SchemaVersion int    `json:"schema_version,omitempty"`
+}
+
+func (v *versioned) GetDescription() string { return "A model with a migration" }
+
+func TestUnmarshal_WrapperMigrations(t *testing.T) {
+	r := testRegistry()
+	r.MustRegisterModel(&versioned{})
+	r.MustRegisterMigration("versioned", 0, func(props map[string]any) error {
+		props["name"] = props["title"]
+		return nil
+	})
+	c := NewCodec(r)
+
+	data, err := c.Marshal(map[string]any{"type": "versioned", "model": map[string]any{"title": "old"}})
+	require.NoError(t, err)
+	var wrapper registry.Wrapper[registry.Model]
+	require.NoError(t, c.Unmarshal(data, &wrapper))
+	assert.Equal(t, &versioned{Name: "old", SchemaVersion: 1}, wrapper.Model)
+
+	data, err = c.Marshal(map[string]any{"type": "other", "model": map[string]any{"schema_version": "one"}})
+	require.NoError(t, err)
+	wrapper = registry.Wrapper[registry.Model]{}
+	assert.ErrorContains(t, c.Unmarshal(data, &wrapper), "invalid schema_version")
+}
+
+// foreignEncodings are valid encodings that Marshal never produces
+var foreignEncodings = []struct {
+	name     string
+	hex      string
+	expected sample
+}{
+	// {_ "name": (_ "a", "b"), "tags": [_ "x"]}, with a text string of indefinite length
+	{"indefinite lengths", "bf646e616d657f61616162ff64746167739f6178ffff", sample{Name: "ab", Tags: []string{"x"}}},
+	// {"when": 1(1704164645), "ratio": 2.0 as a half-precision float}
+	{"epoch and half floats", "a2647768656ec11a65937d2565726174696ff94000", sample{When: time.Unix(1704164645, 0), Ratio: 2}},
+	// {"when": 0("2024-01-02T03:04:05Z"), "NAME": "folded"}
+	{"date tags and folded keys", "a2647768656ec074323032342d30312d30325430333a30343a30355a644e414d4566666f6c646564",
+		sample{When: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Name: "folded"}},
+	// {"ports": {443: "https"}}, with an integer key
+	{"integer keys", "a165706f727473a11901bb656874747073", sample{Ports: map[int]string{443: "https"}}},
+}
+
+func TestUnmarshal_ForeignEncodings(t *testing.T) {
+	for _, tt := range foreignEncodings {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.hex)
+			require.NoError(t, err)
+			var actual sample
+			require.NoError(t, Unmarshal(data, &actual))
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+// malformed are encodings that Unmarshal rejects, with the values it decodes them into
+var malformed = []struct {
+	hex   string
+	value any
+	msg   string
+}{
+	{"", new(int), "EOF"},
+	{"1903", new(int), "unexpected EOF"},
+	{"0000", new(int), "extraneous data"},
+	{"1c", new(int), "invalid additional information 28"},
+	{"6161", new(int), "cannot unmarshal UTF-8 text string into Go value of type int"},
+	{"1901f4", new(int8), "500 overflows int8"},
+	{"20", new(uint), "cannot unmarshal negative integer"},
+	{"f93e00", new(int), "cannot unmarshal primitives into Go value of type int"},
+	{"a1646e616d6501", new(sample), "cannot unmarshal positive integer into Go struct field cbor.sample.name"},
+	{"62c328", new(string), "invalid UTF-8"},
+	{"ff", new(any), "unexpected \"break\" code"},
+	{"a1616101", new(struct{ *common }), "cannot set embedded pointer to unexported struct"},
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	for _, tt := range malformed {
+		data, err := hex.DecodeString(tt.hex)
+		require.NoError(t, err)
+		assert.ErrorContains(t, Unmarshal(data, tt.value), tt.msg, tt.hex)
+	}
+	assert.Error(t, Unmarshal([]byte{0}, 0))
+	assert.Error(t, Unmarshal([]byte{0}, (*int)(nil)))
+}
+
+func TestUnmarshal_Null(t *testing.T) {
+	s := fullSample()
+	require.NoError(t, Unmarshal([]byte{0xf6}, &s.Tags))
+	assert.Nil(t, s.Tags)
+	require.NoError(t, Unmarshal([]byte{0xf6}, &s.Name))
+	assert.Equal(t, "a", s.Name, "null leaves values that cannot be nil unchanged")
+	require.NoError(t, Unmarshal([]byte{0xf6}, &s.Optional))
+	assert.Nil(t, s.Optional)
+}
+
+func benchmarkPayloads() map[string]registry.Model {
+	job := testutils.Example[*model.Job]()
+	asset := model.NewAsset("example.com", "10.0.0.1")
+	job.Target = model.TargetWrapper{Model: &asset}
+	job.Origin = model.TargetWrapper{Model: &asset}
+	for i := range 5 {
+		port := model.NewPort("tcp", 8000+i, &asset)
+		job.Context = append(job.Context, model.NewGraphModelWrapper(&port))
+	}
+
+	webpage := testutils.Example[*model.Webpage]()
+	request := webpage.Requests
+	for range 10 {
+		request = append(request, model.WebpageRequest{
+			OriginalURL: "https://example.com/login?next=%2Fadmin",
+			RawURL:      "https://example.com/login",
+			Method:      "POST",
+			Headers:     map[string][]string{"User-Agent": {"Mozilla/5.0"}, "Cookie": {"session=abc123"}},
+			Body:        `{"username": "admin", "password": "hunter2"}`,
+			Response: &model.WebpageResponse{
+				StatusCode: 200,
+				Headers:    map[string][]string{"Content-Type": {"text/html"}, "Server": {"nginx"}},
+				Body:       strings.Repeat("<div>Example Domain</div>", 40),
+			},
+		})
+	}
+	webpage.Requests = request
+	return map[string]registry.Model{"job": job, "webpage": webpage}
+}
+
+func BenchmarkCodecs(b *testing.B) {
+	for name, payload := range benchmarkPayloads() {
+		codecs := map[string]struct {
+			marshal   func(registry.Model) ([]byte, error)
+			unmarshal func([]byte) error
+		}{
+			"json": {
+				marshal:   func(m registry.Model) ([]byte, error) { return json.Marshal(m) },
+				unmarshal: func(data []byte) error { return json.Unmarshal(data, newModel(payload)) },
+			},
+			"gob": {
+				marshal: func(m registry.Model) ([]byte, error) {
+					var buf bytes.Buffer
+					err := gob.NewEncoder(&buf).Encode(m)
+					return buf.Bytes(), err
+				},
+				unmarshal: func(data []byte) error { return gob.NewDecoder(bytes.NewReader(data)).Decode(newModel(payload)) },
+			},
+			"cbor": {
+				marshal:   func(m registry.Model) ([]byte, error) { return Marshal(m) },
+				unmarshal: func(data []byte) error { return Unmarshal(data, newModel(payload)) },
+			},
+		}
+		for codec, fns := range codecs {
+			data, err := fns.marshal(payload)
+			require.NoError(b, err)
+
+			b.Run(name+"/"+codec+"/marshal", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					if _, err := fns.marshal(payload); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(len(data)), "bytes")
+			})
+			b.Run(name+"/"+codec+"/unmarshal", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					if err := fns.unmarshal(data); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func newModel(like registry.Model) registry.Model {
+	model, _ := registry.Registry.MakeType(registry.Name(like))
+	return model
+}
@@ -0,0 +1,50 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/require"
+)
+
+// FuzzUnmarshal decodes arbitrary input into generic values, models and wrappers. Decoding may
+// fail, but must not panic, and generic values it produces must survive another round trip.
+func FuzzUnmarshal(f *testing.F) {
+	for _, tt := range foreignEncodings {
+		data, err := hex.DecodeString(tt.hex)
+		require.NoError(f, err)
+		f.Add(data)
+	}
+	for _, tt := range malformed {
+		data, err := hex.DecodeString(tt.hex)
+		require.NoError(f, err)
+		f.Add(data)
+	}
+	c := NewCodec(testRegistry())
+	for _, value := range []any{
+		fullSample(),
+		map[string]any{"type": "alias", "model": map[string]any{"key": "#other#1"}},
+		map[string]any{"key": "#other#5", "value": 5},
+	} {
+		data, err := c.Marshal(value)
+		require.NoError(f, err)
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var s sample
+		_ = c.Unmarshal(data, &s)
+		var wrapper registry.Wrapper[registry.Model]
+		_ = c.Unmarshal(data, &wrapper)
+
+		var value any
+		if c.Unmarshal(data, &value) != nil {
+			return
+		}
+		encoded, err := c.Marshal(value)
+		require.NoError(t, err)
+		var decoded any
+		require.NoError(t, c.Unmarshal(encoded, &decoded))
+	})
+}
@@ -1,6 +1,8 @@
 package collection
 
 import (
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"reflect"
 	"slices"
@@ -16,6 +18,16 @@ type Collection struct {
 	Label string                      `json:"-"`
 	Items map[string][]registry.Model `json:"items"`
 	Count int                         `json:"count"`
+
+	// keys indexes each list of Items by item key, for Upsert
+	keys map[string]*keyIndex
+}
+
+// keyIndex holds the position of the first item with each key in a list, for the first indexed
+// items of the list
+type keyIndex struct {
+	positions map[string]int
+	indexed   int
 }
 
 // init lazily initializes Collection
@@ -28,16 +40,85 @@ func (c *Collection) init() {
 func (c *Collection) Add(model registry.Model) {
 	c.init()
 
-	if ok := addInterface[modelpkg.Seedable](c, model); ok {
-		return
+	name := c.name(model)
+	c.Items[name] = append(c.Items[name], model)
+	c.Count++
+}
+
+// Upsert adds model, or applies it to the item of the collection with the same key, so that the
+// collection holds each entity once. Models are applied with the stored item's Visit method, as
+// capability output is applied to the graph, or with its Merge method when it has no Visit.
+// Models without a key are always added. The stored item is returned.
+func (c *Collection) Upsert(model registry.Model) (registry.Model, error) {
+	c.init()
+
+	key := model.GetKey()
+	if key == "" {
+		c.Add(model)
+		return model, nil
+	}
+
+	name := c.name(model)
+	if stored, ok := c.find(name, key); ok {
+		for _, method := range []string{"Visit", "Merge"} {
+			applied, err := registry.CallMethod(stored, method, model)
+			if err != nil {
+				return nil, fmt.Errorf("failed to %s %q: %w", method, key, err)
+			}
+			if applied {
+				break
+			}
+		}
+		return stored, nil
 	}
 
-	name := plural.Plural(registry.Name(model))
 	c.Items[name] = append(c.Items[name], model)
 	c.Count++
+	return model, nil
+}
+
+// find returns the item with key in the named list. The list's index catches up with items
+// appended since it was last used. Since Items may also be replaced or edited directly, a key the
+// index does not hold is looked for in the whole list, dropping the index if it is found.
+func (c *Collection) find(name, key string) (registry.Model, bool) {
+	if c.keys == nil {
+		c.keys = map[string]*keyIndex{}
+	}
+	items := c.Items[name]
+	index := c.keys[name]
+	if index == nil || index.indexed > len(items) {
+		index = &keyIndex{positions: map[string]int{}}
+		c.keys[name] = index
+	}
+	for ; index.indexed < len(items); index.indexed++ {
+		if k := items[index.indexed].GetKey(); k != "" {
+			if _, ok := index.positions[k]; !ok {
+				index.positions[k] = index.indexed
+			}
+		}
+	}
+
+	if i, ok := index.positions[key]; ok && items[i].GetKey() == key {
+		return items[i], true
+	}
+	for _, item := range items {
+		if item.GetKey() == key {
+			delete(c.keys, name)
+			return item, true
+		}
+	}
+	return nil, false
 }
 
-func addInterface[T registry.Model](c *Collection, model registry.Model) bool {
+// name returns the name of the list of Items holding model
+func (c *Collection) name(model registry.Model) string {
+	if name, ok := interfaceName[modelpkg.Seedable](c, model); ok {
+		return name
+	}
+	return plural.Plural(registry.Name(model))
+}
+
+func interfaceName[T registry.Model](c *Collection, model registry.Model) (string, bool) {
 	interfaceType := reflect.TypeOf((*T)(nil)).Elem()
 	modelType := reflect.TypeOf(model)
 
@@ -45,18 +126,13 @@ func addInterface[T registry.Model](c *Collection, model registry.Model) bool {
 	labelMatchesInterface := hasLabel && strings.HasPrefix(interfaceType.Name(), c.Label)
 
 	if !hasLabel || !labelMatchesInterface {
-		return false
+		return "", false
 	}
 
 	label := plural.Plural(c.Label)
 	label = strings.ToLower(label)
 
-	if modelType.Implements(interfaceType) {
-		c.Items[label] = append(c.Items[label], model)
-		c.Count++
-		return true
-	}
-	return false
+	return label, modelType.Implements(interfaceType)
 }
 
 func hasLabel(c *Collection, model registry.Model) bool {
@@ -83,3 +159,61 @@ func Get[T registry.Model](c *Collection) []T {
 	}
 	return out
 }
+
+// UnmarshalJSON decodes a collection, making each item the registered type named by its list. Items
+// of lists named after a label, such as seeds, are made the type named by the prefix of their key.
+func (c *Collection) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Items map[string][]json.RawMessage `json:"items"`
+		Count int                          `json:"count"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	// lists are named by Add after the type of their items, rather than any alias it has
+	names := map[string]string{}
+	for name, tipe := range registry.Registry.GetAllTypes() {
+		if strings.ToLower(tipe.Elem().Name()) == name {
+			names[plural.Plural(name)] = name
+		}
+	}
+
+	c.Items = map[string][]registry.Model{}
+	c.Count = raw.Count
+	c.keys = nil
+	for list, items := range raw.Items {
+		models := make([]registry.Model, 0, len(items))
+		for i, item := range items {
+			name, ok := names[list]
+			if !ok {
+				name = keyName(item)
+			}
+			model, ok := registry.Registry.MakeType(name)
+			if !ok {
+				return fmt.Errorf("failed to resolve the type of %s[%d]", list, i)
+			}
+			if err := registry.Registry.UnmarshalModel(item, model); err != nil {
+				return fmt.Errorf("failed to unmarshal %s[%d]: %w", list, i, err)
+			}
+			models = append(models, model)
+		}
+		c.Items[list] = models
+	}
+	return nil
+}
+
+// keyName returns the type name prefixing the key of item, as in #asset#example.com#example.com
+func keyName(item []byte) string {
+	var keyed struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(item, &keyed); err != nil {
+		return ""
+	}
+	parts := strings.SplitN(keyed.Key, "#", 3)
+	if len(parts) < 3 || parts[0] != "" {
+		return ""
+	}
+	return parts[1]
+}
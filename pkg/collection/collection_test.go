@@ -1,12 +1,15 @@
 package collection
 
 import (
+	"encoding/json"
+	"fmt"
 	"reflect"
 	"testing"
 
 	"github.com/praetorian-inc/tabularium/pkg/lib/plural"
 	"github.com/praetorian-inc/tabularium/pkg/model/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/praetorian-inc/tabularium/pkg/registry"
 )
@@ -73,6 +76,31 @@ func (tm *testModelA) GetName() string {
 	return tm.Name
 }
 
+// mergedModel is a keyed model with a Merge method and no Visit method
+type mergedModel struct {
+	registry.BaseModel
+	Key    string
+	Values []string
+}
+
+func (m *mergedModel) GetDescription() string { return "A keyed model that merges" }
+func (m *mergedModel) GetKey() string         { return m.Key }
+func (m *mergedModel) Merge(other *mergedModel) {
+	m.Values = append(m.Values, other.Values...)
+}
+
+// failingModel is a keyed model whose Visit method fails
+type failingModel struct {
+	registry.BaseModel
+	Key string
+}
+
+func (f *failingModel) GetDescription() string { return "A keyed model that fails to visit" }
+func (f *failingModel) GetKey() string         { return f.Key }
+func (f *failingModel) Visit(other *failingModel) error {
+	return fmt.Errorf("cannot visit %s", other.Key)
+}
+
 func init() {
 	// Ensure a clean registry for this test package
 	registry.Registry = registry.NewTypeRegistry()
@@ -83,6 +111,8 @@ func init() {
 	registry.Registry.MustRegisterModel(&testModelC{})
 	registry.Registry.MustRegisterModel(&unregisteredModel{})
 	registry.Registry.MustRegisterModel(&getTestAuxiliaryModel{})
+	registry.Registry.MustRegisterModel(&model.Asset{})
+	registry.Registry.MustRegisterModel(&model.Attribute{})
 }
 
 func TestNewCollection(t *testing.T) {
@@ -278,3 +308,196 @@ func TestAddInterface_Seed(t *testing.T) {
 	assert.Len(t, c.Items["assets"], 1)
 	assert.Len(t, c.Items["attributes"], 1)
 }
+
+func TestCollection_Upsert(t *testing.T) {
+	t.Run("visits items with the same key", func(t *testing.T) {
+		c := Collection{}
+		asset := model.NewAsset("example.com", "example.com")
+		asset.Visited = "2024-01-01T00:00:00Z"
+		stored, err := c.Upsert(&asset)
+		require.NoError(t, err)
+		assert.Same(t, &asset, stored)
+
+		update := model.NewAsset("example.com", "example.com")
+		update.Visited = "2024-02-01T00:00:00Z"
+		update.Origin = "nuclei"
+		stored, err = c.Upsert(&update)
+		require.NoError(t, err)
+		assert.Same(t, &asset, stored)
+
+		assert.Equal(t, 1, c.Count)
+		require.Len(t, c.Items["assets"], 1)
+		assert.Equal(t, "2024-02-01T00:00:00Z", asset.Visited)
+		assert.Equal(t, "nuclei", asset.Origin)
+	})
+
+	t.Run("adds items with other keys", func(t *testing.T) {
+		c := Collection{}
+		first := model.NewAsset("example.com", "example.com")
+		second := model.NewAsset("example.com", "1.2.3.4")
+		attribute := model.NewAttribute("port", "443", &first)
+		for _, m := range []registry.Model{&first, &second, &attribute, &attribute} {
+			_, err := c.Upsert(m)
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, 3, c.Count)
+		assert.Len(t, c.Items["assets"], 2)
+		assert.Len(t, c.Items["attributes"], 1)
+	})
+
+	t.Run("merges items without a Visit method", func(t *testing.T) {
+		c := Collection{}
+		_, err := c.Upsert(&mergedModel{Key: "#merged#a", Values: []string{"one"}})
+		require.NoError(t, err)
+		stored, err := c.Upsert(&mergedModel{Key: "#merged#a", Values: []string{"two"}})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"one", "two"}, stored.(*mergedModel).Values)
+		assert.Equal(t, 1, c.Count)
+	})
+
+	t.Run("adds items without a key", func(t *testing.T) {
+		c := Collection{}
+		for range 2 {
+			_, err := c.Upsert(&testModelA{ID: 1})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, c.Count)
+	})
+
+	t.Run("dedupes seeds", func(t *testing.T) {
+		c := Collection{Label: model.SeedLabel}
+		first := model.NewAssetSeed("example.com")
+		second := model.NewAssetSeed("example.com")
+		for _, m := range []registry.Model{&first, &second} {
+			_, err := c.Upsert(m)
+			require.NoError(t, err)
+		}
+		assert.Len(t, c.Items["seeds"], 1)
+	})
+
+	t.Run("finds items added or replaced outside Upsert", func(t *testing.T) {
+		c := Collection{}
+		_, err := c.Upsert(&mergedModel{Key: "#merged#a"})
+		require.NoError(t, err)
+		added := &mergedModel{Key: "#merged#b"}
+		c.Add(added)
+		stored, err := c.Upsert(&mergedModel{Key: "#merged#b", Values: []string{"b"}})
+		require.NoError(t, err)
+		assert.Same(t, added, stored)
+
+		// a list of the same length
+		replaced := &mergedModel{Key: "#merged#c"}
+		c.Items["mergedmodels"] = []registry.Model{replaced, &mergedModel{Key: "#merged#d"}}
+		stored, err = c.Upsert(&mergedModel{Key: "#merged#c", Values: []string{"c"}})
+		require.NoError(t, err)
+		assert.Same(t, replaced, stored)
+
+		// an item edited in place
+		c.Items["mergedmodels"][1].(*mergedModel).Key = "#merged#e"
+		stored, err = c.Upsert(&mergedModel{Key: "#merged#e"})
+		require.NoError(t, err)
+		assert.Same(t, c.Items["mergedmodels"][1], stored)
+
+		// a stale key of the index
+		stored, err = c.Upsert(&mergedModel{Key: "#merged#a"})
+		require.NoError(t, err)
+		require.Len(t, c.Items["mergedmodels"], 3)
+		assert.Same(t, c.Items["mergedmodels"][2], stored)
+	})
+
+	t.Run("finds items of a decoded collection", func(t *testing.T) {
+		c := Collection{}
+		asset := model.NewAsset("example.com", "example.com")
+		_, err := c.Upsert(&asset)
+		require.NoError(t, err)
+		data, err := json.Marshal(c)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &c))
+
+		update := model.NewAsset("example.com", "example.com")
+		stored, err := c.Upsert(&update)
+		require.NoError(t, err)
+		assert.Same(t, c.Items["assets"][0], stored)
+		assert.Len(t, c.Items["assets"], 1)
+	})
+
+	t.Run("returns errors from Visit", func(t *testing.T) {
+		c := Collection{}
+		_, err := c.Upsert(&failingModel{Key: "#failing#a"})
+		require.NoError(t, err)
+		_, err = c.Upsert(&failingModel{Key: "#failing#a"})
+		assert.ErrorContains(t, err, "cannot visit #failing#a")
+	})
+}
+
+func TestCollection_UnmarshalJSON(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		c := Collection{}
+		asset := model.NewAsset("example.com", "example.com")
+		attribute := model.NewAttribute("port", "443", &asset)
+		c.Add(&asset)
+		c.Add(&attribute)
+		c.Add(&testModelA{ID: 1, Name: "A1"})
+
+		data, err := json.Marshal(c)
+		require.NoError(t, err)
+		var decoded Collection
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		assert.Equal(t, c.Count, decoded.Count)
+		assets := Get[*model.Asset](&decoded)
+		require.Len(t, assets, 1)
+		assert.Equal(t, asset.Key, assets[0].Key)
+		attributes := Get[*model.Attribute](&decoded)
+		require.Len(t, attributes, 1)
+		assert.Equal(t, attribute.Key, attributes[0].Key)
+		assert.Equal(t, []*testModelA{{ID: 1, Name: "A1"}}, Get[*testModelA](&decoded))
+	})
+
+	t.Run("seeds", func(t *testing.T) {
+		c := Collection{Label: model.SeedLabel}
+		seed := model.NewAssetSeed("example.com")
+		c.Add(&seed)
+
+		data, err := json.Marshal(c)
+		require.NoError(t, err)
+		var decoded Collection
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		require.Len(t, decoded.Items["seeds"], 1)
+		decodedSeed, ok := decoded.Items["seeds"][0].(*model.Asset)
+		require.True(t, ok)
+		assert.Equal(t, seed.Key, decodedSeed.Key)
+	})
+
+	t.Run("upserts into decoded collections", func(t *testing.T) {
+		asset := model.NewAsset("example.com", "example.com")
+		data, err := json.Marshal(Collection{Items: map[string][]registry.Model{"assets": {&asset}}, Count: 1})
+		require.NoError(t, err)
+		var decoded Collection
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		_, err = decoded.Upsert(&asset)
+		require.NoError(t, err)
+		assert.Equal(t, 1, decoded.Count)
+	})
+
+	errors := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"unknown list", `{"items": {"widgets": [{"key": "widget"}]}}`, "widgets[0]"},
+		{"unknown key prefix", `{"items": {"seeds": [{"key": "#widget#a"}]}}`, "seeds[0]"},
+		{"invalid item", `{"items": {"assets": [1]}}`, "failed to unmarshal assets[0]"},
+		{"invalid json", `{"items": []}`, "cannot unmarshal"},
+	}
+	for _, tt := range errors {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Collection
+			assert.ErrorContains(t, json.Unmarshal([]byte(tt.data), &c), tt.err)
+		})
+	}
+}
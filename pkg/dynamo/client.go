@@ -0,0 +1,306 @@
+package dynamo
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+)
+
+// Client is the part of the DynamoDB API used by Put, Get and Query. *dynamodb.Client satisfies it.
+type Client interface {
+	PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	GetItem(ctx context.Context, input *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+var _ Client = (*dynamodb.Client)(nil)
+
+// KeyValue is a Go type stored as a key attribute: a string, a number or a byte slice
+type KeyValue interface {
+	~string | ~[]byte |
+		~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// Key builds the primary key of the T stored under the given partition and sort key values.
+// The keys of the table models in pkg/model are built by JobKey, StatisticKey and their siblings.
+func Key[T model.TableModel, P, S KeyValue](partition P, sort S) (map[string]types.AttributeValue, error) {
+	schema, err := SchemaFor[T]()
+	if err != nil {
+		return nil, err
+	}
+	if schema.SortKey.Name == "" {
+		return nil, fmt.Errorf("table of %s has no sort key", reflect.TypeFor[T]())
+	}
+	return buildKey(schema.PartitionKey, schema.SortKey, partition, sort)
+}
+
+// PartitionKey builds the primary key of the T stored under the given partition key value, for
+// tables without a sort key
+func PartitionKey[T model.TableModel, P KeyValue](partition P) (map[string]types.AttributeValue, error) {
+	schema, err := SchemaFor[T]()
+	if err != nil {
+		return nil, err
+	}
+	if schema.SortKey.Name != "" {
+		return nil, fmt.Errorf("table of %s has sort key %q", reflect.TypeFor[T](), schema.SortKey.Name)
+	}
+	return buildKey(schema.PartitionKey, schema.SortKey, partition, nil)
+}
+
+// KeyOf returns the primary key of m
+func KeyOf(m model.TableModel) (map[string]types.AttributeValue, error) {
+	schema, err := SchemaOf(m)
+	if err != nil {
+		return nil, err
+	}
+	item, err := attributevalue.MarshalMap(m)
+	if err != nil {
+		return nil, err
+	}
+	return primaryKey(schema, item)
+}
+
+func buildKey(partition, sort Attribute, partitionValue, sortValue any) (map[string]types.AttributeValue, error) {
+	key := map[string]types.AttributeValue{}
+	pk, err := keyValue(partition, partitionValue)
+	if err != nil {
+		return nil, err
+	}
+	key[partition.Name] = pk
+
+	if sort.Name != "" {
+		sk, err := keyValue(sort, sortValue)
+		if err != nil {
+			return nil, err
+		}
+		key[sort.Name] = sk
+	}
+	return key, nil
+}
+
+// primaryKey picks the primary key attributes out of item
+func primaryKey(schema Schema, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+	key := map[string]types.AttributeValue{}
+	for _, attr := range []Attribute{schema.PartitionKey, schema.SortKey} {
+		if attr.Name == "" {
+			continue
+		}
+		if err := checkKey(attr, item[attr.Name]); err != nil {
+			return nil, err
+		}
+		key[attr.Name] = item[attr.Name]
+	}
+	return key, nil
+}
+
+// keyValue marshals v as a value of the key attribute attr
+func keyValue(attr Attribute, v any) (types.AttributeValue, error) {
+	av, err := attributevalue.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", attr.Name, err)
+	}
+	if err := checkKey(attr, av); err != nil {
+		return nil, err
+	}
+	return av, nil
+}
+
+// checkKey reports an error unless av is a non-empty value of attr's type, as DynamoDB requires of keys
+func checkKey(attr Attribute, av types.AttributeValue) error {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		if attr.Type == types.ScalarAttributeTypeS && v.Value != "" {
+			return nil
+		}
+	case *types.AttributeValueMemberN:
+		if attr.Type == types.ScalarAttributeTypeN {
+			return nil
+		}
+	case *types.AttributeValueMemberB:
+		if attr.Type == types.ScalarAttributeTypeB && len(v.Value) > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("key %q must be a non-empty value of type %s", attr.Name, attr.Type)
+}
+
+// Put writes m to table, replacing any item with the same primary key
+func Put(ctx context.Context, c Client, table string, m model.TableModel) error {
+	schema, err := SchemaOf(m)
+	if err != nil {
+		return err
+	}
+	item, err := attributevalue.MarshalMap(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %T: %w", m, err)
+	}
+	if _, err := primaryKey(schema, item); err != nil {
+		return err
+	}
+
+	_, err = c.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(table), Item: item})
+	return err
+}
+
+// Get reads the T stored in table under key, as built by Key or KeyOf. It reports false when there is no such item.
+func Get[T model.TableModel](ctx context.Context, c Client, table string, key map[string]types.AttributeValue) (T, bool, error) {
+	var zero T
+	out, err := c.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(table), Key: key, ConsistentRead: aws.Bool(true)})
+	if err != nil {
+		return zero, false, err
+	}
+	if out.Item == nil {
+		return zero, false, nil
+	}
+	m, err := unmarshal[T](out.Item)
+	return m, err == nil, err
+}
+
+// SortCondition restricts the sort key of a query
+type SortCondition struct {
+	op     string
+	values []any
+}
+
+// Equal matches sort keys equal to v
+func Equal(v any) SortCondition { return SortCondition{op: "=", values: []any{v}} }
+
+// LessThan matches sort keys less than v
+func LessThan(v any) SortCondition { return SortCondition{op: "<", values: []any{v}} }
+
+// GreaterThan matches sort keys greater than v
+func GreaterThan(v any) SortCondition { return SortCondition{op: ">", values: []any{v}} }
+
+// BeginsWith matches string or binary sort keys starting with prefix
+func BeginsWith(prefix any) SortCondition {
+	return SortCondition{op: "begins_with", values: []any{prefix}}
+}
+
+// Between matches sort keys from low to high, inclusive
+func Between(low, high any) SortCondition {
+	return SortCondition{op: "BETWEEN", values: []any{low, high}}
+}
+
+// KeyQuery selects the models of one partition of a table or index
+type KeyQuery struct {
+	Index      string        // the index to query, or empty for the table
+	Partition  any           // the value of the partition key
+	Sort       SortCondition // the condition on the sort key, or the zero SortCondition for none
+	Limit      int32         // the maximum number of models to return, or 0 for all
+	Descending bool          // return models in descending order of sort key
+}
+
+// Input builds the query selecting q from table, whose keys are described by schema
+func (q KeyQuery) Input(schema Schema, table string) (*dynamodb.QueryInput, error) {
+	partition, sort, err := schema.keys(q.Index)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := keyValue(partition, q.Partition)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(table),
+		KeyConditionExpression:    aws.String("#pk = :pk"),
+		ExpressionAttributeNames:  map[string]string{"#pk": partition.Name},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":pk": pk},
+		ScanIndexForward:          aws.Bool(!q.Descending),
+	}
+	if q.Index != "" {
+		input.IndexName = aws.String(q.Index)
+	}
+	if q.Limit > 0 {
+		input.Limit = aws.Int32(q.Limit)
+	}
+	if q.Sort.op == "" {
+		return input, nil
+	}
+
+	if sort.Name == "" {
+		return nil, fmt.Errorf("cannot filter on a sort key: %q has none", cmp.Or(q.Index, table))
+	}
+	if q.Sort.op == "begins_with" && sort.Type == types.ScalarAttributeTypeN {
+		return nil, fmt.Errorf("begins_with cannot match numeric key %q", sort.Name)
+	}
+	input.ExpressionAttributeNames["#sk"] = sort.Name
+	placeholders := []string{":sk", ":sk2"}
+	for i, v := range q.Sort.values {
+		av, err := keyValue(sort, v)
+		if err != nil {
+			return nil, err
+		}
+		input.ExpressionAttributeValues[placeholders[i]] = av
+	}
+
+	var condition string
+	switch q.Sort.op {
+	case "begins_with":
+		condition = "begins_with(#sk, :sk)"
+	case "BETWEEN":
+		condition = "#sk BETWEEN :sk AND :sk2"
+	default:
+		condition = "#sk " + q.Sort.op + " :sk"
+	}
+	input.KeyConditionExpression = aws.String("#pk = :pk AND " + condition)
+	return input, nil
+}
+
+// Query reads the Ts selected by q from table, following pages until q.Limit models are read or the partition is exhausted
+func Query[T model.TableModel](ctx context.Context, c Client, table string, q KeyQuery) ([]T, error) {
+	schema, err := SchemaFor[T]()
+	if err != nil {
+		return nil, err
+	}
+	input, err := q.Input(schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []T{}
+	for {
+		if q.Limit > 0 {
+			input.Limit = aws.Int32(q.Limit - int32(len(out)))
+		}
+		page, err := c.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			m, err := unmarshal[T](item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, m)
+		}
+		if len(page.LastEvaluatedKey) == 0 || (q.Limit > 0 && int32(len(out)) >= q.Limit) {
+			return out, nil
+		}
+		input.ExclusiveStartKey = page.LastEvaluatedKey
+	}
+}
+
+// unmarshal decodes item into a new T
+func unmarshal[T model.TableModel](item map[string]types.AttributeValue) (T, error) {
+	var out T
+	target := any(&out)
+	if t := reflect.TypeFor[T](); t.Kind() == reflect.Pointer {
+		out = reflect.New(t.Elem()).Interface().(T)
+		target = out
+	}
+	if err := attributevalue.UnmarshalMap(item, target); err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to unmarshal %T: %w", out, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,292 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const table = "chariot"
+
+func newTable(t *testing.T, models ...model.TableModel) *Memory {
+	t.Helper()
+	input, err := NewCreateTableInput(table, models...)
+	require.NoError(t, err)
+	db := NewMemory()
+	_, err = db.CreateTable(context.Background(), input)
+	require.NoError(t, err)
+	return db
+}
+
+func newJob(username, dns, capability, status string) *model.Job {
+	asset := model.NewAsset(dns, dns)
+	job := model.NewJob(capability, &asset)
+	job.Username = username
+	job.Status = status
+	return &job
+}
+
+func TestKey(t *testing.T) {
+	key, err := Key[*model.Job]("user@example.com", "#job#example.com#example.com#nuclei")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]types.AttributeValue{
+		"username": &types.AttributeValueMemberS{Value: "user@example.com"},
+		"key":      &types.AttributeValueMemberS{Value: "#job#example.com#example.com#nuclei"},
+	}, key)
+
+	key, err = Key[*reading]("sensor-1", 1700000000)
+	require.NoError(t, err)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "1700000000"}, key["at"])
+
+	key, err = PartitionKey[*counter]("visits")
+	require.NoError(t, err)
+	assert.Len(t, key, 1)
+
+	_, err = Key[*model.Job]("user@example.com", 1)
+	assert.ErrorContains(t, err, `key "key"`)
+	_, err = Key[*model.Job]("", "#job#")
+	assert.ErrorContains(t, err, `key "username"`)
+	_, err = Key[*counter]("visits", "extra")
+	assert.ErrorContains(t, err, "no sort key")
+	_, err = PartitionKey[*model.Job]("user@example.com")
+	assert.ErrorContains(t, err, `sort key "key"`)
+}
+
+func TestKeyOf(t *testing.T) {
+	job := newJob("user@example.com", "example.com", "nuclei", model.Queued)
+
+	key, err := KeyOf(job)
+	require.NoError(t, err)
+	expected, err := Key[*model.Job](job.Username, job.Key)
+	require.NoError(t, err)
+	assert.Equal(t, expected, key)
+
+	job.Username = ""
+	_, err = KeyOf(job)
+	assert.Error(t, err)
+}
+
+func TestPutGet(t *testing.T) {
+	ctx := context.Background()
+	db := newTable(t, &model.Job{})
+
+	job := newJob("user@example.com", "example.com", "nuclei", model.Queued)
+	job.Config = map[string]string{"template": "cves"}
+	require.NoError(t, Put(ctx, db, table, job))
+
+	key, err := KeyOf(job)
+	require.NoError(t, err)
+	got, ok, err := Get[*model.Job](ctx, db, table, key)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, job.Key, got.Key)
+	assert.Equal(t, job.Status, got.Status)
+	assert.Equal(t, job.Config, got.Config)
+	assert.Equal(t, job.Target.Model.GetKey(), got.Target.Model.GetKey())
+
+	t.Run("replaces items with the same key", func(t *testing.T) {
+		job.Status = model.Running
+		require.NoError(t, Put(ctx, db, table, job))
+
+		got, _, err := Get[*model.Job](ctx, db, table, key)
+		require.NoError(t, err)
+		assert.Equal(t, model.Running, got.Status)
+	})
+
+	t.Run("missing item", func(t *testing.T) {
+		key, err := Key[*model.Job]("user@example.com", "#job#missing")
+		require.NoError(t, err)
+		got, ok, err := Get[*model.Job](ctx, db, table, key)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Nil(t, got)
+	})
+
+	t.Run("rejects models without keys", func(t *testing.T) {
+		err := Put(ctx, db, table, newJob("", "example.com", "nuclei", model.Queued))
+		assert.ErrorContains(t, err, `key "username"`)
+	})
+
+	t.Run("rejects unknown tables", func(t *testing.T) {
+		err := Put(ctx, db, "missing", job)
+		assert.ErrorContains(t, err, "does not exist")
+	})
+}
+
+func TestKeyQuery_Input(t *testing.T) {
+	schema, err := SchemaFor[*model.Job]()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		query      KeyQuery
+		expression string
+		values     int
+	}{
+		{"partition", KeyQuery{Partition: "u"}, "#pk = :pk", 1},
+		{"equal", KeyQuery{Partition: "u", Sort: Equal("#job#a")}, "#pk = :pk AND #sk = :sk", 2},
+		{"less than", KeyQuery{Partition: "u", Sort: LessThan("#job#a")}, "#pk = :pk AND #sk < :sk", 2},
+		{"greater than", KeyQuery{Partition: "u", Sort: GreaterThan("#job#a")}, "#pk = :pk AND #sk > :sk", 2},
+		{"begins with", KeyQuery{Partition: "u", Sort: BeginsWith("#job#")}, "#pk = :pk AND begins_with(#sk, :sk)", 2},
+		{"between", KeyQuery{Partition: "u", Sort: Between("#job#a", "#job#b")}, "#pk = :pk AND #sk BETWEEN :sk AND :sk2", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := tt.query.Input(schema, table)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expression, aws.ToString(input.KeyConditionExpression))
+			assert.Len(t, input.ExpressionAttributeValues, tt.values)
+			assert.Equal(t, "username", input.ExpressionAttributeNames["#pk"])
+			assert.Nil(t, input.IndexName)
+			assert.True(t, aws.ToBool(input.ScanIndexForward))
+		})
+	}
+
+	t.Run("index", func(t *testing.T) {
+		schema, err := SchemaFor[*task]()
+		require.NoError(t, err)
+		input, err := KeyQuery{Index: "status", Partition: "u", Sort: BeginsWith("JQ"), Descending: true, Limit: 5}.Input(schema, table)
+		require.NoError(t, err)
+		assert.Equal(t, "status", aws.ToString(input.IndexName))
+		assert.Equal(t, "status", input.ExpressionAttributeNames["#sk"])
+		assert.False(t, aws.ToBool(input.ScanIndexForward))
+		assert.Equal(t, int32(5), aws.ToInt32(input.Limit))
+	})
+
+	errors := []struct {
+		name  string
+		query KeyQuery
+		err   string
+	}{
+		{"unknown index", KeyQuery{Index: "missing", Partition: "u"}, `no index named "missing"`},
+		{"wrong partition type", KeyQuery{Partition: 1}, `key "username"`},
+		{"wrong sort type", KeyQuery{Partition: "u", Sort: Equal(1)}, `key "key"`},
+	}
+	for _, tt := range errors {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.query.Input(schema, table)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	t.Run("sort condition without sort key", func(t *testing.T) {
+		schema, err := SchemaFor[*counter]()
+		require.NoError(t, err)
+		_, err = KeyQuery{Partition: "visits", Sort: Equal("x")}.Input(schema, table)
+		assert.ErrorContains(t, err, "has none")
+	})
+
+	t.Run("begins with on a number", func(t *testing.T) {
+		schema, err := SchemaFor[*reading]()
+		require.NoError(t, err)
+		_, err = KeyQuery{Partition: "sensor-1", Sort: BeginsWith(1)}.Input(schema, table)
+		assert.ErrorContains(t, err, "numeric key")
+	})
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	db := newTable(t, &model.Job{}, &model.JobRecord{}, &task{})
+
+	var jobs []*model.Job
+	for i := range 5 {
+		job := newJob("user@example.com", fmt.Sprintf("host%d.example.com", i), "nuclei", model.Queued)
+		require.NoError(t, Put(ctx, db, table, job))
+		jobs = append(jobs, job)
+	}
+	require.NoError(t, Put(ctx, db, table, newJob("other@example.com", "other.example.com", "nuclei", model.Queued)))
+	record := model.NewRecord(*jobs[0])
+	record.Username = "user@example.com"
+	require.NoError(t, Put(ctx, db, table, &record))
+
+	var tasks []*task
+	statuses := []string{model.Queued, model.Running, model.Queued, model.Pass, model.Queued}
+	for i, status := range append(statuses, "") {
+		item := &task{Username: "tasks@example.com", Key: fmt.Sprintf("#task#%d", i), Created: "2024-01-01"}
+		if status != "" {
+			item.Status = fmt.Sprintf("%s#2024-01-0%d", status, 5-i)
+		}
+		require.NoError(t, Put(ctx, db, table, item))
+		tasks = append(tasks, item)
+	}
+
+	keys := func(jobs []*model.Job) []string {
+		out := []string{}
+		for _, job := range jobs {
+			out = append(out, job.Key)
+		}
+		return out
+	}
+	taskKeys := func(tasks []*task) []string {
+		out := []string{}
+		for _, task := range tasks {
+			out = append(out, task.Key)
+		}
+		return out
+	}
+
+	t.Run("partition in sort key order", func(t *testing.T) {
+		got, err := Query[*model.Job](ctx, db, table, KeyQuery{Partition: "user@example.com", Sort: BeginsWith("#job#")})
+		require.NoError(t, err)
+		assert.Equal(t, keys(jobs), keys(got))
+	})
+
+	t.Run("descending", func(t *testing.T) {
+		got, err := Query[*model.Job](ctx, db, table, KeyQuery{Partition: "user@example.com", Sort: BeginsWith("#job#"), Descending: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{jobs[4].Key, jobs[3].Key, jobs[2].Key, jobs[1].Key, jobs[0].Key}, keys(got))
+	})
+
+	t.Run("index", func(t *testing.T) {
+		got, err := Query[*task](ctx, db, table, KeyQuery{Index: "status", Partition: "tasks@example.com", Sort: BeginsWith(model.Queued)})
+		require.NoError(t, err)
+		// ordered by status, whose timestamps run backwards
+		assert.Equal(t, []string{tasks[4].Key, tasks[2].Key, tasks[0].Key}, taskKeys(got))
+	})
+
+	t.Run("index leaves out items without its keys", func(t *testing.T) {
+		got, err := Query[*task](ctx, db, table, KeyQuery{Index: "status", Partition: "tasks@example.com"})
+		require.NoError(t, err)
+		assert.Len(t, got, len(tasks)-1)
+	})
+
+	t.Run("follows pages", func(t *testing.T) {
+		db.PageSize = 2
+		defer func() { db.PageSize = 0 }()
+
+		got, err := Query[*task](ctx, db, table, KeyQuery{Index: "status", Partition: "tasks@example.com"})
+		require.NoError(t, err)
+		assert.Len(t, got, len(tasks)-1)
+
+		limited, err := Query[*model.Job](ctx, db, table, KeyQuery{Partition: "user@example.com", Sort: BeginsWith("#job#"), Limit: 3})
+		require.NoError(t, err)
+		assert.Equal(t, keys(jobs[:3]), keys(limited))
+	})
+
+	t.Run("between", func(t *testing.T) {
+		got, err := Query[*model.Job](ctx, db, table, KeyQuery{Partition: "user@example.com", Sort: Between(jobs[1].Key, jobs[3].Key)})
+		require.NoError(t, err)
+		assert.Equal(t, keys(jobs[1:4]), keys(got))
+	})
+
+	t.Run("other models in the partition", func(t *testing.T) {
+		got, err := Query[*model.JobRecord](ctx, db, table, KeyQuery{Partition: "user@example.com", Sort: BeginsWith("#jobrecord")})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, record.Key, got[0].Key)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := Query[*model.Job](ctx, db, table, KeyQuery{Index: "missing", Partition: "user@example.com"})
+		assert.Error(t, err)
+
+		_, err = Query[*model.Job](ctx, db, "missing", KeyQuery{Partition: "user@example.com"})
+		assert.ErrorContains(t, err, "does not exist")
+	})
+}
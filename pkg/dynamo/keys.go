@@ -0,0 +1,38 @@
+package dynamo
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+)
+
+// JobKey builds the primary key of the job of username running capability against target
+func JobKey(username string, target model.Target, capability string) (map[string]types.AttributeValue, error) {
+	sort, _, ok := model.JobSortKey(target.Group(), target.Identifier(), capability)
+	if !ok {
+		return nil, fmt.Errorf("job key of %s for %q is too long", capability, target.Identifier())
+	}
+	return Key[*model.Job](username, sort)
+}
+
+// StatisticKey builds the primary key of the statistic of username with the given type, name,
+// creation time and value
+func StatisticKey(username, tipe, name, created, value string) (map[string]types.AttributeValue, error) {
+	return Key[*model.Statistic](username, model.StatisticSortKey(tipe, name, created, value))
+}
+
+// CapabilityScheduleKey builds the primary key of the schedule of username with the given ID
+func CapabilityScheduleKey(username, scheduleID string) (map[string]types.AttributeValue, error) {
+	return Key[*model.CapabilitySchedule](username, model.CapabilityScheduleSortKey(scheduleID))
+}
+
+// AegisManagementTaskKey builds the primary key of the task username started with capability
+func AegisManagementTaskKey(username, capability, taskID string) (map[string]types.AttributeValue, error) {
+	return Key[*model.AegisManagementTask](username, model.AegisManagementTaskSortKey(username, capability, taskID))
+}
+
+// JobRecordKey builds the primary key of the record of username's job with jobKey, updated at recordTime
+func JobRecordKey(username, jobKey, recordTime string) (map[string]types.AttributeValue, error) {
+	return Key[*model.JobRecord](username, model.JobRecordSortKey(jobKey, recordTime))
+}
@@ -0,0 +1,65 @@
+package dynamo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelKeys(t *testing.T) {
+	const username = "user@example.com"
+
+	asset := model.NewAsset("example.com", "10.0.0.1")
+	job := model.NewJob("nuclei", &asset)
+	job.Username = username
+
+	statistic := model.NewStatistic("asset_count", "global", "all", "2024-01-01T00:00:00Z")
+	statistic.Username = username
+
+	schedule := model.NewCapabilitySchedule(username, "nuclei", asset.Key, nil, model.WeeklySchedule{}, "2024-01-01T00:00:00Z", "", "")
+
+	aegisTask := model.NewAegisManagementTask(username, "tunnel_management", "C.1234", nil, false, false)
+	taskID := aegisTask.Key[strings.LastIndex(aegisTask.Key, "#")+1:]
+
+	record := model.NewRecord(job)
+	record.Username = username
+
+	tests := []struct {
+		name  string
+		model model.TableModel
+		build func() (map[string]types.AttributeValue, error)
+	}{
+		{"job", &job, func() (map[string]types.AttributeValue, error) { return JobKey(username, &asset, "nuclei") }},
+		{"statistic", &statistic, func() (map[string]types.AttributeValue, error) {
+			return StatisticKey(username, "asset_count", "global", "2024-01-01T00:00:00Z", "all")
+		}},
+		{"capability schedule", schedule, func() (map[string]types.AttributeValue, error) {
+			return CapabilityScheduleKey(username, schedule.ScheduleID)
+		}},
+		{"aegis management task", &aegisTask, func() (map[string]types.AttributeValue, error) {
+			return AegisManagementTaskKey(username, "tunnel_management", taskID)
+		}},
+		{"job record", &record, func() (map[string]types.AttributeValue, error) {
+			return JobRecordKey(username, job.Key, job.Updated)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := KeyOf(tt.model)
+			require.NoError(t, err)
+			key, err := tt.build()
+			require.NoError(t, err)
+			assert.Equal(t, expected, key)
+		})
+	}
+
+	t.Run("job key too long", func(t *testing.T) {
+		long := model.NewAsset("example.com", strings.Repeat("a", 1024))
+		_, err := JobKey(username, &long, "nuclei")
+		assert.ErrorContains(t, err, "too long")
+	})
+}
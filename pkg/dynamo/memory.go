@@ -0,0 +1,379 @@
+package dynamo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"math/big"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Memory is an in-memory Client for tests. Tables are created by CreateTable, and behave as
+// DynamoDB's do for the requests built by this package: keys are validated, global secondary
+// indexes only hold items with their key attributes, queries return items in sort key order, and
+// results are paged by Limit and PageSize. Memory is safe for concurrent use.
+type Memory struct {
+	// PageSize caps the number of items returned by each Query, standing in for DynamoDB's
+	// 1 MB page limit. Zero means no cap.
+	PageSize int
+
+	mu     sync.Mutex
+	tables map[string]*memoryTable
+}
+
+type memoryTable struct {
+	partition Attribute
+	sort      Attribute
+	indexes   map[string]Index
+	items     map[string]map[string]types.AttributeValue
+}
+
+// NewMemory creates a Memory without tables
+func NewMemory() *Memory {
+	return &Memory{tables: map[string]*memoryTable{}}
+}
+
+// CreateTable creates the table described by input
+func (m *Memory) CreateTable(_ context.Context, input *dynamodb.CreateTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name := aws.ToString(input.TableName)
+	if _, ok := m.tables[name]; ok {
+		return nil, fmt.Errorf("table %q already exists", name)
+	}
+	defined := map[string]types.ScalarAttributeType{}
+	for _, def := range input.AttributeDefinitions {
+		defined[aws.ToString(def.AttributeName)] = def.AttributeType
+	}
+	keys := func(schema []types.KeySchemaElement) (partition, sort Attribute, err error) {
+		for _, element := range schema {
+			attr := Attribute{Name: aws.ToString(element.AttributeName), Type: defined[aws.ToString(element.AttributeName)]}
+			if attr.Type == "" {
+				return Attribute{}, Attribute{}, fmt.Errorf("key %q has no attribute definition", attr.Name)
+			}
+			if element.KeyType == types.KeyTypeHash {
+				partition = attr
+			} else {
+				sort = attr
+			}
+		}
+		if partition.Name == "" {
+			return Attribute{}, Attribute{}, fmt.Errorf("no partition key")
+		}
+		return partition, sort, nil
+	}
+
+	table := &memoryTable{indexes: map[string]Index{}, items: map[string]map[string]types.AttributeValue{}}
+	var err error
+	if table.partition, table.sort, err = keys(input.KeySchema); err != nil {
+		return nil, fmt.Errorf("table %q: %w", name, err)
+	}
+	for _, gsi := range input.GlobalSecondaryIndexes {
+		index := Index{Name: aws.ToString(gsi.IndexName)}
+		if index.PartitionKey, index.SortKey, err = keys(gsi.KeySchema); err != nil {
+			return nil, fmt.Errorf("index %q: %w", index.Name, err)
+		}
+		table.indexes[index.Name] = index
+	}
+	m.tables[name] = table
+	return &dynamodb.CreateTableOutput{}, nil
+}
+
+// PutItem stores a copy of input.Item, replacing any item with the same primary key
+func (m *Memory) PutItem(_ context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	table, err := m.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	id, err := table.id(input.Item, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range table.indexes {
+		for _, attr := range []Attribute{index.PartitionKey, index.SortKey} {
+			if av, ok := input.Item[attr.Name]; ok && attr.Name != "" {
+				if err := checkKey(attr, av); err != nil {
+					return nil, fmt.Errorf("index %q: %w", index.Name, err)
+				}
+			}
+		}
+	}
+	table.items[id] = maps.Clone(input.Item)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+// GetItem returns a copy of the item stored under input.Key, or a nil Item when there is none
+func (m *Memory) GetItem(_ context.Context, input *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	table, err := m.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	id, err := table.id(input.Key, false)
+	if err != nil {
+		return nil, err
+	}
+	item, ok := table.items[id]
+	if !ok {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	return &dynamodb.GetItemOutput{Item: maps.Clone(item)}, nil
+}
+
+// Query returns the items matching input.KeyConditionExpression, which must be one built by KeyQuery.Input
+func (m *Memory) Query(_ context.Context, input *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	table, err := m.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	partition, sort := table.partition, table.sort
+	if name := aws.ToString(input.IndexName); name != "" {
+		index, ok := table.indexes[name]
+		if !ok {
+			return nil, fmt.Errorf("table %q has no index %q", aws.ToString(input.TableName), name)
+		}
+		partition, sort = index.PartitionKey, index.SortKey
+	}
+	cond, err := parseCondition(input, partition, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []map[string]types.AttributeValue
+	for _, item := range table.items {
+		if cond.matches(item) {
+			matched = append(matched, item)
+		}
+	}
+	slices.SortFunc(matched, func(a, b map[string]types.AttributeValue) int {
+		if sort.Name != "" {
+			if c := compare(a[sort.Name], b[sort.Name]); c != 0 {
+				return c
+			}
+		}
+		// items sharing an index's sort key are ordered by their primary key
+		return table.compareKeys(a, b)
+	})
+	if input.ScanIndexForward != nil && !*input.ScanIndexForward {
+		slices.Reverse(matched)
+	}
+
+	if start := input.ExclusiveStartKey; len(start) > 0 {
+		id, err := table.id(start, true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ExclusiveStartKey: %w", err)
+		}
+		for i, item := range matched {
+			if itemID, _ := table.id(item, true); itemID == id {
+				matched = matched[i+1:]
+				break
+			}
+		}
+	}
+
+	limit := len(matched)
+	if input.Limit != nil && int(*input.Limit) < limit {
+		limit = int(*input.Limit)
+	}
+	if m.PageSize > 0 && m.PageSize < limit {
+		limit = m.PageSize
+	}
+	out := &dynamodb.QueryOutput{Items: make([]map[string]types.AttributeValue, limit)}
+	for i := range limit {
+		out.Items[i] = maps.Clone(matched[i])
+	}
+	if limit < len(matched) && limit > 0 {
+		last := matched[limit-1]
+		out.LastEvaluatedKey = map[string]types.AttributeValue{}
+		for _, attr := range []Attribute{table.partition, table.sort, partition, sort} {
+			if attr.Name != "" {
+				out.LastEvaluatedKey[attr.Name] = last[attr.Name]
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m *Memory) table(name *string) (*memoryTable, error) {
+	table, ok := m.tables[aws.ToString(name)]
+	if !ok {
+		return nil, fmt.Errorf("table %q does not exist", aws.ToString(name))
+	}
+	return table, nil
+}
+
+// id identifies the item with the primary key held by item. Unless extra is set, item must hold
+// nothing but the primary key.
+func (t *memoryTable) id(item map[string]types.AttributeValue, extra bool) (string, error) {
+	var b strings.Builder
+	count := 0
+	for _, attr := range []Attribute{t.partition, t.sort} {
+		if attr.Name == "" {
+			continue
+		}
+		if err := checkKey(attr, item[attr.Name]); err != nil {
+			return "", err
+		}
+		b.WriteString(canonical(item[attr.Name]))
+		b.WriteByte(0)
+		count++
+	}
+	if !extra && len(item) != count {
+		return "", fmt.Errorf("key holds attributes other than the primary key")
+	}
+	return b.String(), nil
+}
+
+func (t *memoryTable) compareKeys(a, b map[string]types.AttributeValue) int {
+	if c := compare(a[t.partition.Name], b[t.partition.Name]); c != 0 || t.sort.Name == "" {
+		return c
+	}
+	return compare(a[t.sort.Name], b[t.sort.Name])
+}
+
+var (
+	partitionCondition = regexp.MustCompile(`^#pk = :pk(?: AND (.+))?$`)
+	sortCondition      = regexp.MustCompile(`^(?:#sk (=|<|>) :sk|begins_with\(#sk, :sk\)|#sk BETWEEN :sk AND :sk2)$`)
+)
+
+type condition struct {
+	partition Attribute
+	sort      Attribute
+	pk        types.AttributeValue
+	op        string
+	values    []types.AttributeValue
+}
+
+// parseCondition reads the key condition of input, which must use the expressions written by KeyQuery.Input
+func parseCondition(input *dynamodb.QueryInput, partition, sort Attribute) (condition, error) {
+	expr := aws.ToString(input.KeyConditionExpression)
+	match := partitionCondition.FindStringSubmatch(expr)
+	if match == nil || input.ExpressionAttributeNames["#pk"] != partition.Name {
+		return condition{}, fmt.Errorf("unsupported key condition %q", expr)
+	}
+	cond := condition{partition: partition, sort: sort, pk: input.ExpressionAttributeValues[":pk"]}
+	if err := checkKey(partition, cond.pk); err != nil {
+		return condition{}, err
+	}
+	if match[1] == "" {
+		return cond, nil
+	}
+
+	sortMatch := sortCondition.FindStringSubmatch(match[1])
+	if sortMatch == nil || sort.Name == "" || input.ExpressionAttributeNames["#sk"] != sort.Name {
+		return condition{}, fmt.Errorf("unsupported key condition %q", expr)
+	}
+	switch {
+	case sortMatch[1] != "":
+		cond.op = sortMatch[1]
+	case strings.HasPrefix(match[1], "begins_with"):
+		cond.op = "begins_with"
+	default:
+		cond.op = "BETWEEN"
+	}
+	placeholders := []string{":sk"}
+	if cond.op == "BETWEEN" {
+		placeholders = append(placeholders, ":sk2")
+	}
+	for _, placeholder := range placeholders {
+		av := input.ExpressionAttributeValues[placeholder]
+		if err := checkKey(sort, av); err != nil {
+			return condition{}, err
+		}
+		cond.values = append(cond.values, av)
+	}
+	return cond, nil
+}
+
+func (c condition) matches(item map[string]types.AttributeValue) bool {
+	pk, ok := item[c.partition.Name]
+	if !ok || compare(pk, c.pk) != 0 {
+		return false
+	}
+	if c.sort.Name == "" {
+		return true
+	}
+	sk, ok := item[c.sort.Name]
+	if !ok || checkKey(c.sort, sk) != nil {
+		// items without the sort key of an index are not in the index
+		return false
+	}
+
+	switch c.op {
+	case "=":
+		return compare(sk, c.values[0]) == 0
+	case "<":
+		return compare(sk, c.values[0]) < 0
+	case ">":
+		return compare(sk, c.values[0]) > 0
+	case "BETWEEN":
+		return compare(sk, c.values[0]) >= 0 && compare(sk, c.values[1]) <= 0
+	case "begins_with":
+		switch v := sk.(type) {
+		case *types.AttributeValueMemberS:
+			return strings.HasPrefix(v.Value, c.values[0].(*types.AttributeValueMemberS).Value)
+		case *types.AttributeValueMemberB:
+			return bytes.HasPrefix(v.Value, c.values[0].(*types.AttributeValueMemberB).Value)
+		}
+		return false
+	}
+	return true
+}
+
+// compare orders two key values of the same type as DynamoDB does: strings and binary by their
+// bytes, and numbers by value
+func compare(a, b types.AttributeValue) int {
+	switch a := a.(type) {
+	case *types.AttributeValueMemberS:
+		if b, ok := b.(*types.AttributeValueMemberS); ok {
+			return strings.Compare(a.Value, b.Value)
+		}
+	case *types.AttributeValueMemberN:
+		if b, ok := b.(*types.AttributeValueMemberN); ok {
+			return number(a.Value).Cmp(number(b.Value))
+		}
+	case *types.AttributeValueMemberB:
+		if b, ok := b.(*types.AttributeValueMemberB); ok {
+			return bytes.Compare(a.Value, b.Value)
+		}
+	}
+	return strings.Compare(canonical(a), canonical(b))
+}
+
+// canonical encodes a key value so that equal values encode the same way
+func canonical(av types.AttributeValue) string {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return "S" + v.Value
+	case *types.AttributeValueMemberN:
+		return "N" + number(v.Value).Text('g', -1)
+	case *types.AttributeValueMemberB:
+		return "B" + string(v.Value)
+	}
+	return fmt.Sprintf("%T", av)
+}
+
+func number(s string) *big.Float {
+	f, _, err := big.ParseFloat(s, 10, 256, big.ToNearestEven)
+	if err != nil {
+		return new(big.Float)
+	}
+	return f
+}
@@ -0,0 +1,188 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_CreateTable(t *testing.T) {
+	ctx := context.Background()
+	input, err := NewCreateTableInput(table, &model.Job{})
+	require.NoError(t, err)
+
+	db := NewMemory()
+	_, err = db.CreateTable(ctx, input)
+	require.NoError(t, err)
+	_, err = db.CreateTable(ctx, input)
+	assert.ErrorContains(t, err, "already exists")
+
+	undefined := &dynamodb.CreateTableInput{
+		TableName: aws.String("undefined"),
+		KeySchema: []types.KeySchemaElement{{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash}},
+	}
+	_, err = db.CreateTable(ctx, undefined)
+	assert.ErrorContains(t, err, "no attribute definition")
+}
+
+func TestMemory_Items(t *testing.T) {
+	ctx := context.Background()
+	db := newTable(t, &task{})
+	item := map[string]types.AttributeValue{
+		"username": &types.AttributeValueMemberS{Value: "user@example.com"},
+		"key":      &types.AttributeValueMemberS{Value: "#job#example.com"},
+		"status":   &types.AttributeValueMemberS{Value: model.Queued},
+	}
+	_, err := db.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(table), Item: item})
+	require.NoError(t, err)
+
+	t.Run("stores a copy", func(t *testing.T) {
+		item["status"] = &types.AttributeValueMemberS{Value: model.Running}
+		defer func() { item["status"] = &types.AttributeValueMemberS{Value: model.Queued} }()
+
+		out, err := db.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(table), Key: map[string]types.AttributeValue{
+			"username": item["username"],
+			"key":      item["key"],
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, &types.AttributeValueMemberS{Value: model.Queued}, out.Item["status"])
+	})
+
+	tests := []struct {
+		name string
+		item map[string]types.AttributeValue
+		err  string
+	}{
+		{"missing sort key", map[string]types.AttributeValue{
+			"username": &types.AttributeValueMemberS{Value: "user@example.com"},
+		}, `key "key"`},
+		{"wrong key type", map[string]types.AttributeValue{
+			"username": &types.AttributeValueMemberS{Value: "user@example.com"},
+			"key":      &types.AttributeValueMemberN{Value: "1"},
+		}, `key "key"`},
+		{"wrong index key type", map[string]types.AttributeValue{
+			"username": &types.AttributeValueMemberS{Value: "user@example.com"},
+			"key":      &types.AttributeValueMemberS{Value: "#job#example.com"},
+			"status":   &types.AttributeValueMemberBOOL{Value: true},
+		}, `index "status"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(table), Item: tt.item})
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	t.Run("key holding other attributes", func(t *testing.T) {
+		_, err := db.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(table), Key: item})
+		assert.ErrorContains(t, err, "other than the primary key")
+	})
+}
+
+func TestMemory_Query(t *testing.T) {
+	ctx := context.Background()
+	db := newTable(t, &reading{})
+	readings := []*reading{
+		{Sensor: "sensor-1", At: 100, Level: "high", Value: 9.5},
+		{Sensor: "sensor-1", At: 20, Level: "low", Value: 1},
+		{Sensor: "sensor-1", At: 3, Value: 4},
+		{Sensor: "sensor-2", At: 50, Level: "high", Value: 12},
+		{Sensor: "sensor-1", At: 1000, Level: "high", Value: 9.5},
+	}
+	for _, r := range readings {
+		require.NoError(t, Put(ctx, db, table, r))
+	}
+
+	at := func(readings []*reading) []int64 {
+		out := []int64{}
+		for _, r := range readings {
+			out = append(out, r.At)
+		}
+		return out
+	}
+
+	t.Run("numbers in numeric order", func(t *testing.T) {
+		got, err := Query[*reading](ctx, db, table, KeyQuery{Partition: "sensor-1"})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{3, 20, 100, 1000}, at(got))
+	})
+
+	t.Run("sort conditions", func(t *testing.T) {
+		tests := []struct {
+			name string
+			sort SortCondition
+			want []int64
+		}{
+			{"equal", Equal(20), []int64{20}},
+			{"less than", LessThan(100), []int64{3, 20}},
+			{"greater than", GreaterThan(20), []int64{100, 1000}},
+			{"between", Between(3, 100), []int64{3, 20, 100}},
+			{"nothing", Between(101, 999), []int64{}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := Query[*reading](ctx, db, table, KeyQuery{Partition: "sensor-1", Sort: tt.sort})
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, at(got))
+			})
+		}
+	})
+
+	t.Run("index with its own partition key", func(t *testing.T) {
+		got, err := Query[*reading](ctx, db, table, KeyQuery{Index: "level", Partition: "high", Descending: true})
+		require.NoError(t, err)
+		// equal values are ordered by primary key, then reversed
+		assert.Equal(t, []int64{50, 1000, 100}, at(got))
+	})
+
+	t.Run("index pages", func(t *testing.T) {
+		db.PageSize = 1
+		defer func() { db.PageSize = 0 }()
+
+		schema, err := SchemaFor[*reading]()
+		require.NoError(t, err)
+		input, err := KeyQuery{Index: "level", Partition: "high"}.Input(schema, table)
+		require.NoError(t, err)
+
+		var got []string
+		for {
+			out, err := db.Query(ctx, input)
+			require.NoError(t, err)
+			for _, item := range out.Items {
+				got = append(got, item["at"].(*types.AttributeValueMemberN).Value)
+			}
+			if out.LastEvaluatedKey == nil {
+				break
+			}
+			assert.Len(t, out.LastEvaluatedKey, 4, "table and index keys")
+			input.ExclusiveStartKey = out.LastEvaluatedKey
+		}
+		assert.Equal(t, []string{"100", "1000", "50"}, got)
+	})
+
+	t.Run("unsupported expressions", func(t *testing.T) {
+		input := &dynamodb.QueryInput{
+			TableName:                 aws.String(table),
+			KeyConditionExpression:    aws.String("sensor = :pk"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{":pk": &types.AttributeValueMemberS{Value: "sensor-1"}},
+		}
+		_, err := db.Query(ctx, input)
+		assert.ErrorContains(t, err, "unsupported key condition")
+
+		input.KeyConditionExpression = aws.String("#pk = :pk AND #sk <= :sk")
+		input.ExpressionAttributeNames = map[string]string{"#pk": "sensor", "#sk": "at"}
+		_, err = db.Query(ctx, input)
+		assert.ErrorContains(t, err, "unsupported key condition")
+	})
+
+	t.Run("unknown index", func(t *testing.T) {
+		_, err := db.Query(ctx, &dynamodb.QueryInput{TableName: aws.String(table), IndexName: aws.String("missing")})
+		assert.ErrorContains(t, err, `no index "missing"`)
+	})
+}
@@ -0,0 +1,279 @@
+// Package dynamo derives DynamoDB table designs from TableModel types, and reads and writes
+// those models through them.
+//
+// The keys of a table model are declared with table tags on the fields that hold them, using the
+// attribute names of their dynamodbav tags:
+//
+//	table:"pk"            the partition key of the table
+//	table:"sk"            the sort key of the table
+//	table:"gsi:status"    the sort key of the global secondary index "status", which shares the table's partition key
+//	table:"gsi:byjob:pk"  the partition key of the global secondary index "byjob"
+//	table:"gsi:byjob:sk"  the sort key of the global secondary index "byjob"
+//
+// A field may hold several keys, separated by commas. Key attributes must be strings, numbers or
+// byte slices.
+//
+// Client is the part of *dynamodb.Client the helpers use, and Memory is an in-memory fake of it
+// for tests.
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+)
+
+// Attribute is a key attribute of a table or index
+type Attribute struct {
+	Name string
+	Type types.ScalarAttributeType
+}
+
+// Index is a global secondary index of a table
+type Index struct {
+	Name         string
+	PartitionKey Attribute
+	SortKey      Attribute // the zero Attribute when the index has no sort key
+}
+
+// Schema is the key design of a table model, as declared by its table tags
+type Schema struct {
+	PartitionKey Attribute
+	SortKey      Attribute // the zero Attribute when the table has no sort key
+	Indexes      []Index   // ordered by name
+}
+
+// Index returns the index named name
+func (s Schema) Index(name string) (Index, bool) {
+	for _, index := range s.Indexes {
+		if index.Name == name {
+			return index, true
+		}
+	}
+	return Index{}, false
+}
+
+// keys returns the partition and sort key of the table, or of the named index
+func (s Schema) keys(index string) (partition Attribute, sort Attribute, err error) {
+	if index == "" {
+		return s.PartitionKey, s.SortKey, nil
+	}
+	idx, ok := s.Index(index)
+	if !ok {
+		return Attribute{}, Attribute{}, fmt.Errorf("no index named %q", index)
+	}
+	return idx.PartitionKey, idx.SortKey, nil
+}
+
+var schemaCache sync.Map // reflect.Type -> schemaResult
+
+type schemaResult struct {
+	schema Schema
+	err    error
+}
+
+// SchemaOf returns the key design declared by m's table tags
+func SchemaOf(m model.TableModel) (Schema, error) {
+	return schemaOf(reflect.TypeOf(m))
+}
+
+// SchemaFor returns the key design declared by T's table tags
+func SchemaFor[T model.TableModel]() (Schema, error) {
+	return schemaOf(reflect.TypeFor[T]())
+}
+
+func schemaOf(t reflect.Type) (Schema, error) {
+	if cached, ok := schemaCache.Load(t); ok {
+		result := cached.(schemaResult)
+		return result.schema, result.err
+	}
+	schema, err := buildSchema(t)
+	schemaCache.Store(t, schemaResult{schema: schema, err: err})
+	return schema, err
+}
+
+func buildSchema(t reflect.Type) (Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return Schema{}, fmt.Errorf("%s is not a struct", t)
+	}
+
+	var schema Schema
+	indexes := map[string]*Index{}
+	for _, sf := range reflect.VisibleFields(t) {
+		tag, ok := sf.Tag.Lookup("table")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		attr, err := attribute(sf)
+		if err != nil {
+			return Schema{}, fmt.Errorf("%s.%s: %w", t.Name(), sf.Name, err)
+		}
+
+		for _, role := range strings.Split(tag, ",") {
+			var slot *Attribute
+			switch parts := strings.Split(role, ":"); {
+			case role == "pk":
+				slot = &schema.PartitionKey
+			case role == "sk":
+				slot = &schema.SortKey
+			case parts[0] == "gsi" && len(parts) >= 2 && len(parts) <= 3 && parts[1] != "":
+				index, ok := indexes[parts[1]]
+				if !ok {
+					index = &Index{Name: parts[1]}
+					indexes[parts[1]] = index
+				}
+				switch {
+				case len(parts) == 2 || parts[2] == "sk":
+					slot = &index.SortKey
+				case parts[2] == "pk":
+					slot = &index.PartitionKey
+				}
+			}
+			if slot == nil {
+				return Schema{}, fmt.Errorf("%s.%s: unknown table key %q", t.Name(), sf.Name, role)
+			}
+			if slot.Name != "" {
+				return Schema{}, fmt.Errorf("%s.%s: table key %q is already held by %q", t.Name(), sf.Name, role, slot.Name)
+			}
+			*slot = attr
+		}
+	}
+
+	if schema.PartitionKey.Name == "" {
+		return Schema{}, fmt.Errorf("%s declares no partition key", t.Name())
+	}
+	for _, index := range indexes {
+		if index.PartitionKey.Name == "" {
+			index.PartitionKey = schema.PartitionKey
+		}
+		if index.SortKey.Name == "" && index.PartitionKey == schema.PartitionKey {
+			return Schema{}, fmt.Errorf("%s: index %q has the table's partition key and no sort key", t.Name(), index.Name)
+		}
+		schema.Indexes = append(schema.Indexes, *index)
+	}
+	slices.SortFunc(schema.Indexes, func(a, b Index) int { return strings.Compare(a.Name, b.Name) })
+	return schema, nil
+}
+
+// attribute returns the key attribute stored by sf
+func attribute(sf reflect.StructField) (Attribute, error) {
+	name, _, _ := strings.Cut(sf.Tag.Get("dynamodbav"), ",")
+	if name == "-" {
+		return Attribute{}, fmt.Errorf("key is not stored")
+	}
+	if name == "" {
+		name = sf.Name
+	}
+
+	switch t := sf.Type; t.Kind() {
+	case reflect.String:
+		return Attribute{Name: name, Type: types.ScalarAttributeTypeS}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return Attribute{Name: name, Type: types.ScalarAttributeTypeN}, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Attribute{Name: name, Type: types.ScalarAttributeTypeB}, nil
+		}
+	}
+	return Attribute{}, fmt.Errorf("key has unsupported type %s", sf.Type)
+}
+
+// NewCreateTableInput designs the table holding models, which must agree on the table's keys and on
+// the definition of any index they share. Indexes project every attribute, and the table bills per request.
+func NewCreateTableInput(table string, models ...model.TableModel) (*dynamodb.CreateTableInput, error) {
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no models for table %q", table)
+	}
+
+	var base Schema
+	indexes := map[string]Index{}
+	for i, m := range models {
+		schema, err := SchemaOf(m)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			base = schema
+		} else if schema.PartitionKey != base.PartitionKey || schema.SortKey != base.SortKey {
+			return nil, fmt.Errorf("%T and %T declare different keys for table %q", models[0], m, table)
+		}
+		for _, index := range schema.Indexes {
+			if existing, ok := indexes[index.Name]; ok && existing != index {
+				return nil, fmt.Errorf("%T declares index %q differently from another model", m, index.Name)
+			}
+			indexes[index.Name] = index
+		}
+	}
+
+	input := &dynamodb.CreateTableInput{
+		TableName:   aws.String(table),
+		KeySchema:   keySchema(base.PartitionKey, base.SortKey),
+		BillingMode: types.BillingModePayPerRequest,
+	}
+	attributes := map[string]types.ScalarAttributeType{}
+	define := func(attrs ...Attribute) error {
+		for _, attr := range attrs {
+			if attr.Name == "" {
+				continue
+			}
+			if existing, ok := attributes[attr.Name]; ok && existing != attr.Type {
+				return fmt.Errorf("attribute %q is declared as both %s and %s", attr.Name, existing, attr.Type)
+			}
+			attributes[attr.Name] = attr.Type
+		}
+		return nil
+	}
+	if err := define(base.PartitionKey, base.SortKey); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		index := indexes[name]
+		if err := define(index.PartitionKey, index.SortKey); err != nil {
+			return nil, err
+		}
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, types.GlobalSecondaryIndex{
+			IndexName:  aws.String(index.Name),
+			KeySchema:  keySchema(index.PartitionKey, index.SortKey),
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		})
+	}
+
+	attributeNames := make([]string, 0, len(attributes))
+	for name := range attributes {
+		attributeNames = append(attributeNames, name)
+	}
+	slices.Sort(attributeNames)
+	for _, name := range attributeNames {
+		input.AttributeDefinitions = append(input.AttributeDefinitions, types.AttributeDefinition{
+			AttributeName: aws.String(name),
+			AttributeType: attributes[name],
+		})
+	}
+	return input, nil
+}
+
+func keySchema(partition, sort Attribute) []types.KeySchemaElement {
+	out := []types.KeySchemaElement{{AttributeName: aws.String(partition.Name), KeyType: types.KeyTypeHash}}
+	if sort.Name != "" {
+		out = append(out, types.KeySchemaElement{AttributeName: aws.String(sort.Name), KeyType: types.KeyTypeRange})
+	}
+	return out
+}
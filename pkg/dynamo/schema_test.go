@@ -0,0 +1,263 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reading is a table model with numeric keys and an index with its own partition key
+type reading struct {
+	registry.BaseModel
+	Sensor string  `dynamodbav:"sensor" table:"pk"`
+	At     int64   `dynamodbav:"at" table:"sk"`
+	Level  string  `dynamodbav:"level,omitempty" table:"gsi:level:pk"`
+	Value  float64 `dynamodbav:"value" table:"gsi:level:sk"`
+}
+
+func (r *reading) GetDescription() string { return "A sensor reading" }
+func (r *reading) TableModel()            {}
+
+// counter is a table model without a sort key
+type counter struct {
+	registry.BaseModel
+	Name  string `dynamodbav:"name" table:"pk"`
+	Count int    `dynamodbav:"count"`
+}
+
+func (c *counter) GetDescription() string { return "A named counter" }
+func (c *counter) TableModel()            {}
+
+type untagged struct {
+	registry.BaseModel
+	Key string `dynamodbav:"key"`
+}
+
+func (u *untagged) GetDescription() string { return "A model without table tags" }
+func (u *untagged) TableModel()            {}
+
+type badRole struct {
+	registry.BaseModel
+	Key string `dynamodbav:"key" table:"pk,lsi"`
+}
+
+func (b *badRole) GetDescription() string { return "A model with an unknown table key" }
+func (b *badRole) TableModel()            {}
+
+type twoPartitions struct {
+	registry.BaseModel
+	A string `dynamodbav:"a" table:"pk"`
+	B string `dynamodbav:"b" table:"pk"`
+}
+
+func (t *twoPartitions) GetDescription() string { return "A model with two partition keys" }
+func (t *twoPartitions) TableModel()            {}
+
+type mapKey struct {
+	registry.BaseModel
+	Key map[string]string `dynamodbav:"key" table:"pk"`
+}
+
+func (m *mapKey) GetDescription() string { return "A model with a map key" }
+func (m *mapKey) TableModel()            {}
+
+type hiddenKey struct {
+	registry.BaseModel
+	Key string `dynamodbav:"-" table:"pk"`
+}
+
+func (h *hiddenKey) GetDescription() string { return "A model with an unstored key" }
+func (h *hiddenKey) TableModel()            {}
+
+type bareIndex struct {
+	registry.BaseModel
+	Username string `dynamodbav:"username" table:"pk,gsi:user:pk"`
+}
+
+func (b *bareIndex) GetDescription() string {
+	return "A model with an index on the partition key alone"
+}
+func (b *bareIndex) TableModel() {}
+
+// task shares the job table's keys, and declares indexes on its status and creation time
+type task struct {
+	registry.BaseModel
+	Username string `dynamodbav:"username" table:"pk"`
+	Key      string `dynamodbav:"key" table:"sk"`
+	Status   string `dynamodbav:"status,omitempty" table:"gsi:status"`
+	Created  string `dynamodbav:"created" table:"gsi:created"`
+}
+
+func (t *task) GetDescription() string { return "A task with indexes" }
+func (t *task) TableModel()            {}
+
+// numericStatus shares the task table's keys, but declares the status index on a number
+type numericStatus struct {
+	registry.BaseModel
+	Username string `dynamodbav:"username" table:"pk"`
+	Key      string `dynamodbav:"key" table:"sk"`
+	Status   int    `dynamodbav:"status" table:"gsi:status"`
+}
+
+func (n *numericStatus) GetDescription() string { return "A model with a numeric status" }
+func (n *numericStatus) TableModel()            {}
+
+// numericCreated declares a differently named index on a number held by another model as a string
+type numericCreated struct {
+	registry.BaseModel
+	Username string `dynamodbav:"username" table:"pk"`
+	Key      string `dynamodbav:"key" table:"sk"`
+	Created  int64  `dynamodbav:"created" table:"gsi:bycreated"`
+}
+
+func (n *numericCreated) GetDescription() string { return "A model with a numeric creation time" }
+func (n *numericCreated) TableModel()            {}
+
+func TestSchemaFor(t *testing.T) {
+	t.Run("job", func(t *testing.T) {
+		schema, err := SchemaFor[*model.Job]()
+		require.NoError(t, err)
+
+		assert.Equal(t, Schema{
+			PartitionKey: Attribute{Name: "username", Type: types.ScalarAttributeTypeS},
+			SortKey:      Attribute{Name: "key", Type: types.ScalarAttributeTypeS},
+		}, schema)
+	})
+
+	t.Run("indexes sharing the partition key", func(t *testing.T) {
+		schema, err := SchemaFor[*task]()
+		require.NoError(t, err)
+
+		username := Attribute{Name: "username", Type: types.ScalarAttributeTypeS}
+		assert.Equal(t, []Index{
+			{Name: "created", PartitionKey: username, SortKey: Attribute{Name: "created", Type: types.ScalarAttributeTypeS}},
+			{Name: "status", PartitionKey: username, SortKey: Attribute{Name: "status", Type: types.ScalarAttributeTypeS}},
+		}, schema.Indexes)
+	})
+
+	t.Run("index with its own partition key", func(t *testing.T) {
+		schema, err := SchemaOf(&reading{})
+		require.NoError(t, err)
+
+		assert.Equal(t, Attribute{Name: "at", Type: types.ScalarAttributeTypeN}, schema.SortKey)
+		index, ok := schema.Index("level")
+		require.True(t, ok)
+		assert.Equal(t, Index{
+			Name:         "level",
+			PartitionKey: Attribute{Name: "level", Type: types.ScalarAttributeTypeS},
+			SortKey:      Attribute{Name: "value", Type: types.ScalarAttributeTypeN},
+		}, index)
+	})
+
+	t.Run("every tagged table model", func(t *testing.T) {
+		for _, name := range registry.GetTypes[model.TableModel](registry.Registry) {
+			m, _ := registry.Registry.MakeType(name)
+			schema, err := SchemaOf(m.(model.TableModel))
+			if err != nil {
+				assert.ErrorContains(t, err, "declares no partition key", name)
+				continue
+			}
+			assert.Equal(t, "username", schema.PartitionKey.Name, name)
+			assert.Equal(t, "key", schema.SortKey.Name, name)
+		}
+	})
+
+	tests := []struct {
+		name  string
+		model model.TableModel
+		err   string
+	}{
+		{"no partition key", &untagged{}, "declares no partition key"},
+		{"unknown key", &badRole{}, `unknown table key "lsi"`},
+		{"two partition keys", &twoPartitions{}, `already held by "a"`},
+		{"unsupported type", &mapKey{}, "unsupported type"},
+		{"unstored key", &hiddenKey{}, "not stored"},
+		{"index without sort key", &bareIndex{}, `index "user"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SchemaOf(tt.model)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestNewCreateTableInput(t *testing.T) {
+	t.Run("table models", func(t *testing.T) {
+		input, err := NewCreateTableInput("chariot",
+			&model.Job{}, &model.JobRecord{}, &model.Statistic{}, &model.CapabilitySchedule{},
+			&model.AegisManagementTask{}, &model.TraceEvent{}, &task{})
+		require.NoError(t, err)
+
+		definition := func(name string) types.AttributeDefinition {
+			return types.AttributeDefinition{AttributeName: aws.String(name), AttributeType: types.ScalarAttributeTypeS}
+		}
+		keySchema := func(partition, sort string) []types.KeySchemaElement {
+			return []types.KeySchemaElement{
+				{AttributeName: aws.String(partition), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String(sort), KeyType: types.KeyTypeRange},
+			}
+		}
+		index := func(name string) types.GlobalSecondaryIndex {
+			return types.GlobalSecondaryIndex{
+				IndexName:  aws.String(name),
+				KeySchema:  keySchema("username", name),
+				Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+			}
+		}
+
+		assert.Equal(t, &dynamodb.CreateTableInput{
+			TableName: aws.String("chariot"),
+			AttributeDefinitions: []types.AttributeDefinition{
+				definition("created"), definition("key"), definition("status"), definition("username"),
+			},
+			KeySchema:              keySchema("username", "key"),
+			GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{index("created"), index("status")},
+			BillingMode:            types.BillingModePayPerRequest,
+		}, input)
+	})
+
+	t.Run("every tagged table model", func(t *testing.T) {
+		var models []model.TableModel
+		for _, name := range registry.GetTypes[model.TableModel](registry.Registry) {
+			m, _ := registry.Registry.MakeType(name)
+			if _, err := SchemaOf(m.(model.TableModel)); err == nil {
+				models = append(models, m.(model.TableModel))
+			}
+		}
+		_, err := NewCreateTableInput("chariot", models...)
+		assert.NoError(t, err)
+	})
+
+	t.Run("table without sort key", func(t *testing.T) {
+		input, err := NewCreateTableInput("counters", &counter{})
+		require.NoError(t, err)
+		assert.Equal(t, []types.KeySchemaElement{{AttributeName: aws.String("name"), KeyType: types.KeyTypeHash}}, input.KeySchema)
+		assert.Equal(t, []types.AttributeDefinition{{AttributeName: aws.String("name"), AttributeType: types.ScalarAttributeTypeS}}, input.AttributeDefinitions)
+		assert.Empty(t, input.GlobalSecondaryIndexes)
+	})
+
+	tests := []struct {
+		name   string
+		models []model.TableModel
+		err    string
+	}{
+		{"no models", nil, "no models"},
+		{"untagged model", []model.TableModel{&untagged{}}, "declares no partition key"},
+		{"different table keys", []model.TableModel{&model.Job{}, &reading{}}, "declare different keys"},
+		{"different index definitions", []model.TableModel{&task{}, &numericStatus{}}, `index "status" differently`},
+		{"conflicting attribute types", []model.TableModel{&task{}, &numericCreated{}}, `attribute "created"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCreateTableInput("chariot", tt.models...)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,320 @@
+// Package graph holds registered graph models in memory as Neo4j would, and converts them to and
+// from the properties stored on Neo4j nodes and relationships.
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// Direction selects which relationships of a node are considered during a neighbor lookup
+type Direction string
+
+const (
+	// Outgoing matches relationships where the node is the source
+	Outgoing Direction = "outgoing"
+	// Incoming matches relationships where the node is the target
+	Incoming Direction = "incoming"
+	// Both matches relationships in either direction
+	Both Direction = "both"
+)
+
+// Graph is an in-memory store of GraphModel nodes and GraphRelationship edges.
+// Nodes are keyed by GetKey() and relationships by their base key. Upserts apply the
+// stored type's own Merge/Visit logic, mirroring what the backing graph database does.
+// Graph is safe for concurrent use.
+type Graph struct {
+	mu            sync.RWMutex
+	nodes         map[string]model.GraphModel
+	relationships map[string]model.GraphRelationship
+	outgoing      map[string]map[string]struct{}
+	incoming      map[string]map[string]struct{}
+}
+
+// New creates an empty graph
+func New() *Graph {
+	return &Graph{
+		nodes:         make(map[string]model.GraphModel),
+		relationships: make(map[string]model.GraphRelationship),
+		outgoing:      make(map[string]map[string]struct{}),
+		incoming:      make(map[string]map[string]struct{}),
+	}
+}
+
+// AddNode inserts a node, or visits the existing node with the same key using the
+// stored type's Visit method. This is how capability output is applied to the graph.
+// The stored node is returned.
+func (g *Graph) AddNode(node model.GraphModel) (model.GraphModel, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.upsertNode(node, "Visit")
+}
+
+// MergeNode inserts a node, or merges it into the existing node with the same key using
+// the stored type's Merge method. This is how user-initiated updates are applied to the graph.
+// The stored node is returned.
+func (g *Graph) MergeNode(node model.GraphModel) (model.GraphModel, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.upsertNode(node, "Merge")
+}
+
+// AddRelationship inserts a relationship, or visits the existing relationship with the same key.
+// The source and target nodes are added to the graph as with AddNode, and the stored relationship
+// is re-pointed at the stored nodes.
+func (g *Graph) AddRelationship(rel model.GraphRelationship) (model.GraphRelationship, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if rel == nil || rel.Base() == nil {
+		return nil, fmt.Errorf("relationship is nil")
+	}
+	if rel.GetKey() == "" {
+		return nil, fmt.Errorf("relationship has no key")
+	}
+	source, target := rel.Nodes()
+	if source == nil || target == nil {
+		return nil, fmt.Errorf("relationship %q is missing a source or target", rel.GetKey())
+	}
+	if !rel.Valid() {
+		return nil, fmt.Errorf("invalid relationship %q", rel.GetKey())
+	}
+	// check both nodes before storing either, so that a failure leaves the graph unchanged
+	if err := g.checkNode(source, "Visit"); err != nil {
+		return nil, fmt.Errorf("failed to add source of %q: %w", rel.GetKey(), err)
+	}
+	if err := g.checkNode(target, "Visit"); err != nil {
+		return nil, fmt.Errorf("failed to add target of %q: %w", rel.GetKey(), err)
+	}
+
+	storedSource, err := g.upsertNode(source, "Visit")
+	if err != nil {
+		return nil, fmt.Errorf("failed to add source of %q: %w", rel.GetKey(), err)
+	}
+	storedTarget, err := g.upsertNode(target, "Visit")
+	if err != nil {
+		return nil, fmt.Errorf("failed to add target of %q: %w", rel.GetKey(), err)
+	}
+
+	key := rel.GetKey()
+	stored, ok := g.relationships[key]
+	if ok {
+		stored.Visit(rel)
+	} else {
+		stored = rel
+		g.relationships[key] = stored
+	}
+	stored.Base().Source = storedSource
+	stored.Base().Target = storedTarget
+
+	link(g.outgoing, storedSource.GetKey(), key)
+	link(g.incoming, storedTarget.GetKey(), key)
+	return stored, nil
+}
+
+// Apply adds every graph model and relationship in items, as emitted by Job.Send.
+// Items that are neither a GraphModel nor a GraphRelationship are ignored.
+func (g *Graph) Apply(items ...registry.Model) error {
+	for _, item := range items {
+		switch i := item.(type) {
+		case model.GraphRelationship:
+			if _, err := g.AddRelationship(i); err != nil {
+				return err
+			}
+		case model.GraphModel:
+			if _, err := g.AddNode(i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Node returns the node stored under key
+func (g *Graph) Node(key string) (model.GraphModel, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	node, ok := g.nodes[key]
+	return node, ok
+}
+
+// Relationship returns the relationship stored under key
+func (g *Graph) Relationship(key string) (model.GraphRelationship, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	rel, ok := g.relationships[key]
+	return rel, ok
+}
+
+// Nodes returns all nodes carrying the given label, ordered by key. An empty label matches every node.
+func (g *Graph) Nodes(label string) []model.GraphModel {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	out := []model.GraphModel{}
+	for _, key := range sortedKeys(g.nodes) {
+		node := g.nodes[key]
+		if label == "" || slices.Contains(node.GetLabels(), label) {
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
+// Relationships returns the relationships of the node stored under key that have the given label
+// and direction, ordered by key. An empty label matches every relationship.
+func (g *Graph) Relationships(key, label string, direction Direction) []model.GraphRelationship {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	out := []model.GraphRelationship{}
+	for _, relKey := range g.relationshipKeys(key, direction) {
+		rel := g.relationships[relKey]
+		if label == "" || rel.Label() == label {
+			out = append(out, rel)
+		}
+	}
+	return out
+}
+
+// Neighbors returns the nodes connected to the node stored under key by relationships that have the
+// given label and direction. Each neighbor is returned once, ordered by key.
+func (g *Graph) Neighbors(key, label string, direction Direction) []model.GraphModel {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	seen := map[string]model.GraphModel{}
+	if direction == Outgoing || direction == Both {
+		for _, relKey := range g.relationshipKeys(key, Outgoing) {
+			if rel := g.relationships[relKey]; label == "" || rel.Label() == label {
+				_, target := rel.Nodes()
+				seen[target.GetKey()] = target
+			}
+		}
+	}
+	if direction == Incoming || direction == Both {
+		for _, relKey := range g.relationshipKeys(key, Incoming) {
+			if rel := g.relationships[relKey]; label == "" || rel.Label() == label {
+				source, _ := rel.Nodes()
+				seen[source.GetKey()] = source
+			}
+		}
+	}
+
+	out := make([]model.GraphModel, 0, len(seen))
+	for _, k := range sortedKeys(seen) {
+		out = append(out, seen[k])
+	}
+	return out
+}
+
+// RemoveNode deletes the node stored under key along with all of its relationships
+func (g *Graph) RemoveNode(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, relKey := range g.relationshipKeys(key, Both) {
+		g.removeRelationship(relKey)
+	}
+	delete(g.nodes, key)
+	delete(g.outgoing, key)
+	delete(g.incoming, key)
+}
+
+// RemoveRelationship deletes the relationship stored under key
+func (g *Graph) RemoveRelationship(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.removeRelationship(key)
+}
+
+// Len returns the number of nodes and relationships in the graph
+func (g *Graph) Len() (nodes int, relationships int) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.nodes), len(g.relationships)
+}
+
+// checkNode reports an error unless node can be stored, or applied to the stored node with the
+// same key with the named method
+func (g *Graph) checkNode(node model.GraphModel, method string) error {
+	if v := reflect.ValueOf(node); !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return fmt.Errorf("node is nil")
+	}
+	if !node.Valid() {
+		return fmt.Errorf("invalid node %q", node.GetKey())
+	}
+	stored, ok := g.nodes[node.GetKey()]
+	if !ok {
+		return nil
+	}
+	if reflect.TypeOf(stored) != reflect.TypeOf(node) || !registry.AcceptsMethod(stored, method, node) {
+		return fmt.Errorf("cannot %s %T into the stored %T %q", method, node, stored, node.GetKey())
+	}
+	return nil
+}
+
+func (g *Graph) upsertNode(node model.GraphModel, method string) (model.GraphModel, error) {
+	if err := g.checkNode(node, method); err != nil {
+		return nil, err
+	}
+
+	key := node.GetKey()
+	stored, ok := g.nodes[key]
+	if !ok {
+		g.nodes[key] = node
+		return node, nil
+	}
+	if _, err := registry.CallMethod(stored, method, node); err != nil {
+		return nil, fmt.Errorf("failed to %s %q: %w", method, key, err)
+	}
+	return stored, nil
+}
+
+func (g *Graph) relationshipKeys(key string, direction Direction) []string {
+	keys := map[string]struct{}{}
+	if direction == Outgoing || direction == Both {
+		for k := range g.outgoing[key] {
+			keys[k] = struct{}{}
+		}
+	}
+	if direction == Incoming || direction == Both {
+		for k := range g.incoming[key] {
+			keys[k] = struct{}{}
+		}
+	}
+	return sortedKeys(keys)
+}
+
+func (g *Graph) removeRelationship(key string) {
+	rel, ok := g.relationships[key]
+	if !ok {
+		return
+	}
+	source, target := rel.Nodes()
+	delete(g.outgoing[source.GetKey()], key)
+	delete(g.incoming[target.GetKey()], key)
+	delete(g.relationships, key)
+}
+
+func link(index map[string]map[string]struct{}, nodeKey, relKey string) {
+	if index[nodeKey] == nil {
+		index[nodeKey] = map[string]struct{}{}
+	}
+	index[nodeKey][relKey] = struct{}{}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
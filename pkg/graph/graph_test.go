@@ -0,0 +1,344 @@
+package graph
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// otherAsset is a graph model of another type, with the keys of an asset
+type otherAsset struct {
+	model.Asset
+}
+
+func TestGraph_AddNode(t *testing.T) {
+	t.Run("inserts new node", func(t *testing.T) {
+		g := New()
+		asset := model.NewAsset("example.com", "example.com")
+
+		stored, err := g.AddNode(&asset)
+		require.NoError(t, err)
+		assert.Same(t, &asset, stored)
+
+		got, ok := g.Node(asset.Key)
+		require.True(t, ok)
+		assert.Same(t, &asset, got)
+	})
+
+	t.Run("visits existing node", func(t *testing.T) {
+		g := New()
+		existing := model.NewAsset("example.com", "example.com")
+		existing.Visited = "2023-01-01T00:00:00Z"
+		existing.Tags.Tags = []string{"a"}
+		_, err := g.AddNode(&existing)
+		require.NoError(t, err)
+
+		update := model.NewAsset("example.com", "example.com")
+		update.Visited = "2024-01-01T00:00:00Z"
+		update.Tags.Tags = []string{"b"}
+		stored, err := g.AddNode(&update)
+		require.NoError(t, err)
+
+		assert.Same(t, &existing, stored)
+		assert.Equal(t, "2024-01-01T00:00:00Z", existing.Visited)
+		assert.Equal(t, []string{"a", "b"}, existing.Tags.Tags)
+	})
+
+	t.Run("seed source wins on visit", func(t *testing.T) {
+		g := New()
+		seed := model.NewAssetSeed("example.com")
+		_, err := g.AddNode(&seed)
+		require.NoError(t, err)
+
+		discovered := model.NewAsset("example.com", "example.com")
+		_, err = g.AddNode(&discovered)
+		require.NoError(t, err)
+
+		assert.Equal(t, model.SeedSource, seed.Source)
+		assert.Equal(t, int64(0), seed.TTL)
+	})
+
+	t.Run("value-typed Visit is dispatched", func(t *testing.T) {
+		g := New()
+		asset := model.NewAsset("example.com", "example.com")
+		existing := model.NewPort("tcp", 80, &asset)
+		_, err := g.AddNode(&existing)
+		require.NoError(t, err)
+
+		update := model.NewPort("tcp", 80, &asset)
+		update.Service = "http"
+		_, err = g.AddNode(&update)
+		require.NoError(t, err)
+
+		assert.Equal(t, "http", existing.Service)
+	})
+
+	t.Run("rejects invalid node", func(t *testing.T) {
+		g := New()
+		_, err := g.AddNode(&model.Asset{})
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects nodes of another type", func(t *testing.T) {
+		g := New()
+		asset := model.NewAsset("example.com", "example.com")
+		_, err := g.AddNode(&asset)
+		require.NoError(t, err)
+
+		other := &otherAsset{Asset: model.NewAsset("example.com", "example.com")}
+		other.Visited = "2099-01-01T00:00:00Z"
+		_, err = g.AddNode(other)
+		assert.ErrorContains(t, err, "cannot Visit")
+		_, err = g.MergeNode(other)
+		assert.ErrorContains(t, err, "cannot Merge")
+		assert.NotEqual(t, other.Visited, asset.Visited)
+	})
+
+	t.Run("rejects nil node", func(t *testing.T) {
+		g := New()
+		var asset *model.Asset
+		_, err := g.AddNode(asset)
+		assert.Error(t, err)
+	})
+}
+
+func TestGraph_MergeNode(t *testing.T) {
+	g := New()
+	asset := model.NewAsset("example.com", "example.com")
+	risk := model.NewRisk(&asset, "CVE-2023-12345", model.TriageHigh)
+	_, err := g.AddNode(&risk)
+	require.NoError(t, err)
+
+	update := model.NewRisk(&asset, "CVE-2023-12345", model.OpenHigh)
+	stored, err := g.MergeNode(&update)
+	require.NoError(t, err)
+
+	assert.Same(t, &risk, stored)
+	assert.Equal(t, model.OpenHigh, risk.Status)
+	require.Len(t, risk.History.History, 1)
+	assert.Equal(t, model.TriageHigh, risk.History.History[0].From)
+}
+
+func TestGraph_AddRelationship(t *testing.T) {
+	t.Run("adds endpoints and relationship", func(t *testing.T) {
+		g := New()
+		asset := model.NewAsset("example.com", "example.com")
+		port := model.NewPort("tcp", 443, &asset)
+
+		rel, err := g.AddRelationship(model.NewHasPort(&asset, &port))
+		require.NoError(t, err)
+
+		nodes, rels := g.Len()
+		assert.Equal(t, 2, nodes)
+		assert.Equal(t, 1, rels)
+
+		source, target := rel.Nodes()
+		assert.Same(t, &asset, source)
+		assert.Same(t, &port, target)
+	})
+
+	t.Run("re-points at stored nodes and visits", func(t *testing.T) {
+		g := New()
+		asset := model.NewAsset("example.com", "example.com")
+		port := model.NewPort("tcp", 443, &asset)
+		first := model.NewHasPort(&asset, &port)
+		first.Base().Capability = "nmap"
+		_, err := g.AddRelationship(first)
+		require.NoError(t, err)
+
+		assetCopy := model.NewAsset("example.com", "example.com")
+		portCopy := model.NewPort("tcp", 443, &assetCopy)
+		second := model.NewHasPort(&assetCopy, &portCopy)
+		second.Base().Capability = "portscan"
+		stored, err := g.AddRelationship(second)
+		require.NoError(t, err)
+
+		assert.Same(t, first, stored)
+		assert.Equal(t, "portscan", stored.Base().Capability)
+		source, target := stored.Nodes()
+		assert.Same(t, &asset, source)
+		assert.Same(t, &port, target)
+
+		_, rels := g.Len()
+		assert.Equal(t, 1, rels)
+	})
+
+	t.Run("uses type-specific visit", func(t *testing.T) {
+		g := New()
+		principal := model.NewAsset("example.com", "principal")
+		resource := model.NewAsset("example.com", "resource")
+		_, err := g.AddRelationship(model.NewIAMAWSRelationship(&principal, &resource, []string{"s3:GetObject"}))
+		require.NoError(t, err)
+		stored, err := g.AddRelationship(model.NewIAMAWSRelationship(&principal, &resource, []string{"s3:PutObject"}))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"s3:GetObject", "s3:PutObject"}, stored.(*model.IAMAWSPermission).Actions)
+	})
+
+	t.Run("rejects relationships without a key", func(t *testing.T) {
+		g := New()
+		asset := model.NewAsset("example.com", "example.com")
+		port := model.NewPort("tcp", 443, &asset)
+		rel := model.NewHasPort(&asset, &port)
+		rel.Base().Key = ""
+		_, err := g.AddRelationship(rel)
+		assert.ErrorContains(t, err, "no key")
+		nodes, _ := g.Len()
+		assert.Zero(t, nodes)
+	})
+
+	t.Run("leaves the graph unchanged when an endpoint is rejected", func(t *testing.T) {
+		g := New()
+		asset := model.NewAsset("example.com", "example.com")
+		_, err := g.AddNode(&otherAsset{Asset: model.NewAsset("example.com", "example.com")})
+		require.NoError(t, err)
+
+		source := model.NewAsset("example.com", "source.example.com")
+		_, err = g.AddRelationship(model.NewDiscovered(&source, &asset))
+		assert.ErrorContains(t, err, "failed to add target")
+		_, ok := g.Node(source.Key)
+		assert.False(t, ok, "source was added")
+		nodes, rels := g.Len()
+		assert.Equal(t, 1, nodes)
+		assert.Zero(t, rels)
+	})
+
+	t.Run("rejects missing endpoints", func(t *testing.T) {
+		g := New()
+		_, err := g.AddRelationship(&model.Discovered{BaseRelationship: &model.BaseRelationship{Key: "#k"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestGraph_Neighbors(t *testing.T) {
+	g := New()
+	asset := model.NewAsset("example.com", "example.com")
+	other := model.NewAsset("example.com", "10.0.0.1")
+	http := model.NewPort("tcp", 80, &asset)
+	https := model.NewPort("tcp", 443, &asset)
+	risk := model.NewRisk(&asset, "CVE-2023-12345", model.TriageHigh)
+
+	require.NoError(t, g.Apply(
+		model.NewHasPort(&asset, &http),
+		model.NewHasPort(&asset, &https),
+		model.NewHasVulnerability(&asset, &risk),
+		model.NewDiscovered(&other, &asset),
+	))
+
+	tests := []struct {
+		name      string
+		key       string
+		label     string
+		direction Direction
+		expected  []string
+	}{
+		{
+			name:      "outgoing by label",
+			key:       asset.Key,
+			label:     model.HasPortLabel,
+			direction: Outgoing,
+			expected:  []string{https.Key, http.Key},
+		},
+		{
+			name:      "outgoing any label",
+			key:       asset.Key,
+			direction: Outgoing,
+			expected:  []string{https.Key, http.Key, risk.Key},
+		},
+		{
+			name:      "incoming",
+			key:       asset.Key,
+			direction: Incoming,
+			expected:  []string{other.Key},
+		},
+		{
+			name:      "both",
+			key:       asset.Key,
+			label:     model.DiscoveredLabel,
+			direction: Both,
+			expected:  []string{other.Key},
+		},
+		{
+			name:      "incoming to port",
+			key:       http.Key,
+			label:     model.HasPortLabel,
+			direction: Incoming,
+			expected:  []string{asset.Key},
+		},
+		{
+			name:      "unknown node",
+			key:       "#asset#missing#missing",
+			direction: Both,
+			expected:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := []string{}
+			for _, n := range g.Neighbors(tt.key, tt.label, tt.direction) {
+				keys = append(keys, n.GetKey())
+			}
+			assert.Equal(t, tt.expected, keys)
+		})
+	}
+
+	assert.Len(t, g.Relationships(asset.Key, model.HasPortLabel, Outgoing), 2)
+	assert.Len(t, g.Nodes(model.PortLabel), 2)
+	assert.Len(t, g.Nodes(""), 5)
+}
+
+func TestGraph_Remove(t *testing.T) {
+	g := New()
+	asset := model.NewAsset("example.com", "example.com")
+	port := model.NewPort("tcp", 80, &asset)
+	rel, err := g.AddRelationship(model.NewHasPort(&asset, &port))
+	require.NoError(t, err)
+
+	g.RemoveRelationship(rel.GetKey())
+	assert.Empty(t, g.Neighbors(asset.Key, "", Both))
+
+	_, err = g.AddRelationship(model.NewHasPort(&asset, &port))
+	require.NoError(t, err)
+	g.RemoveNode(port.Key)
+
+	nodes, rels := g.Len()
+	assert.Equal(t, 1, nodes)
+	assert.Equal(t, 0, rels)
+	assert.Empty(t, g.Neighbors(asset.Key, "", Both))
+}
+
+func TestGraph_Apply_IgnoresNonGraphModels(t *testing.T) {
+	g := New()
+	asset := model.NewAsset("example.com", "example.com")
+	job := model.NewJob("portscan", &asset)
+
+	require.NoError(t, g.Apply(&job, &asset))
+
+	nodes, _ := g.Len()
+	assert.Equal(t, 1, nodes)
+}
+
+func TestGraph_Concurrent(t *testing.T) {
+	g := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			asset := model.NewAsset("example.com", "example.com")
+			port := model.NewPort("tcp", 80, &asset)
+			_, err := g.AddRelationship(model.NewHasPort(&asset, &port))
+			assert.NoError(t, err)
+			g.Neighbors(asset.Key, "", Both)
+		}()
+	}
+	wg.Wait()
+
+	nodes, rels := g.Len()
+	assert.Equal(t, 2, nodes)
+	assert.Equal(t, 1, rels)
+}
@@ -0,0 +1,485 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// ToProperties flattens node into the property map stored on its Neo4j node.
+// Only fields with a neo4j tag are stored: fields tagged "-" are skipped, and fields tagged
+// omitempty are skipped when empty. Untagged embedded structs such as BaseAsset, Metadata,
+// OriginationData and History are flattened into the node, with the shallowest field winning
+// when names collide. Nil values are left out, since Neo4j does not store nulls.
+//
+// Values are converted into types Neo4j accepts as properties: integers become int64, floats
+// become float64, and slices of primitives become typed lists. Nested structs, maps and slices of
+// anything else, such as history records or cloud resource properties, are stored as JSON strings.
+func ToProperties(node model.GraphModel) (map[string]any, error) {
+	if v := reflect.ValueOf(node); !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return nil, fmt.Errorf("node is nil")
+	}
+	return toProperties(node)
+}
+
+// ToRelationshipProperties flattens rel into the property map stored on its Neo4j relationship,
+// following the same rules as ToProperties. The source and target nodes are not included.
+func ToRelationshipProperties(rel model.GraphRelationship) (map[string]any, error) {
+	if v := reflect.ValueOf(rel); !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) || rel.Base() == nil {
+		return nil, fmt.Errorf("relationship is nil")
+	}
+	return toProperties(rel)
+}
+
+// FromNode rebuilds the model stored on a Neo4j node from its labels and properties.
+// The concrete type is the registered GraphModel, named by a label or by the prefix of the node's
+// key (#aduser#...), whose rebuilt model carries the node's labels: a CloudResource labeled Asset
+// is a CloudResource, not an Asset. When none does, the type named by the key prefix wins, falling
+// back to the first in label order. A node without labels, such as NoInput, is named by its key
+// prefix, or by the whole key when it has none.
+// Properties that do not belong to the model are ignored.
+func FromNode(labels []string, props map[string]any) (model.GraphModel, error) {
+	key, _ := props["key"].(string)
+	names := nodeTypes(labels, key)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no registered model for labels %v", labels)
+	}
+
+	var fallback model.GraphModel
+	var firstErr error
+	for _, name := range names {
+		m, _ := registry.Registry.MakeType(name)
+		if err := fromProperties(m, props); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to load %s: %w", name, err)
+			}
+			continue
+		}
+		node := m.(model.GraphModel)
+		if sameLabels(node.GetLabels(), labels) {
+			return node, nil
+		}
+		if fallback == nil {
+			fallback = node
+		}
+	}
+	if fallback == nil {
+		return nil, firstErr
+	}
+	return fallback, nil
+}
+
+// FromRelationship rebuilds the model stored on a Neo4j relationship from its label and properties,
+// connecting it to source and target. The concrete type is the registered GraphRelationship named
+// by the label, ignoring case and underscores (HAS_VULNERABILITY is hasvulnerability).
+func FromRelationship(label string, props map[string]any, source, target model.GraphModel) (model.GraphRelationship, error) {
+	name := strings.ToLower(strings.ReplaceAll(label, "_", ""))
+	typ, ok := registry.Registry.GetType(name)
+	if !ok || !typ.Implements(reflect.TypeFor[model.GraphRelationship]()) {
+		return nil, fmt.Errorf("no registered relationship for label %q", label)
+	}
+
+	m, _ := registry.Registry.MakeType(name)
+	if err := fromProperties(m, props); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", label, err)
+	}
+	rel := m.(model.GraphRelationship)
+	rel.Base().Source = source
+	rel.Base().Target = target
+	return rel, nil
+}
+
+// nodeTypes returns the registered GraphModels a node with the given labels and key may hold,
+// starting with the one named by the key prefix
+func nodeTypes(labels []string, key string) []string {
+	prefix := key
+	if rest, ok := strings.CutPrefix(key, "#"); ok {
+		prefix, _, _ = strings.Cut(rest, "#")
+	} else if len(labels) > 0 {
+		prefix = ""
+	}
+
+	var names []string
+	for _, label := range append([]string{prefix}, labels...) {
+		name := strings.ToLower(label)
+		if name == "" || slices.Contains(names, name) {
+			continue
+		}
+		if typ, ok := registry.Registry.GetType(name); ok && typ.Implements(reflect.TypeFor[model.GraphModel]()) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// sameLabels reports whether a and b hold the same labels, ignoring order and empty labels
+func sameLabels(a, b []string) bool {
+	set := func(labels []string) []string {
+		out := slices.DeleteFunc(slices.Clone(labels), func(label string) bool { return label == "" })
+		slices.Sort(out)
+		return slices.Compact(out)
+	}
+	return slices.Equal(set(a), set(b))
+}
+
+// property is a field of a model stored as a Neo4j property
+type property struct {
+	name      string
+	index     []int
+	omitempty bool
+}
+
+var propertyCache sync.Map // reflect.Type -> []property
+
+// propertiesOf returns the properties of the struct type t, in field order
+func propertiesOf(t reflect.Type) []property {
+	if cached, ok := propertyCache.Load(t); ok {
+		return cached.([]property)
+	}
+
+	var found []property
+	collectProperties(t, nil, &found, map[reflect.Type]bool{})
+
+	// the shallowest field wins, and fields at the same depth hide each other, as in Go
+	byName := map[string][]property{}
+	for _, p := range found {
+		byName[p.name] = append(byName[p.name], p)
+	}
+	out := []property{}
+	for _, p := range found {
+		if dominates(p, byName[p.name]) {
+			out = append(out, p)
+		}
+	}
+
+	cached, _ := propertyCache.LoadOrStore(t, out)
+	return cached.([]property)
+}
+
+// dominates reports whether p is the only shallowest of the properties sharing its name
+func dominates(p property, named []property) bool {
+	for _, other := range named {
+		if len(other.index) < len(p.index) || (len(other.index) == len(p.index) && !slices.Equal(other.index, p.index)) {
+			return false
+		}
+	}
+	return true
+}
+
+func collectProperties(t reflect.Type, index []int, out *[]property, visiting map[reflect.Type]bool) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, tagged := sf.Tag.Lookup("neo4j")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+
+		fieldIndex := append(append([]int{}, index...), i)
+		if sf.Anonymous && !tagged {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectProperties(ft, fieldIndex, out, visiting)
+			}
+			continue
+		}
+		if !tagged || !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		*out = append(*out, property{
+			name:      name,
+			index:     fieldIndex,
+			omitempty: strings.Contains(","+opts+",", ",omitempty,"),
+		})
+	}
+}
+
+func toProperties(m registry.Model) (map[string]any, error) {
+	v := reflect.ValueOf(m)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%T is not a struct", m)
+	}
+
+	props := map[string]any{}
+	for _, p := range propertiesOf(v.Type()) {
+		fv, err := v.FieldByIndexErr(p.index)
+		if err != nil {
+			// the field sits behind an unset embedded pointer
+			continue
+		}
+		if p.omitempty && isEmpty(fv) {
+			continue
+		}
+		value, err := toValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", p.name, err)
+		}
+		if value != nil {
+			props[p.name] = value
+		}
+	}
+	return props, nil
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// toValue converts v into a value Neo4j can store as a property. Nil values yield nil.
+func toValue(v reflect.Value) (any, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		return v.Interface(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows int64", v.Uint())
+		}
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+		if list, ok := toList(v); ok {
+			return list, nil
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return nil, fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// toList converts a slice of strings, bools, signed integers or floats into the typed list
+// Neo4j stores. Slices of anything else report ok=false.
+func toList(v reflect.Value) (any, bool) {
+	switch v.Type().Elem().Kind() {
+	case reflect.String:
+		out := make([]string, v.Len())
+		for i := range out {
+			out[i] = v.Index(i).String()
+		}
+		return out, true
+	case reflect.Bool:
+		out := make([]bool, v.Len())
+		for i := range out {
+			out[i] = v.Index(i).Bool()
+		}
+		return out, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		out := make([]int64, v.Len())
+		for i := range out {
+			out[i] = v.Index(i).Int()
+		}
+		return out, true
+	case reflect.Float32, reflect.Float64:
+		out := make([]float64, v.Len())
+		for i := range out {
+			out[i] = v.Index(i).Float()
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func fromProperties(m registry.Model, props map[string]any) error {
+	v := reflect.ValueOf(m).Elem()
+	allocateEmbedded(v)
+	for _, p := range propertiesOf(v.Type()) {
+		value, ok := props[p.name]
+		if !ok || value == nil {
+			continue
+		}
+		if err := fromValue(v.FieldByIndex(p.index), value); err != nil {
+			return fmt.Errorf("property %q: %w", p.name, err)
+		}
+	}
+	return nil
+}
+
+// allocateEmbedded sets every nil embedded struct pointer of v, such as the *BaseRelationship of a relationship
+func allocateEmbedded(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.Anonymous || !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() == reflect.Struct {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct {
+			allocateEmbedded(fv)
+		}
+	}
+}
+
+// fromValue stores a property read from Neo4j into the field v, reversing toValue
+func fromValue(v reflect.Value, value any) error {
+	if value == nil {
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+		if err := fromValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if v.Kind() == reflect.Interface || v.Type() == timeType {
+		if !rv.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("cannot store %T in %s", value, v.Type())
+		}
+		v.Set(rv)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if rv.Kind() == reflect.Bool {
+			v.SetBool(rv.Bool())
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := asInt(rv); ok && !v.OverflowInt(n) {
+			v.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, ok := asInt(rv); ok && n >= 0 && !v.OverflowUint(uint64(n)) {
+			v.SetUint(uint64(n))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, ok := asInt(rv); ok {
+			v.SetFloat(float64(n))
+			return nil
+		}
+		if rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64 {
+			v.SetFloat(rv.Float())
+			return nil
+		}
+	case reflect.String:
+		if rv.Kind() == reflect.String {
+			v.SetString(rv.String())
+			return nil
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 && rv.Type() == reflect.TypeFor[[]byte]() {
+			v.SetBytes(append([]byte{}, rv.Bytes()...))
+			return nil
+		}
+		if _, ok := toList(reflect.New(v.Type()).Elem()); ok {
+			if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+				break
+			}
+			list := setLen(v, rv.Len())
+			for i := 0; i < rv.Len(); i++ {
+				if err := fromValue(list.Index(i), rv.Index(i).Interface()); err != nil {
+					return fmt.Errorf("element %d: %w", i, err)
+				}
+			}
+			return nil
+		}
+		return fromJSON(v, value)
+	case reflect.Struct, reflect.Map:
+		return fromJSON(v, value)
+	}
+	return fmt.Errorf("cannot store %T in %s", value, v.Type())
+}
+
+// setLen sizes v to hold n elements, returning the value to fill
+func setLen(v reflect.Value, n int) reflect.Value {
+	if v.Kind() == reflect.Array {
+		if n > v.Len() {
+			n = v.Len()
+		}
+		return v.Slice(0, n)
+	}
+	v.Set(reflect.MakeSlice(v.Type(), n, n))
+	return v
+}
+
+// fromJSON decodes a property holding JSON, as written by toValue for nested values
+func fromJSON(v reflect.Value, value any) error {
+	data, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("cannot store %T in %s", value, v.Type())
+	}
+	return json.Unmarshal([]byte(data), v.Addr().Interface())
+}
+
+// asInt reads an integer of any width, as returned by the Neo4j driver (int64) or built by hand (int)
+func asInt(rv reflect.Value) (int64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(rv.Uint()), true
+	}
+	return 0, false
+}
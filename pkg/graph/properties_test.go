@@ -0,0 +1,339 @@
+package graph
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/praetorian-inc/tabularium/pkg/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToProperties(t *testing.T) {
+	t.Run("flattens embedded structs", func(t *testing.T) {
+		asset := model.NewAsset("example.com", "10.0.0.1")
+		asset.Comment = "not stored"
+		asset.Capability = []string{"portscan"}
+		asset.ASNumber = "AS15169"
+		asset.Tags.Tags = []string{"a", "b"}
+
+		props, err := ToProperties(&asset)
+		require.NoError(t, err)
+
+		assert.Equal(t, asset.Key, props["key"])
+		assert.Equal(t, "example.com", props["dns"])
+		assert.Equal(t, "10.0.0.1", props["name"])
+		assert.Equal(t, asset.TTL, props["ttl"])
+		assert.Equal(t, true, props["private"])
+		assert.Equal(t, []string{"portscan"}, props["capability"])
+		assert.Equal(t, "AS15169", props["asnumber"])
+		assert.Equal(t, []string{"a", "b"}, props["tags"])
+		assert.Equal(t, false, props["isExternal"])
+		assert.NotContains(t, props, "comment")
+	})
+
+	t.Run("skips empty omitempty fields and nil values", func(t *testing.T) {
+		asset := model.NewAsset("example.com", "example.com")
+
+		props, err := ToProperties(&asset)
+		require.NoError(t, err)
+
+		for _, name := range []string{"asname", "city", "capability", "origins", "tags", "lastScanState", "secret", "logit"} {
+			assert.NotContains(t, props, name)
+		}
+		assert.Contains(t, props, "status")
+		assert.Contains(t, props, "class")
+	})
+
+	t.Run("stores nested values as JSON", func(t *testing.T) {
+		asset := model.NewAsset("example.com", "example.com")
+		asset.History.History = []model.HistoryRecord{{From: "A", To: "F", By: "user@example.com"}}
+		asset.LastScanState = map[string]string{"nuclei": "2024-01-01"}
+
+		props, err := ToProperties(&asset)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `[{"from":"A","to":"F","by":"user@example.com"}]`, props["history"].(string))
+		assert.JSONEq(t, `{"nuclei":"2024-01-01"}`, props["lastScanState"].(string))
+	})
+
+	t.Run("stores maps of structs as JSON", func(t *testing.T) {
+		u, _ := url.Parse("https://example.com/login")
+		page := model.NewWebpage(*u, nil)
+		page.SSOIdentified = map[string]model.SSOWebpage{"okta": {}}
+
+		props, err := ToProperties(&page)
+		require.NoError(t, err)
+
+		assert.IsType(t, "", props["sso_identified"])
+		assert.Contains(t, props["sso_identified"], `"okta"`)
+		assert.Equal(t, []string{}, props["source"])
+	})
+
+	t.Run("stores cloud resource properties as JSON", func(t *testing.T) {
+		resource, err := model.NewAWSResource("arn:aws:s3:::bucket", "123456789012", model.AWSS3Bucket, map[string]any{"public": true, "size": 3})
+		require.NoError(t, err)
+
+		props, err := ToProperties(&resource)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{"public":true,"size":3}`, props["properties"].(string))
+		assert.Equal(t, "arn:aws:s3:::bucket", props["name"])
+		assert.Equal(t, "123456789012", props["accountRef"])
+	})
+
+	t.Run("dereferences pointers", func(t *testing.T) {
+		asset := model.NewAsset("example.com", "example.com")
+		secret := "#asset#amazon#0123456789012"
+		asset.Secret = &secret
+
+		props, err := ToProperties(&asset)
+		require.NoError(t, err)
+		assert.Equal(t, secret, props["secret"])
+	})
+
+	t.Run("rejects nil node", func(t *testing.T) {
+		_, err := ToProperties(nil)
+		assert.Error(t, err)
+
+		var asset *model.Asset
+		_, err = ToProperties(asset)
+		assert.Error(t, err)
+	})
+}
+
+func TestToRelationshipProperties(t *testing.T) {
+	asset := model.NewAsset("example.com", "example.com")
+	risk := model.NewRisk(&asset, "CVE-2023-12345", model.TriageHigh)
+
+	props, err := ToRelationshipProperties(model.NewHasVulnerability(&asset, &risk))
+	require.NoError(t, err)
+
+	assert.Equal(t, asset.Key+"#HAS_VULNERABILITY"+risk.Key, props["key"])
+	assert.Contains(t, props, "created")
+	assert.Contains(t, props, "visited")
+	assert.NotContains(t, props, "attachment")
+
+	_, err = ToRelationshipProperties(&model.Discovered{})
+	assert.Error(t, err)
+}
+
+func TestFromNode(t *testing.T) {
+	t.Run("round trips an asset", func(t *testing.T) {
+		asset := model.NewAsset("example.com", "10.0.0.1")
+		asset.Capability = []string{"portscan"}
+		asset.History.History = []model.HistoryRecord{{From: "A", To: "F"}}
+		asset.LastScanState = map[string]string{"nuclei": "2024-01-01"}
+
+		props, err := ToProperties(&asset)
+		require.NoError(t, err)
+
+		node, err := FromNode(asset.GetLabels(), props)
+		require.NoError(t, err)
+
+		got, ok := node.(*model.Asset)
+		require.True(t, ok, "got %T", node)
+		assert.Equal(t, asset.Key, got.Key)
+		assert.Equal(t, asset.TTL, got.TTL)
+		assert.Equal(t, asset.Capability, got.Capability)
+		assert.Equal(t, asset.History.History, got.History.History)
+		assert.Equal(t, asset.LastScanState, got.LastScanState)
+	})
+
+	t.Run("accepts driver value types", func(t *testing.T) {
+		props := map[string]any{
+			"key":        "#asset#example.com#example.com",
+			"dns":        "example.com",
+			"ttl":        int64(1706353200),
+			"capability": []any{"portscan", "whois"},
+			"private":    true,
+			"extra":      "ignored",
+		}
+
+		node, err := FromNode([]string{"Asset", "TTL"}, props)
+		require.NoError(t, err)
+
+		asset := node.(*model.Asset)
+		assert.Equal(t, int64(1706353200), asset.TTL)
+		assert.Equal(t, []string{"portscan", "whois"}, asset.Capability)
+		assert.True(t, asset.Private)
+	})
+
+	t.Run("picks the type from the key prefix", func(t *testing.T) {
+		user := model.NewADObject("example.local", "S-1-5-21-1", "CN=user,DC=example,DC=local", model.ADUserLabel)
+		props, err := ToProperties(&user)
+		require.NoError(t, err)
+
+		node, err := FromNode(user.GetLabels(), props)
+		require.NoError(t, err)
+
+		got, ok := node.(*model.ADObject)
+		require.True(t, ok, "got %T", node)
+		assert.Equal(t, user.Key, got.Key)
+		assert.Equal(t, model.ADUserLabel, got.Label)
+		assert.Equal(t, user.GetLabels(), got.GetLabels())
+	})
+
+	t.Run("picks the type carrying the labels", func(t *testing.T) {
+		resource := model.CloudResource{
+			Name:   "example.com",
+			Labels: []string{"Asset", "CloudResource", "TTL"},
+		}
+		resource.Key = "#asset#example.com#example.com"
+		props, err := ToProperties(&resource)
+		require.NoError(t, err)
+
+		node, err := FromNode(resource.GetLabels(), props)
+		require.NoError(t, err)
+		assert.IsType(t, &model.CloudResource{}, node)
+	})
+
+	t.Run("picks label-less types from the key", func(t *testing.T) {
+		node, err := FromNode(nil, map[string]any{"key": "noinput"})
+		require.NoError(t, err)
+		assert.IsType(t, &model.NoInput{}, node)
+	})
+
+	t.Run("falls back to label order", func(t *testing.T) {
+		node, err := FromNode([]string{"TTL", "Risk"}, map[string]any{"key": "#unknown#prefix"})
+		require.NoError(t, err)
+		assert.IsType(t, &model.Risk{}, node)
+	})
+
+	t.Run("rejects unknown labels", func(t *testing.T) {
+		_, err := FromNode([]string{"Unknown"}, map[string]any{})
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects mismatched values", func(t *testing.T) {
+		_, err := FromNode([]string{"Asset"}, map[string]any{"ttl": "soon"})
+		assert.ErrorContains(t, err, `"ttl"`)
+
+		_, err = FromNode([]string{"Asset"}, map[string]any{"history": "not json"})
+		assert.ErrorContains(t, err, `"history"`)
+	})
+
+	t.Run("round trips every graph model", func(t *testing.T) {
+		for _, name := range registry.GetTypes[model.GraphModel](registry.Registry) {
+			t.Run(name, func(t *testing.T) {
+				example, err := testutils.ExampleOf(name)
+				require.NoError(t, err)
+				node := example.(model.GraphModel)
+
+				props, err := ToProperties(node)
+				require.NoError(t, err)
+
+				loaded, err := FromNode(node.GetLabels(), props)
+				require.NoError(t, err)
+				require.IsType(t, node, loaded)
+
+				reloaded, err := ToProperties(loaded)
+				require.NoError(t, err)
+				assert.Equal(t, props, reloaded)
+			})
+		}
+	})
+}
+
+func TestFromRelationship(t *testing.T) {
+	asset := model.NewAsset("example.com", "example.com")
+	risk := model.NewRisk(&asset, "CVE-2023-12345", model.TriageHigh)
+
+	t.Run("round trips a relationship", func(t *testing.T) {
+		rel := model.NewHasVulnerability(&asset, &risk)
+		props, err := ToRelationshipProperties(rel)
+		require.NoError(t, err)
+
+		loaded, err := FromRelationship(rel.Label(), props, &asset, &risk)
+		require.NoError(t, err)
+
+		assert.IsType(t, &model.HasVulnerability{}, loaded)
+		assert.Equal(t, rel.GetKey(), loaded.GetKey())
+		assert.Equal(t, rel.Base().Created, loaded.Base().Created)
+		source, target := loaded.Nodes()
+		assert.Same(t, &asset, source)
+		assert.Same(t, &risk, target)
+	})
+
+	t.Run("resolves aliased relationships", func(t *testing.T) {
+		user := model.NewADObject("example.local", "S-1-5-21-1", "CN=user,DC=example,DC=local", model.ADUserLabel)
+		group := model.NewADObject("example.local", "S-1-5-21-2", "CN=group,DC=example,DC=local", model.ADGroupLabel)
+		enforced := model.GobSafeBool(true)
+		rel := model.NewADRelationship(&user, &group, model.ADMemberOfLabel).(*model.ADRelationship)
+		rel.Enforced = &enforced
+
+		props, err := ToRelationshipProperties(rel)
+		require.NoError(t, err)
+		assert.Equal(t, true, props["enforced"])
+
+		loaded, err := FromRelationship(model.ADMemberOfLabel, props, &user, &group)
+		require.NoError(t, err)
+
+		got, ok := loaded.(*model.ADRelationship)
+		require.True(t, ok, "got %T", loaded)
+		assert.Equal(t, model.ADMemberOfLabel, got.Label())
+		assert.Equal(t, rel.GetKey(), got.GetKey())
+		require.NotNil(t, got.Enforced)
+		assert.True(t, bool(*got.Enforced))
+	})
+
+	t.Run("allocates the base of an empty relationship", func(t *testing.T) {
+		loaded, err := FromRelationship("DISCOVERED", map[string]any{}, &asset, &risk)
+		require.NoError(t, err)
+		require.NotNil(t, loaded.Base())
+		assert.Same(t, &asset, loaded.Base().Source)
+	})
+
+	t.Run("round trips every relationship", func(t *testing.T) {
+		for _, name := range registry.GetTypes[model.GraphRelationship](registry.Registry) {
+			t.Run(name, func(t *testing.T) {
+				example, err := testutils.ExampleOf(name)
+				require.NoError(t, err)
+				rel := example.(model.GraphRelationship)
+				label := rel.Label()
+				if label == "" {
+					// examples of aliased relationships carry no label of their own
+					label = name
+				}
+
+				props, err := ToRelationshipProperties(rel)
+				require.NoError(t, err)
+
+				loaded, err := FromRelationship(label, props, &asset, &risk)
+				require.NoError(t, err)
+				assert.IsType(t, rel, loaded)
+
+				reloaded, err := ToRelationshipProperties(loaded)
+				require.NoError(t, err)
+				assert.Equal(t, props, reloaded)
+			})
+		}
+	})
+
+	t.Run("rejects unknown labels", func(t *testing.T) {
+		_, err := FromRelationship("UNKNOWN", map[string]any{}, &asset, &risk)
+		assert.Error(t, err)
+
+		_, err = FromRelationship("Asset", map[string]any{}, &asset, &risk)
+		assert.Error(t, err)
+	})
+}
+
+func TestToValue_Time(t *testing.T) {
+	type timed struct {
+		At time.Time `neo4j:"at"`
+	}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	props := map[string]any{}
+	for _, p := range propertiesOf(reflect.TypeFor[timed]()) {
+		value, err := toValue(reflect.ValueOf(timed{At: at}).FieldByIndex(p.index))
+		require.NoError(t, err)
+		props[p.name] = value
+	}
+	assert.Equal(t, map[string]any{"at": at}, props)
+}
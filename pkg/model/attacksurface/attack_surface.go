@@ -1,5 +1,11 @@
 package attacksurface
 
+import "github.com/praetorian-inc/tabularium/pkg/registry"
+
+func init() {
+	registry.RegisterEnum(registry.Registry, Internal, External, Cloud, SCM, Application)
+}
+
 type Surface string
 
 const (
@@ -0,0 +1,188 @@
+package filters
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ToCypher compiles f into a parameterized Cypher predicate over the node bound to alias.
+// Values are never interpolated; each is bound to a parameter ($p0, $p1, ...) returned in params.
+// A filter's own Alias, when set, takes precedence over alias.
+//
+// The generated predicate has the same semantics as Match. Relationship filters compile to an
+// EXISTS subquery, where a relationshipDirection of "target" means the related node is the target
+// of the relationship, i.e. (alias)-[:LABEL]->(:NodeLabel), and "source" means it is the source.
+func ToCypher(alias string, f Filter) (clause string, params map[string]any, err error) {
+	c := &cypherCompiler{params: map[string]any{}}
+	clause, err = c.compile(alias, f)
+	if err != nil {
+		return "", nil, err
+	}
+	return clause, c.params, nil
+}
+
+type cypherCompiler struct {
+	params        map[string]any
+	relationships int
+}
+
+func (c *cypherCompiler) param(value any) string {
+	name := fmt.Sprintf("p%d", len(c.params))
+	c.params[name] = value
+	return "$" + name
+}
+
+func (c *cypherCompiler) compile(alias string, f Filter) (string, error) {
+	if f.Alias != "" {
+		alias = f.Alias
+	}
+	if !identifier.MatchString(alias) {
+		return "", fmt.Errorf("invalid alias %q", alias)
+	}
+	if err := f.Validate(); err != nil {
+		return "", err
+	}
+
+	var clause string
+	var err error
+	switch {
+	case f.Operator == OperatorAnd || f.Operator == OperatorOr:
+		clause, err = c.logical(alias, f)
+	case f.IsRelationshipFilter():
+		clause, err = c.relationship(alias, f)
+	default:
+		clause, err = c.predicate(alias, f)
+	}
+	if err != nil {
+		return "", err
+	}
+	if f.Not {
+		if !strings.HasPrefix(clause, "(") {
+			clause = "(" + clause + ")"
+		}
+		clause = "NOT " + clause
+	}
+	return clause, nil
+}
+
+func (c *cypherCompiler) logical(alias string, f Filter) (string, error) {
+	if len(f.Value) == 0 {
+		if f.Operator == OperatorAnd {
+			return "true", nil
+		}
+		return "false", nil
+	}
+
+	clauses := make([]string, 0, len(f.Value))
+	for _, value := range f.Value {
+		nested, ok := value.(Filter)
+		if !ok {
+			return "", fmt.Errorf("%s filter contains non-filter value %T", f.Operator, value)
+		}
+		clause, err := c.compile(alias, nested)
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, clause)
+	}
+	return "(" + strings.Join(clauses, " "+f.Operator+" ") + ")", nil
+}
+
+func (c *cypherCompiler) relationship(alias string, f Filter) (string, error) {
+	related := fmt.Sprintf("%s_rel%d", alias, c.relationships)
+	c.relationships++
+
+	label := escapeIdentifier(f.RelationshipLabel)
+	node := escapeIdentifier(f.RelationshipNodeLabel)
+	var pattern string
+	if f.RelationshipDirection == "target" {
+		pattern = fmt.Sprintf("(%s)-[:%s]->(%s:%s)", alias, label, related, node)
+	} else {
+		pattern = fmt.Sprintf("(%s)<-[:%s]-(%s:%s)", alias, label, related, node)
+	}
+
+	if f.Field == "" && f.Operator == "" {
+		return fmt.Sprintf("EXISTS { MATCH %s }", pattern), nil
+	}
+	predicate, err := c.predicate(related, f)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("EXISTS { MATCH %s WHERE %s }", pattern, predicate), nil
+}
+
+func (c *cypherCompiler) predicate(alias string, f Filter) (string, error) {
+	if f.Field == "" {
+		return "", fmt.Errorf("operator %q requires a field", f.Operator)
+	}
+	property := alias + "." + escapeIdentifier(f.Field)
+	values := operands(f.Value)
+	// list comprehension variables are derived from the alias so they cannot shadow it
+	x, v := alias+"_x", alias+"_v"
+
+	switch f.Operator {
+	case OperatorIsNull, OperatorIsNotNull:
+		return fmt.Sprintf("%s %s", property, f.Operator), nil
+	}
+
+	if len(values) == 0 {
+		return "", fmt.Errorf("operator %q on %q requires a value", f.Operator, f.Field)
+	}
+	single := len(values) == 1
+
+	switch f.Operator {
+	case OperatorEqual:
+		if single {
+			return binary(property, "=", c.param(values[0]), f.ReverseOperands), nil
+		}
+		return binary(property, "=", c.param(values), f.ReverseOperands), nil
+	case OperatorContains, OperatorStartsWith, OperatorEndsWith, OperatorLike:
+		operator := f.Operator
+		if operator == OperatorLike {
+			operator = "=~"
+		}
+		if single {
+			return binary(property, operator, c.param(values[0]), f.ReverseOperands), nil
+		}
+		return fmt.Sprintf("ANY(%s IN %s WHERE %s)", v, c.param(values), binary(property, operator, v, f.ReverseOperands)), nil
+	case OperatorLessThan, OperatorLessThanEqualTo, OperatorGreaterThan, OperatorGreaterThanEqualTo:
+		if !single {
+			return "", fmt.Errorf("operator %q on %q requires a single value", f.Operator, f.Field)
+		}
+		return binary(property, f.Operator, c.param(values[0]), f.ReverseOperands), nil
+	case OperatorIn:
+		if !f.ReverseOperands {
+			return fmt.Sprintf("%s IN %s", property, c.param(values)), nil
+		}
+		if single {
+			return fmt.Sprintf("%s IN %s", c.param(values[0]), property), nil
+		}
+		return fmt.Sprintf("ANY(%s IN %s WHERE %s IN %s)", v, c.param(values), v, property), nil
+	case OperatorAnyIn:
+		return fmt.Sprintf("ANY(%s IN %s WHERE %s IN %s)", x, property, x, c.param(values)), nil
+	case OperatorAnyStartsWith:
+		if single {
+			return fmt.Sprintf("ANY(%s IN %s WHERE %s STARTS WITH %s)", x, property, x, c.param(values[0])), nil
+		}
+		return fmt.Sprintf("ANY(%s IN %s WHERE ANY(%s IN %s WHERE %s STARTS WITH %s))", x, property, v, c.param(values), x, v), nil
+	}
+	return "", fmt.Errorf("unsupported operator %q", f.Operator)
+}
+
+func binary(property, operator, value string, reverse bool) string {
+	if reverse {
+		return fmt.Sprintf("%s %s %s", value, operator, property)
+	}
+	return fmt.Sprintf("%s %s %s", property, operator, value)
+}
+
+// escapeIdentifier quotes names that are not plain Cypher identifiers
+func escapeIdentifier(name string) string {
+	if identifier.MatchString(name) {
+		return name
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,297 @@
+package filters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToCypher(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		clause string
+		params map[string]any
+	}{
+		{
+			name:   "equal",
+			filter: NewFilter("status", OperatorEqual, "A"),
+			clause: "n.status = $p0",
+			params: map[string]any{"p0": "A"},
+		},
+		{
+			name:   "equal list",
+			filter: NewFilter("tags", OperatorEqual, []string{"a", "b"}),
+			clause: "n.tags = $p0",
+			params: map[string]any{"p0": []any{"a", "b"}},
+		},
+		{
+			name:   "equal reversed",
+			filter: NewFilter("ttl", OperatorEqual, 0, WithReverseOperands()),
+			clause: "$p0 = n.ttl",
+			params: map[string]any{"p0": int64(0)},
+		},
+		{
+			name:   "contains",
+			filter: NewFilter("dns", OperatorContains, "example"),
+			clause: "n.dns CONTAINS $p0",
+			params: map[string]any{"p0": "example"},
+		},
+		{
+			name:   "contains any",
+			filter: NewFilter("dns", OperatorContains, []string{"a", "b"}),
+			clause: "ANY(n_v IN $p0 WHERE n.dns CONTAINS n_v)",
+			params: map[string]any{"p0": []any{"a", "b"}},
+		},
+		{
+			name:   "contains reversed",
+			filter: NewFilter("dns", OperatorContains, "www.example.com", WithReverseOperands()),
+			clause: "$p0 CONTAINS n.dns",
+			params: map[string]any{"p0": "www.example.com"},
+		},
+		{
+			name:   "like",
+			filter: NewFilter("name", OperatorLike, `.*\.com`),
+			clause: "n.name =~ $p0",
+			params: map[string]any{"p0": `.*\.com`},
+		},
+		{
+			name:   "starts with",
+			filter: NewFilter("status", OperatorStartsWith, "A"),
+			clause: "n.status STARTS WITH $p0",
+			params: map[string]any{"p0": "A"},
+		},
+		{
+			name:   "ends with any reversed",
+			filter: NewFilter("dns", OperatorEndsWith, []string{"a.com", "b.com"}, WithReverseOperands()),
+			clause: "ANY(n_v IN $p0 WHERE n_v ENDS WITH n.dns)",
+			params: map[string]any{"p0": []any{"a.com", "b.com"}},
+		},
+		{
+			name:   "less than",
+			filter: NewFilter("priority", OperatorLessThan, 10),
+			clause: "n.priority < $p0",
+			params: map[string]any{"p0": int64(10)},
+		},
+		{
+			name:   "less than or equal",
+			filter: NewFilter("priority", OperatorLessThanEqualTo, 10),
+			clause: "n.priority <= $p0",
+			params: map[string]any{"p0": int64(10)},
+		},
+		{
+			name:   "greater than",
+			filter: NewFilter("score", OperatorGreaterThan, 7.5),
+			clause: "n.score > $p0",
+			params: map[string]any{"p0": 7.5},
+		},
+		{
+			name:   "greater than or equal reversed",
+			filter: NewFilter("created", OperatorGreaterThanEqualTo, "2024-01-01", WithReverseOperands()),
+			clause: "$p0 >= n.created",
+			params: map[string]any{"p0": "2024-01-01"},
+		},
+		{
+			name:   "in",
+			filter: NewFilter("class", OperatorIn, []string{"ipv4", "ipv6"}),
+			clause: "n.class IN $p0",
+			params: map[string]any{"p0": []any{"ipv4", "ipv6"}},
+		},
+		{
+			name:   "in single value",
+			filter: NewFilter("class", OperatorIn, "ipv4"),
+			clause: "n.class IN $p0",
+			params: map[string]any{"p0": []any{"ipv4"}},
+		},
+		{
+			name:   "in reversed",
+			filter: NewFilter("tags", OperatorIn, "prod", WithReverseOperands()),
+			clause: "$p0 IN n.tags",
+			params: map[string]any{"p0": "prod"},
+		},
+		{
+			name:   "in reversed any",
+			filter: NewFilter("tags", OperatorIn, []string{"prod", "dev"}, WithReverseOperands()),
+			clause: "ANY(n_v IN $p0 WHERE n_v IN n.tags)",
+			params: map[string]any{"p0": []any{"prod", "dev"}},
+		},
+		{
+			name:   "is null",
+			filter: NewFilter("secret", OperatorIsNull, nil),
+			clause: "n.secret IS NULL",
+			params: map[string]any{},
+		},
+		{
+			name:   "is not null",
+			filter: NewFilter("secret", OperatorIsNotNull, nil),
+			clause: "n.secret IS NOT NULL",
+			params: map[string]any{},
+		},
+		{
+			name:   "any starts with",
+			filter: NewFilter("capability", OperatorAnyStartsWith, "port"),
+			clause: "ANY(n_x IN n.capability WHERE n_x STARTS WITH $p0)",
+			params: map[string]any{"p0": "port"},
+		},
+		{
+			name:   "any starts with several",
+			filter: NewFilter("capability", OperatorAnyStartsWith, []string{"port", "nuc"}),
+			clause: "ANY(n_x IN n.capability WHERE ANY(n_v IN $p0 WHERE n_x STARTS WITH n_v))",
+			params: map[string]any{"p0": []any{"port", "nuc"}},
+		},
+		{
+			name:   "any in",
+			filter: NewFilter("attackSurface", OperatorAnyIn, []string{"external", "cloud"}),
+			clause: "ANY(n_x IN n.attackSurface WHERE n_x IN $p0)",
+			params: map[string]any{"p0": []any{"external", "cloud"}},
+		},
+		{
+			name:   "not",
+			filter: NewFilter("dns", OperatorEndsWith, ".local", WithNot()),
+			clause: "NOT (n.dns ENDS WITH $p0)",
+			params: map[string]any{"p0": ".local"},
+		},
+		{
+			name:   "escaped field",
+			filter: NewFilter("odd field`", OperatorEqual, "x"),
+			clause: "n.`odd field``` = $p0",
+			params: map[string]any{"p0": "x"},
+		},
+		{
+			name: "filter alias",
+			filter: Filter{
+				Field:    "name",
+				Operator: OperatorEqual,
+				Value:    SliceOrValue[any]{"x"},
+				Alias:    "m",
+			},
+			clause: "m.name = $p0",
+			params: map[string]any{"p0": "x"},
+		},
+		{
+			name: "nested logical",
+			filter: NewFilter("", OperatorAnd, []Filter{
+				NewFilter("status", OperatorStartsWith, "A"),
+				NewFilter("", OperatorOr, []Filter{
+					NewFilter("class", OperatorIn, []string{"ipv4", "ipv6"}),
+					NewFilter("dns", OperatorEndsWith, ".local", WithNot()),
+				}),
+			}),
+			clause: "(n.status STARTS WITH $p0 AND (n.class IN $p1 OR NOT (n.dns ENDS WITH $p2)))",
+			params: map[string]any{"p0": "A", "p1": []any{"ipv4", "ipv6"}, "p2": ".local"},
+		},
+		{
+			name: "negated logical",
+			filter: NewFilter("", OperatorOr, []Filter{
+				NewFilter("status", OperatorEqual, "D"),
+				NewFilter("status", OperatorEqual, "F"),
+			}, WithNot()),
+			clause: "NOT (n.status = $p0 OR n.status = $p1)",
+			params: map[string]any{"p0": "D", "p1": "F"},
+		},
+		{
+			name:   "empty and",
+			filter: Filter{Operator: OperatorAnd},
+			clause: "true",
+			params: map[string]any{},
+		},
+		{
+			name:   "empty or",
+			filter: Filter{Operator: OperatorOr},
+			clause: "false",
+			params: map[string]any{},
+		},
+		{
+			name:   "relationship to target",
+			filter: relationship("port", OperatorEqual, 443, "Port", "target", "HAS_PORT"),
+			clause: "EXISTS { MATCH (n)-[:HAS_PORT]->(n_rel0:Port) WHERE n_rel0.port = $p0 }",
+			params: map[string]any{"p0": int64(443)},
+		},
+		{
+			name:   "relationship from source",
+			filter: relationship("class", OperatorEqual, "ipv4", "Asset", "source", "DISCOVERED"),
+			clause: "EXISTS { MATCH (n)<-[:DISCOVERED]-(n_rel0:Asset) WHERE n_rel0.class = $p0 }",
+			params: map[string]any{"p0": "ipv4"},
+		},
+		{
+			name: "relationship existence",
+			filter: Filter{RelationshipFilter: RelationshipFilter{
+				RelationshipNodeLabel: "Risk",
+				RelationshipDirection: "target",
+				RelationshipLabel:     "HAS_VULNERABILITY",
+			}},
+			clause: "EXISTS { MATCH (n)-[:HAS_VULNERABILITY]->(n_rel0:Risk) }",
+			params: map[string]any{},
+		},
+		{
+			name: "negated relationships in logical filter",
+			filter: NewFilter("", OperatorAnd, []Filter{
+				relationship("port", OperatorEqual, 80, "Port", "target", "HAS_PORT"),
+				func() Filter {
+					f := relationship("status", OperatorStartsWith, "O", "Risk", "target", "HAS_VULNERABILITY")
+					f.Not = true
+					return f
+				}(),
+			}),
+			clause: "(EXISTS { MATCH (n)-[:HAS_PORT]->(n_rel0:Port) WHERE n_rel0.port = $p0 } AND NOT (EXISTS { MATCH (n)-[:HAS_VULNERABILITY]->(n_rel1:Risk) WHERE n_rel1.status STARTS WITH $p1 }))",
+			params: map[string]any{"p0": int64(80), "p1": "O"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, params, err := ToCypher("n", tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.clause, clause)
+			assert.Equal(t, tt.params, params)
+		})
+	}
+}
+
+func TestToCypher_FromJSON(t *testing.T) {
+	input := `{
+		"operator": "OR",
+		"value": [
+			{"field": "name", "operator": "=", "value": "example.com"},
+			{"field": "ttl", "operator": ">", "value": 1.5}
+		]
+	}`
+	var f Filter
+	require.NoError(t, json.Unmarshal([]byte(input), &f))
+
+	clause, params, err := ToCypher("a", f)
+	require.NoError(t, err)
+	assert.Equal(t, "(a.name = $p0 OR a.ttl > $p1)", clause)
+	assert.Equal(t, map[string]any{"p0": "example.com", "p1": 1.5}, params)
+}
+
+func TestToCypher_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		alias  string
+		filter Filter
+	}{
+		{"invalid alias", "n) DETACH DELETE (n", NewFilter("name", OperatorEqual, "x")},
+		{"missing field", "n", NewFilter("", OperatorEqual, "x")},
+		{"missing value", "n", Filter{Field: "name", Operator: OperatorEqual}},
+		{"unsupported operator", "n", NewFilter("name", "~", "x")},
+		{"comparison with several values", "n", NewFilter("ttl", OperatorGreaterThan, []int{1, 2})},
+		{"non-filter in logical filter", "n", NewFilter("", OperatorOr, "x")},
+		{"incomplete relationship filter", "n", Filter{
+			Field:              "name",
+			Operator:           OperatorEqual,
+			Value:              SliceOrValue[any]{"x"},
+			RelationshipFilter: RelationshipFilter{RelationshipLabel: "HAS_PORT"},
+		}},
+		{"invalid relationship direction", "n", relationship("port", OperatorEqual, 80, "Port", "out", "HAS_PORT")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ToCypher(tt.alias, tt.filter)
+			assert.Error(t, err)
+		})
+	}
+}
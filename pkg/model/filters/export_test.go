@@ -0,0 +1,15 @@
+package filters
+
+// Relationship exposes the relationship fixture to the external tests of the package
+var Relationship = relationship
+
+// relationship builds a filter on the field of nodes related to the filtered one
+func relationship(field, operator string, value any, nodeLabel, direction, label string, opts ...Option) Filter {
+	f := NewFilter(field, operator, value, opts...)
+	f.RelationshipFilter = RelationshipFilter{
+		RelationshipNodeLabel: nodeLabel,
+		RelationshipDirection: direction,
+		RelationshipLabel:     label,
+	}
+	return f
+}
@@ -0,0 +1,153 @@
+package filters
+
+import (
+	"reflect"
+	"strings"
+)
+
+// field describes a model property addressable by a filter
+type field struct {
+	reflect.StructField
+	name      string
+	omitempty bool
+}
+
+// resolveField finds the property of t named by a filter's Field. Names are matched against
+// neo4j tags first, then json tags (or the Go field name when no json tag is present).
+// Fields promoted from embedded structs such as BaseAsset, Metadata and OriginationData
+// are resolved as if they were declared on t; the shallowest match wins.
+func resolveField(t reflect.Type, name string) (field, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || name == "" {
+		return field{}, false
+	}
+
+	candidates := reflect.VisibleFields(t)
+	for _, tag := range []string{"neo4j", "json"} {
+		var best *field
+		for _, sf := range candidates {
+			if !sf.IsExported() || sf.Anonymous {
+				continue
+			}
+			tagName, omitempty, ok := tagInfo(sf, tag)
+			if !ok || tagName != name {
+				continue
+			}
+			if best == nil || len(sf.Index) < len(best.Index) {
+				best = &field{StructField: sf, name: tagName, omitempty: omitempty}
+			}
+		}
+		if best != nil {
+			return *best, true
+		}
+	}
+	return field{}, false
+}
+
+// tagInfo returns the property name and omitempty option of sf for the given struct tag.
+// Fields excluded with "-" report ok=false. A json field without a tag uses its Go name,
+// matching encoding/json.
+func tagInfo(sf reflect.StructField, tag string) (name string, omitempty bool, ok bool) {
+	value, present := sf.Tag.Lookup(tag)
+	if !present {
+		if tag == "json" {
+			return sf.Name, false, true
+		}
+		return "", false, false
+	}
+	parts := strings.Split(value, ",")
+	if parts[0] == "-" {
+		return "", false, false
+	}
+	name = parts[0]
+	if name == "" {
+		name = sf.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, true
+}
+
+// fieldValue reads f from v. Unset embedded pointers yield an invalid value.
+func fieldValue(v reflect.Value, f field) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	fv, err := v.FieldByIndexErr(f.Index)
+	if err != nil {
+		return reflect.Value{}
+	}
+	return fv
+}
+
+// normalize converts a Go value into the loosely typed form used for comparisons:
+// nil, string, int64, float64, bool, []any, or the original value for anything else.
+// Nil pointers, slices and maps become nil, as do zero values when omitempty is set,
+// since such properties are not stored in the graph.
+func normalize(v reflect.Value, omitempty bool) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if omitempty && v.IsZero() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return normalize(v.Elem(), false)
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if omitempty && v.Len() == 0 {
+			return nil
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = normalize(v.Index(i), false)
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() || (omitempty && v.Len() == 0) {
+			return nil
+		}
+	}
+	return v.Interface()
+}
+
+// operands flattens a filter's values into a list of normalized operands.
+// Slice values, as produced by NewFilter("f", op, []string{...}), are expanded.
+func operands(values SliceOrValue[any]) []any {
+	out := []any{}
+	for _, value := range values {
+		v := reflect.ValueOf(value)
+		if v.IsValid() && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) {
+			for i := 0; i < v.Len(); i++ {
+				out = append(out, normalize(v.Index(i), false))
+			}
+			continue
+		}
+		out = append(out, normalize(v, false))
+	}
+	return out
+}
@@ -0,0 +1,306 @@
+package filters
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// Match reports whether m satisfies f, evaluating the filter in memory with the same semantics
+// the filter has when compiled to Cypher:
+//   - Field is resolved against the model's neo4j and json tag names, including fields of embedded structs
+//   - missing properties, nil values and empty omitempty values are null
+//   - comparisons against null are null, and null is treated as a non-match, also under Not
+//   - a filter with several values matches when any value matches; = compares against the whole list
+//   - LIKE is a full regular expression match, as Cypher's =~
+//   - ReverseOperands swaps the property and value, so "IN" with reversed operands tests list membership
+//
+// Relationship filters cannot be evaluated against a single model and return an error.
+func Match(f Filter, m registry.Model) (bool, error) {
+	if m == nil {
+		return false, fmt.Errorf("cannot match filter against nil model")
+	}
+	result, err := evaluate(f, reflect.ValueOf(m))
+	if err != nil {
+		return false, err
+	}
+	return result == ternaryTrue, nil
+}
+
+// ternary is a value of Cypher's three-valued logic
+type ternary int
+
+const (
+	ternaryNull ternary = iota
+	ternaryFalse
+	ternaryTrue
+)
+
+func ternaryOf(b bool) ternary {
+	if b {
+		return ternaryTrue
+	}
+	return ternaryFalse
+}
+
+func (t ternary) not() ternary {
+	switch t {
+	case ternaryTrue:
+		return ternaryFalse
+	case ternaryFalse:
+		return ternaryTrue
+	}
+	return ternaryNull
+}
+
+func evaluate(f Filter, v reflect.Value) (ternary, error) {
+	if f.IsRelationshipFilter() {
+		return ternaryNull, fmt.Errorf("relationship filter on %q cannot be evaluated against a model", f.Field)
+	}
+
+	var result ternary
+	var err error
+	switch f.Operator {
+	case OperatorAnd, OperatorOr:
+		result, err = evaluateLogical(f, v)
+	default:
+		fld, ok := resolveField(v.Type(), f.Field)
+		var property any
+		if ok {
+			property = normalize(fieldValue(v, fld), fld.omitempty)
+		}
+		result, err = evaluateOperator(f, property)
+	}
+	if err != nil {
+		return ternaryNull, err
+	}
+	if f.Not {
+		result = result.not()
+	}
+	return result, nil
+}
+
+func evaluateLogical(f Filter, v reflect.Value) (ternary, error) {
+	and := f.Operator == OperatorAnd
+	result := ternaryOf(and)
+	for _, value := range f.Value {
+		nested, ok := value.(Filter)
+		if !ok {
+			return ternaryNull, fmt.Errorf("%s filter contains non-filter value %T", f.Operator, value)
+		}
+		r, err := evaluate(nested, v)
+		if err != nil {
+			return ternaryNull, err
+		}
+		switch {
+		case and && r == ternaryFalse, !and && r == ternaryTrue:
+			return r, nil
+		case r == ternaryNull:
+			result = ternaryNull
+		}
+	}
+	return result, nil
+}
+
+func evaluateOperator(f Filter, property any) (ternary, error) {
+	values := operands(f.Value)
+
+	switch f.Operator {
+	case OperatorIsNull:
+		return ternaryOf(property == nil), nil
+	case OperatorIsNotNull:
+		return ternaryOf(property != nil), nil
+	}
+
+	if len(values) == 0 {
+		return ternaryNull, fmt.Errorf("operator %q on %q requires a value", f.Operator, f.Field)
+	}
+
+	switch f.Operator {
+	case OperatorEqual:
+		if len(values) == 1 {
+			return equal(property, values[0]), nil
+		}
+		return equal(property, values), nil
+	case OperatorContains, OperatorStartsWith, OperatorEndsWith, OperatorLike:
+		return anyOf(values, func(value any) (ternary, error) {
+			left, right := property, value
+			if f.ReverseOperands {
+				left, right = right, left
+			}
+			return stringOperator(f.Operator, left, right)
+		})
+	case OperatorLessThan, OperatorLessThanEqualTo, OperatorGreaterThan, OperatorGreaterThanEqualTo:
+		if len(values) != 1 {
+			return ternaryNull, fmt.Errorf("operator %q on %q requires a single value", f.Operator, f.Field)
+		}
+		left, right := property, values[0]
+		if f.ReverseOperands {
+			left, right = right, left
+		}
+		return comparison(f.Operator, left, right), nil
+	case OperatorIn:
+		if f.ReverseOperands {
+			return anyOf(values, func(value any) (ternary, error) {
+				return in(value, property), nil
+			})
+		}
+		return in(property, values), nil
+	case OperatorAnyIn:
+		return anyElement(property, func(element any) (ternary, error) {
+			return in(element, values), nil
+		})
+	case OperatorAnyStartsWith:
+		return anyElement(property, func(element any) (ternary, error) {
+			return anyOf(values, func(value any) (ternary, error) {
+				return stringOperator(OperatorStartsWith, element, value)
+			})
+		})
+	}
+	return ternaryNull, fmt.Errorf("unsupported operator %q", f.Operator)
+}
+
+// anyOf mirrors Cypher's ANY(x IN list WHERE predicate)
+func anyOf(values []any, predicate func(any) (ternary, error)) (ternary, error) {
+	result := ternaryFalse
+	for _, value := range values {
+		r, err := predicate(value)
+		if err != nil {
+			return ternaryNull, err
+		}
+		if r == ternaryTrue {
+			return ternaryTrue, nil
+		}
+		if r == ternaryNull {
+			result = ternaryNull
+		}
+	}
+	return result, nil
+}
+
+// anyElement applies predicate to each element of a list property. Non-list properties are null.
+func anyElement(property any, predicate func(any) (ternary, error)) (ternary, error) {
+	list, ok := property.([]any)
+	if !ok {
+		return ternaryNull, nil
+	}
+	return anyOf(list, predicate)
+}
+
+func in(value any, list any) ternary {
+	elements, ok := list.([]any)
+	if !ok {
+		return ternaryNull
+	}
+	if value == nil {
+		return ternaryNull
+	}
+	result, _ := anyOf(elements, func(element any) (ternary, error) {
+		return equal(value, element), nil
+	})
+	return result
+}
+
+func equal(a, b any) ternary {
+	if a == nil || b == nil {
+		return ternaryNull
+	}
+	if x, y, ok := numbers(a, b); ok {
+		return ternaryOf(x == y)
+	}
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return ternaryOf(ok && x == y)
+	case bool:
+		y, ok := b.(bool)
+		return ternaryOf(ok && x == y)
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return ternaryFalse
+		}
+		result := ternaryTrue
+		for i := range x {
+			switch equal(x[i], y[i]) {
+			case ternaryFalse:
+				return ternaryFalse
+			case ternaryNull:
+				result = ternaryNull
+			}
+		}
+		return result
+	}
+	return ternaryOf(reflect.DeepEqual(a, b))
+}
+
+func comparison(operator string, a, b any) ternary {
+	var cmp int
+	if x, y, ok := numbers(a, b); ok {
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		}
+	} else {
+		x, xok := a.(string)
+		y, yok := b.(string)
+		if !xok || !yok {
+			return ternaryNull
+		}
+		cmp = strings.Compare(x, y)
+	}
+
+	switch operator {
+	case OperatorLessThan:
+		return ternaryOf(cmp < 0)
+	case OperatorLessThanEqualTo:
+		return ternaryOf(cmp <= 0)
+	case OperatorGreaterThan:
+		return ternaryOf(cmp > 0)
+	default:
+		return ternaryOf(cmp >= 0)
+	}
+}
+
+func stringOperator(operator string, a, b any) (ternary, error) {
+	x, xok := a.(string)
+	y, yok := b.(string)
+	if !xok || !yok {
+		return ternaryNull, nil
+	}
+	switch operator {
+	case OperatorContains:
+		return ternaryOf(strings.Contains(x, y)), nil
+	case OperatorStartsWith:
+		return ternaryOf(strings.HasPrefix(x, y)), nil
+	case OperatorEndsWith:
+		return ternaryOf(strings.HasSuffix(x, y)), nil
+	default:
+		re, err := regexp.Compile("^(?:" + y + ")$")
+		if err != nil {
+			return ternaryNull, fmt.Errorf("invalid LIKE pattern %q: %w", y, err)
+		}
+		return ternaryOf(re.MatchString(x)), nil
+	}
+}
+
+func numbers(a, b any) (float64, float64, bool) {
+	x, xok := number(a)
+	y, yok := number(b)
+	return x, y, xok && yok
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,42 @@
+package filters_test
+
+import (
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/model/filters"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch_RegisteredModels(t *testing.T) {
+	asset := model.NewAsset("example.com", "10.0.0.1")
+	asset.Country = "US"
+	asset.AttackSurface = []string{"external"}
+	model.DeriveAttackSurfaceFlags(&asset.OriginationData)
+	risk := model.NewRisk(&asset, "CVE-2023-12345", model.TriageHigh)
+
+	tests := []struct {
+		name     string
+		filter   filters.Filter
+		model    registry.Model
+		expected bool
+	}{
+		{"base asset field", filters.NewFilter("class", filters.OperatorEqual, "ipv4"), &asset, true},
+		{"asset field", filters.NewFilter("dns", filters.OperatorEqual, "example.com"), &asset, true},
+		{"metadata field", filters.NewFilter("country", filters.OperatorEqual, "US"), &asset, true},
+		{"origination data field", filters.NewFilter("attackSurface", filters.OperatorAnyIn, []string{"external"}), &asset, true},
+		{"origination data flag", filters.NewFilter("isExternal", filters.OperatorEqual, true), &asset, true},
+		{"risk status", filters.NewFilter("status", filters.OperatorStartsWith, model.Triage), &risk, true},
+		{"risk key", filters.NewFilter("key", filters.OperatorEqual, "#risk#example.com#CVE-2023-12345"), &risk, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := filters.Match(tt.filter, tt.model)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
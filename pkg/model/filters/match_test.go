@@ -0,0 +1,219 @@
+package filters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type matchOrigination struct {
+	Capability []string `neo4j:"capability,omitempty" json:"capability,omitempty"`
+	IsExternal bool     `neo4j:"isExternal" json:"isExternal"`
+}
+
+type matchMetadata struct {
+	Country string `neo4j:"country,omitempty" json:"country,omitempty"`
+	matchOrigination
+}
+
+type matchBase struct {
+	registry.BaseModel
+	Key     string  `neo4j:"key" json:"key"`
+	Status  string  `neo4j:"status" json:"status"`
+	TTL     int64   `neo4j:"ttl" json:"ttl"`
+	Secret  *string `neo4j:"secret" json:"secret,omitempty"`
+	Comment string  `neo4j:"-" json:"comment,omitempty"`
+	matchMetadata
+}
+
+type matchModel struct {
+	matchBase
+	DNS   string   `neo4j:"dns" json:"dns"`
+	Class string   `neo4j:"class" json:"class"`
+	Tags  []string `neo4j:"tags,omitempty" json:"tags,omitempty"`
+	Score float64  `neo4j:"score" json:"score"`
+	Ports []int    `neo4j:"ports" json:"ports"`
+}
+
+func (m *matchModel) GetDescription() string { return "model used for filter matching tests" }
+
+func newMatchModel() *matchModel {
+	secret := "#asset#amazon#123"
+	return &matchModel{
+		matchBase: matchBase{
+			Key:     "#asset#example.com#10.0.0.1",
+			Status:  "AH",
+			TTL:     168,
+			Secret:  &secret,
+			Comment: "seen",
+			matchMetadata: matchMetadata{
+				Country: "US",
+				matchOrigination: matchOrigination{
+					Capability: []string{"amazon", "portscan"},
+					IsExternal: true,
+				},
+			},
+		},
+		DNS:   "example.com",
+		Class: "ipv4",
+		Tags:  []string{"prod", "web"},
+		Score: 7.5,
+		Ports: []int{80, 443},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected bool
+	}{
+		{"equal", NewFilter("status", OperatorEqual, "AH"), true},
+		{"equal mismatch", NewFilter("status", OperatorEqual, "A"), false},
+		{"equal int64 against int64 field", NewFilter("ttl", OperatorEqual, int64(168)), true},
+		{"equal int against int64 field", NewFilter("ttl", OperatorEqual, 168), true},
+		{"equal float", NewFilter("score", OperatorEqual, 7.5), true},
+		{"equal bool from embedded struct", NewFilter("isExternal", OperatorEqual, true), true},
+		{"equal whole list", NewFilter("tags", OperatorEqual, []string{"prod", "web"}), true},
+		{"equal through pointer", NewFilter("secret", OperatorEqual, "#asset#amazon#123"), true},
+		{"json name when neo4j excluded", NewFilter("comment", OperatorEqual, "seen"), true},
+		{"embedded metadata", NewFilter("country", OperatorEqual, "US"), true},
+		{"contains", NewFilter("dns", OperatorContains, "ample"), true},
+		{"contains any value", NewFilter("dns", OperatorContains, []string{"nope", "ample"}), true},
+		{"contains on list is null", NewFilter("tags", OperatorContains, "prod"), false},
+		{"starts with", NewFilter("status", OperatorStartsWith, "A"), true},
+		{"ends with", NewFilter("dns", OperatorEndsWith, ".com"), true},
+		{"ends with mismatch", NewFilter("dns", OperatorEndsWith, ".local"), false},
+		{"like", NewFilter("dns", OperatorLike, `.*\.com`), true},
+		{"like is anchored", NewFilter("dns", OperatorLike, `example`), false},
+		{"less than", NewFilter("ttl", OperatorLessThan, 200), true},
+		{"less than equal", NewFilter("ttl", OperatorLessThanEqualTo, 168), true},
+		{"greater than", NewFilter("score", OperatorGreaterThan, 7), true},
+		{"greater than equal", NewFilter("score", OperatorGreaterThanEqualTo, 8), false},
+		{"string comparison", NewFilter("status", OperatorGreaterThan, "A"), true},
+		{"mixed type comparison is null", NewFilter("status", OperatorGreaterThan, 1), false},
+		{"in", NewFilter("class", OperatorIn, []string{"ipv4", "ipv6"}), true},
+		{"in mismatch", NewFilter("class", OperatorIn, []string{"domain"}), false},
+		{"in reversed is list membership", NewFilter("tags", OperatorIn, "prod", WithReverseOperands()), true},
+		{"in reversed mismatch", NewFilter("tags", OperatorIn, "dev", WithReverseOperands()), false},
+		{"contains reversed", NewFilter("class", OperatorContains, "ipv4-address", WithReverseOperands()), true},
+		{"less than reversed", NewFilter("ttl", OperatorLessThan, 100, WithReverseOperands()), true},
+		{"any in", NewFilter("capability", OperatorAnyIn, []string{"nuclei", "portscan"}), true},
+		{"any in mismatch", NewFilter("capability", OperatorAnyIn, []string{"nuclei"}), false},
+		{"any in numbers", NewFilter("ports", OperatorAnyIn, []any{int64(443)}), true},
+		{"any starts with", NewFilter("tags", OperatorAnyStartsWith, "we"), true},
+		{"any starts with mismatch", NewFilter("tags", OperatorAnyStartsWith, "dev"), false},
+		{"is null on unknown field", NewFilter("missing", OperatorIsNull, nil), true},
+		{"is null on set field", NewFilter("dns", OperatorIsNull, nil), false},
+		{"is not null", NewFilter("dns", OperatorIsNotNull, nil), true},
+		{"not", NewFilter("status", OperatorEqual, "A", WithNot()), true},
+		{"not on null stays non-match", NewFilter("missing", OperatorEqual, "x", WithNot()), false},
+		{"and", NewFilter("", OperatorAnd, []Filter{
+			NewFilter("status", OperatorStartsWith, "A"),
+			NewFilter("class", OperatorIn, []string{"ipv4", "ipv6"}),
+		}), true},
+		{"and short circuit false", NewFilter("", OperatorAnd, []Filter{
+			NewFilter("status", OperatorEqual, "P"),
+			NewFilter("missing", OperatorEqual, "x"),
+		}), false},
+		{"or", NewFilter("", OperatorOr, []Filter{
+			NewFilter("status", OperatorEqual, "P"),
+			NewFilter("dns", OperatorEndsWith, ".com"),
+		}), true},
+		{"or with null", NewFilter("", OperatorOr, []Filter{
+			NewFilter("status", OperatorEqual, "P"),
+			NewFilter("missing", OperatorEqual, "x"),
+		}), false},
+		{"not or with null stays non-match", NewFilter("", OperatorOr, []Filter{
+			NewFilter("status", OperatorEqual, "P"),
+			NewFilter("missing", OperatorEqual, "x"),
+		}, WithNot()), false},
+		{"nested", NewFilter("", OperatorAnd, []Filter{
+			NewFilter("status", OperatorStartsWith, "A"),
+			NewFilter("", OperatorOr, []Filter{
+				NewFilter("class", OperatorIn, []string{"ipv6"}),
+				NewFilter("dns", OperatorEndsWith, ".local", WithNot()),
+			}),
+		}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Match(tt.filter, newMatchModel())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestMatch_OmitEmptyIsNull(t *testing.T) {
+	m := newMatchModel()
+	m.Country = ""
+	m.Tags = nil
+	m.Secret = nil
+
+	for _, field := range []string{"country", "tags", "secret"} {
+		result, err := Match(NewFilter(field, OperatorIsNull, nil), m)
+		require.NoError(t, err)
+		assert.True(t, result, field)
+	}
+
+	// non-omitempty zero values are stored, and are therefore not null
+	m.DNS = ""
+	result, err := Match(NewFilter("dns", OperatorIsNull, nil), m)
+	require.NoError(t, err)
+	assert.False(t, result)
+}
+
+func TestMatch_FromJSON(t *testing.T) {
+	input := `{
+		"operator": "AND",
+		"value": [
+			{"field": "status", "operator": "STARTS WITH", "value": "A"},
+			{"field": "ttl", "operator": ">", "value": 100},
+			{"field": "tags", "operator": "ANY_IN", "value": ["web", "api"]}
+		]
+	}`
+	var f Filter
+	require.NoError(t, json.Unmarshal([]byte(input), &f))
+
+	result, err := Match(f, newMatchModel())
+	require.NoError(t, err)
+	assert.True(t, result)
+}
+
+func TestMatch_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+	}{
+		{"unsupported operator", NewFilter("dns", "~", "x")},
+		{"missing value", Filter{Field: "dns", Operator: OperatorEqual}},
+		{"comparison with several values", NewFilter("ttl", OperatorLessThan, []int{1, 2})},
+		{"invalid like pattern", NewFilter("dns", OperatorLike, "(")},
+		{"non-filter in logical filter", NewFilter("", OperatorAnd, "status")},
+		{"relationship filter", Filter{
+			Field:    "name",
+			Operator: OperatorEqual,
+			Value:    SliceOrValue[any]{"x"},
+			RelationshipFilter: RelationshipFilter{
+				RelationshipNodeLabel: "Port",
+				RelationshipDirection: "target",
+				RelationshipLabel:     "HAS_PORT",
+			},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Match(tt.filter, newMatchModel())
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := Match(NewFilter("dns", OperatorEqual, "x"), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,644 @@
+package filters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The filter query language is a human-writable form of Filter. For example:
+//
+//	status STARTS WITH "A" AND (class IN ["ipv4","ipv6"] OR NOT dns ENDS WITH ".local")
+//
+// Grammar:
+//
+//	query      = or
+//	or         = and { "OR" and }
+//	and        = unary { "AND" unary }
+//	unary      = "NOT" unary | "(" or ")" | predicate
+//	predicate  = property operator value | value operator property | property "IS" ["NOT"] "NULL" | relationship
+//	property   = [ alias "." ] name | [ alias ] relationship "." name
+//	relationship = "-[" label "]->" nodeLabel | "<-[" label "]-" nodeLabel
+//	operator   = "=" | "<" | "<=" | ">" | ">=" | "CONTAINS" | "LIKE" | "STARTS WITH" | "ENDS WITH"
+//	           | "IN" | "ANY_IN" | "ANY_STARTS_WITH"
+//	value      = string | number | "true" | "false" | "null" | "[" [ value { "," value } ] "]"
+//
+// Keywords are case-insensitive. Names that are not plain identifiers, or that collide with a keyword,
+// are quoted with backticks. Strings use JSON escaping. A value on the left of the operator sets
+// ReverseOperands, and a relationship without a property matches on the relationship's existence.
+// "-[HAS_PORT]->Port.port = 443" is a relationship filter whose related node is the target,
+// "<-[DISCOVERED]-Asset" one whose related node is the source. An alias prefixes the property,
+// as in "m.name" or "m-[HAS_PORT]->Port.port".
+
+// ParseError reports a syntax error in a filter query
+type ParseError struct {
+	Column  int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Message)
+}
+
+// ParseQuery parses a filter query into a Filter and validates the result with Filter.Validate
+func ParseQuery(query string) (Filter, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return Filter{}, err
+	}
+	p := &queryParser{input: query, tokens: tokens}
+	f, err := p.or()
+	if err != nil {
+		return Filter{}, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return Filter{}, p.errorf(tok, "unexpected %s", tok)
+	}
+	if err := f.Validate(); err != nil {
+		return Filter{}, err
+	}
+	return f, nil
+}
+
+// FormatQuery prints f in the filter query language. ParseQuery(FormatQuery(f)) yields a filter
+// equal to f once its values are normalized the way JSON unmarshalling normalizes them.
+func FormatQuery(f Filter) (string, error) {
+	return formatQuery(f, "")
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenQuoted
+	tokenString
+	tokenNumber
+	tokenPunct
+	tokenRelOut    // -[
+	tokenRelIn     // <-[
+	tokenRelOutEnd // ]->
+	tokenRelInEnd  // ]-
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+func (t token) keyword(words ...string) bool {
+	if t.kind != tokenIdent {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+var keywords = []string{"AND", "OR", "NOT", "IS", "NULL", "TRUE", "FALSE", "IN", "CONTAINS", "LIKE", "STARTS", "ENDS", "WITH", "ANY_IN", "ANY_STARTS_WITH"}
+
+func isKeyword(s string) bool {
+	for _, k := range keywords {
+		if strings.EqualFold(s, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func column(input string, pos int) int {
+	return utf8.RuneCountInString(input[:pos]) + 1
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(input) {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(input[i:], "<-["):
+			tokens = append(tokens, token{tokenRelIn, "<-[", i})
+			i += 3
+		case strings.HasPrefix(input[i:], "-["):
+			tokens = append(tokens, token{tokenRelOut, "-[", i})
+			i += 2
+		case strings.HasPrefix(input[i:], "]->"):
+			tokens = append(tokens, token{tokenRelOutEnd, "]->", i})
+			i += 3
+		case strings.HasPrefix(input[i:], "]-"):
+			tokens = append(tokens, token{tokenRelInEnd, "]-", i})
+			i += 2
+		case strings.HasPrefix(input[i:], "<=") || strings.HasPrefix(input[i:], ">="):
+			tokens = append(tokens, token{tokenPunct, input[i : i+2], i})
+			i += 2
+		case strings.ContainsRune("()[],.=<>", rune(c)):
+			tokens = append(tokens, token{tokenPunct, string(c), i})
+			i++
+		case c == '"':
+			end, err := scanString(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokenString, input[i:end], i})
+			i = end
+		case c == '`':
+			end := i + 1
+			var name strings.Builder
+			for {
+				if end >= len(input) {
+					return nil, &ParseError{column(input, i), "unterminated quoted name"}
+				}
+				if input[end] == '`' {
+					if end+1 < len(input) && input[end+1] == '`' {
+						name.WriteByte('`')
+						end += 2
+						continue
+					}
+					break
+				}
+				name.WriteByte(input[end])
+				end++
+			}
+			tokens = append(tokens, token{tokenQuoted, name.String(), i})
+			i = end + 1
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(input) && strings.IndexByte("0123456789.eE+-", input[end]) >= 0 {
+				if (input[end] == '+' || input[end] == '-') && input[end-1] != 'e' && input[end-1] != 'E' {
+					break
+				}
+				end++
+			}
+			tokens = append(tokens, token{tokenNumber, input[i:end], i})
+			i = end
+		case c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z'):
+			end := i + 1
+			for end < len(input) && isIdentByte(input[end]) {
+				end++
+			}
+			tokens = append(tokens, token{tokenIdent, input[i:end], i})
+			i = end
+		default:
+			r, _ := utf8.DecodeRuneInString(input[i:])
+			return nil, &ParseError{column(input, i), fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(input)}), nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
+
+func scanString(input string, start int) (int, error) {
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, &ParseError{column(input, start), "unterminated string"}
+}
+
+type queryParser struct {
+	input  string
+	tokens []token
+	pos    int
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *queryParser) errorf(tok token, format string, args ...any) error {
+	return &ParseError{column(p.input, tok.pos), fmt.Sprintf(format, args...)}
+}
+
+func (p *queryParser) expectPunct(text string) error {
+	tok := p.next()
+	if tok.kind != tokenPunct || tok.text != text {
+		return p.errorf(tok, "expected %q, found %s", text, tok)
+	}
+	return nil
+}
+
+func (p *queryParser) or() (Filter, error) {
+	return p.logical(OperatorOr, p.and)
+}
+
+func (p *queryParser) and() (Filter, error) {
+	return p.logical(OperatorAnd, p.unary)
+}
+
+func (p *queryParser) logical(operator string, operand func() (Filter, error)) (Filter, error) {
+	first, err := operand()
+	if err != nil {
+		return Filter{}, err
+	}
+	if !p.peek().keyword(operator) {
+		return first, nil
+	}
+	var nested []Filter
+	nested = append(nested, first)
+	for p.peek().keyword(operator) {
+		p.next()
+		f, err := operand()
+		if err != nil {
+			return Filter{}, err
+		}
+		nested = append(nested, f)
+	}
+	return NewFilter("", operator, nested), nil
+}
+
+func (p *queryParser) unary() (Filter, error) {
+	tok := p.peek()
+	if tok.keyword("NOT") {
+		p.next()
+		f, err := p.unary()
+		if err != nil {
+			return Filter{}, err
+		}
+		f.Not = !f.Not
+		return f, nil
+	}
+	if tok.kind == tokenPunct && tok.text == "(" {
+		p.next()
+		f, err := p.or()
+		if err != nil {
+			return Filter{}, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return Filter{}, err
+		}
+		return f, nil
+	}
+	return p.predicate()
+}
+
+func (p *queryParser) predicate() (Filter, error) {
+	tok := p.peek()
+	if p.startsProperty(tok) {
+		f, hasField, err := p.property()
+		if err != nil {
+			return Filter{}, err
+		}
+		if !hasField {
+			return f, nil
+		}
+		op, err := p.operator(true)
+		if err != nil {
+			return Filter{}, err
+		}
+		f.Operator = op
+		if op == OperatorIsNull || op == OperatorIsNotNull {
+			return f, nil
+		}
+		f.Value, err = p.value()
+		return f, err
+	}
+
+	values, err := p.value()
+	if err != nil {
+		return Filter{}, err
+	}
+	op, err := p.operator(false)
+	if err != nil {
+		return Filter{}, err
+	}
+	propertyTok := p.peek()
+	if !p.startsProperty(propertyTok) {
+		return Filter{}, p.errorf(propertyTok, "expected property, found %s", propertyTok)
+	}
+	f, hasField, err := p.property()
+	if err != nil {
+		return Filter{}, err
+	}
+	if !hasField {
+		return Filter{}, p.errorf(propertyTok, "expected property after relationship")
+	}
+	f.Operator = op
+	f.Value = values
+	f.ReverseOperands = true
+	return f, nil
+}
+
+func (p *queryParser) startsProperty(tok token) bool {
+	switch tok.kind {
+	case tokenQuoted, tokenRelOut, tokenRelIn:
+		return true
+	case tokenIdent:
+		return !tok.keyword("TRUE", "FALSE", "NULL")
+	}
+	return false
+}
+
+// property parses a property reference. A relationship without a trailing property is reported
+// with hasField=false.
+func (p *queryParser) property() (f Filter, hasField bool, err error) {
+	if tok := p.peek(); tok.kind != tokenRelOut && tok.kind != tokenRelIn {
+		name, err := p.name()
+		if err != nil {
+			return Filter{}, false, err
+		}
+		switch next := p.peek(); {
+		case next.kind == tokenPunct && next.text == ".":
+			p.next()
+			f.Alias = name
+			f.Field, err = p.name()
+			return f, true, err
+		case next.kind == tokenRelOut || next.kind == tokenRelIn:
+			f.Alias = name
+		default:
+			f.Field = name
+			return f, true, nil
+		}
+	}
+
+	start := p.next()
+	label, err := p.name()
+	if err != nil {
+		return Filter{}, false, err
+	}
+	end := p.next()
+	direction := "target"
+	switch {
+	case start.kind == tokenRelOut && end.kind == tokenRelOutEnd:
+	case start.kind == tokenRelIn && end.kind == tokenRelInEnd:
+		direction = "source"
+	case start.kind == tokenRelOut:
+		return Filter{}, false, p.errorf(end, "expected \"]->\", found %s", end)
+	default:
+		return Filter{}, false, p.errorf(end, "expected \"]-\", found %s", end)
+	}
+	node, err := p.name()
+	if err != nil {
+		return Filter{}, false, err
+	}
+	f.RelationshipFilter = RelationshipFilter{
+		RelationshipNodeLabel: node,
+		RelationshipDirection: direction,
+		RelationshipLabel:     label,
+	}
+	if dot := p.peek(); dot.kind != tokenPunct || dot.text != "." {
+		return f, false, nil
+	}
+	p.next()
+	f.Field, err = p.name()
+	return f, true, err
+}
+
+func (p *queryParser) name() (string, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokenQuoted:
+		return tok.text, nil
+	case tok.kind == tokenIdent && !isKeyword(tok.text):
+		return tok.text, nil
+	}
+	return "", p.errorf(tok, "expected name, found %s", tok)
+}
+
+func (p *queryParser) operator(allowNull bool) (string, error) {
+	tok := p.next()
+	if tok.kind == tokenPunct {
+		switch tok.text {
+		case OperatorEqual, OperatorLessThan, OperatorLessThanEqualTo, OperatorGreaterThan, OperatorGreaterThanEqualTo:
+			return tok.text, nil
+		}
+	}
+	switch {
+	case tok.keyword(OperatorContains, OperatorLike, OperatorIn, OperatorAnyIn, OperatorAnyStartsWith):
+		return strings.ToUpper(tok.text), nil
+	case tok.keyword("STARTS", "ENDS"):
+		with := p.next()
+		if !with.keyword("WITH") {
+			return "", p.errorf(with, "expected WITH, found %s", with)
+		}
+		return strings.ToUpper(tok.text) + " WITH", nil
+	case tok.keyword("IS") && allowNull:
+		op := OperatorIsNull
+		if p.peek().keyword("NOT") {
+			p.next()
+			op = OperatorIsNotNull
+		}
+		if null := p.next(); !null.keyword("NULL") {
+			return "", p.errorf(null, "expected NULL, found %s", null)
+		}
+		return op, nil
+	}
+	return "", p.errorf(tok, "expected operator, found %s", tok)
+}
+
+func (p *queryParser) value() (SliceOrValue[any], error) {
+	tok := p.peek()
+	if tok.kind == tokenPunct && tok.text == "[" {
+		p.next()
+		values := SliceOrValue[any]{}
+		if end := p.peek(); end.kind == tokenPunct && end.text == "]" {
+			p.next()
+			return values, nil
+		}
+		for {
+			v, err := p.literal()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			sep := p.next()
+			if sep.kind == tokenPunct && sep.text == "]" {
+				return values, nil
+			}
+			if sep.kind != tokenPunct || sep.text != "," {
+				return nil, p.errorf(sep, "expected \",\" or \"]\", found %s", sep)
+			}
+		}
+	}
+	v, err := p.literal()
+	if err != nil {
+		return nil, err
+	}
+	return SliceOrValue[any]{v}, nil
+}
+
+func (p *queryParser) literal() (any, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokenString:
+		var s string
+		if err := json.Unmarshal([]byte(tok.text), &s); err != nil {
+			return nil, p.errorf(tok, "invalid string %s", tok.text)
+		}
+		return s, nil
+	case tok.kind == tokenNumber:
+		// numbers are normalized the same way Filter.UnmarshalJSON normalizes them
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil || !json.Valid([]byte(tok.text)) {
+			return nil, p.errorf(tok, "invalid number %s", tok.text)
+		}
+		if n == float64(int64(n)) {
+			return int64(n), nil
+		}
+		return n, nil
+	case tok.keyword("TRUE"):
+		return true, nil
+	case tok.keyword("FALSE"):
+		return false, nil
+	case tok.keyword("NULL"):
+		return nil, nil
+	}
+	return nil, p.errorf(tok, "expected value, found %s", tok)
+}
+
+func formatQuery(f Filter, parent string) (string, error) {
+	var out string
+	switch f.Operator {
+	case OperatorAnd, OperatorOr:
+		if len(f.Value) == 0 {
+			return "", fmt.Errorf("cannot format %s filter without nested filters", f.Operator)
+		}
+		parts := make([]string, 0, len(f.Value))
+		for _, value := range f.Value {
+			nested, ok := value.(Filter)
+			if !ok {
+				return "", fmt.Errorf("%s filter contains non-filter value %T", f.Operator, value)
+			}
+			part, err := formatQuery(nested, f.Operator)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		}
+		out = strings.Join(parts, " "+f.Operator+" ")
+		// nested logical filters keep their grouping: same-operator children and OR under AND need parentheses
+		if len(f.Value) > 1 && !f.Not && (parent == f.Operator || (parent == OperatorAnd && f.Operator == OperatorOr)) {
+			out = "(" + out + ")"
+		}
+		if f.Not {
+			return "NOT (" + out + ")", nil
+		}
+		return out, nil
+	}
+
+	property := formatProperty(f)
+	switch {
+	case f.IsRelationshipFilter() && f.Field == "" && f.Operator == "":
+		out = property
+	case f.Operator == OperatorIsNull || f.Operator == OperatorIsNotNull:
+		out = property + " " + f.Operator
+	default:
+		if f.Field == "" {
+			return "", fmt.Errorf("operator %q requires a field", f.Operator)
+		}
+		values := operands(f.Value)
+		list := len(values) != 1 || f.Operator == OperatorIn && !f.ReverseOperands || f.Operator == OperatorAnyIn
+		value, err := formatValue(values, list)
+		if err != nil {
+			return "", err
+		}
+		if f.ReverseOperands {
+			out = value + " " + f.Operator + " " + property
+		} else {
+			out = property + " " + f.Operator + " " + value
+		}
+	}
+	if f.Not {
+		out = "NOT " + out
+	}
+	return out, nil
+}
+
+func formatProperty(f Filter) string {
+	var prefix string
+	if f.Alias != "" {
+		prefix = formatName(f.Alias)
+	}
+	if f.IsRelationshipFilter() {
+		if f.RelationshipDirection == "source" {
+			prefix += "<-[" + formatName(f.RelationshipLabel) + "]-" + formatName(f.RelationshipNodeLabel)
+		} else {
+			prefix += "-[" + formatName(f.RelationshipLabel) + "]->" + formatName(f.RelationshipNodeLabel)
+		}
+		if f.Field == "" {
+			return prefix
+		}
+	}
+	if prefix != "" {
+		prefix += "."
+	}
+	return prefix + formatName(f.Field)
+}
+
+func formatName(name string) string {
+	if identifier.MatchString(name) && !isKeyword(name) {
+		return name
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func formatValue(values []any, list bool) (string, error) {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		s, err := formatLiteral(v)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, s)
+	}
+	if !list {
+		return parts[0], nil
+	}
+	return "[" + strings.Join(parts, ", ") + "]", nil
+}
+
+func formatLiteral(v any) (string, error) {
+	switch value := v.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(value); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return "", fmt.Errorf("cannot format %v", value)
+		}
+		return strconv.FormatFloat(value, 'g', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	}
+	return "", fmt.Errorf("cannot format value of type %T", v)
+}
@@ -0,0 +1,264 @@
+package filters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected Filter
+	}{
+		{
+			name:     "equal",
+			query:    `status = "A"`,
+			expected: Filter{Field: "status", Operator: OperatorEqual, Value: SliceOrValue[any]{"A"}},
+		},
+		{
+			name:     "equal list",
+			query:    `tags = ["a", "b"]`,
+			expected: Filter{Field: "tags", Operator: OperatorEqual, Value: SliceOrValue[any]{"a", "b"}},
+		},
+		{
+			name:     "numbers",
+			query:    `score >= -1.5`,
+			expected: Filter{Field: "score", Operator: OperatorGreaterThanEqualTo, Value: SliceOrValue[any]{-1.5}},
+		},
+		{
+			name:     "integral numbers are int64",
+			query:    `port < 1e3`,
+			expected: Filter{Field: "port", Operator: OperatorLessThan, Value: SliceOrValue[any]{int64(1000)}},
+		},
+		{
+			name:     "booleans and null",
+			query:    `flags IN [true, FALSE, null]`,
+			expected: Filter{Field: "flags", Operator: OperatorIn, Value: SliceOrValue[any]{true, false, nil}},
+		},
+		{
+			name:     "string escapes",
+			query:    `name = "a \"quoted\" \\ é"`,
+			expected: Filter{Field: "name", Operator: OperatorEqual, Value: SliceOrValue[any]{`a "quoted" \ é`}},
+		},
+		{
+			name:     "keywords are case-insensitive",
+			query:    `not dns ends with ".local"`,
+			expected: Filter{Field: "dns", Operator: OperatorEndsWith, Value: SliceOrValue[any]{".local"}, Not: true},
+		},
+		{
+			name:     "reversed operands",
+			query:    `"prod" IN tags`,
+			expected: Filter{Field: "tags", Operator: OperatorIn, Value: SliceOrValue[any]{"prod"}, ReverseOperands: true},
+		},
+		{
+			name:     "is not null",
+			query:    `secret IS NOT NULL`,
+			expected: Filter{Field: "secret", Operator: OperatorIsNotNull},
+		},
+		{
+			name:     "any operators",
+			query:    `capability ANY_STARTS_WITH "port"`,
+			expected: Filter{Field: "capability", Operator: OperatorAnyStartsWith, Value: SliceOrValue[any]{"port"}},
+		},
+		{
+			name:     "quoted names and alias",
+			query:    "m.`odd field``` LIKE \".*\"",
+			expected: Filter{Field: "odd field`", Operator: OperatorLike, Value: SliceOrValue[any]{".*"}, Alias: "m"},
+		},
+		{
+			name:  "precedence",
+			query: `status STARTS WITH "A" AND (class IN ["ipv4","ipv6"] OR NOT dns ENDS WITH ".local")`,
+			expected: NewFilter("", OperatorAnd, []Filter{
+				{Field: "status", Operator: OperatorStartsWith, Value: SliceOrValue[any]{"A"}},
+				NewFilter("", OperatorOr, []Filter{
+					{Field: "class", Operator: OperatorIn, Value: SliceOrValue[any]{"ipv4", "ipv6"}},
+					{Field: "dns", Operator: OperatorEndsWith, Value: SliceOrValue[any]{".local"}, Not: true},
+				}),
+			}),
+		},
+		{
+			name:  "and binds tighter than or",
+			query: `a = 1 OR b = 2 AND c = 3`,
+			expected: NewFilter("", OperatorOr, []Filter{
+				{Field: "a", Operator: OperatorEqual, Value: SliceOrValue[any]{int64(1)}},
+				NewFilter("", OperatorAnd, []Filter{
+					{Field: "b", Operator: OperatorEqual, Value: SliceOrValue[any]{int64(2)}},
+					{Field: "c", Operator: OperatorEqual, Value: SliceOrValue[any]{int64(3)}},
+				}),
+			}),
+		},
+		{
+			name:  "negated group",
+			query: `NOT (status = "D" OR status = "F")`,
+			expected: NewFilter("", OperatorOr, []Filter{
+				{Field: "status", Operator: OperatorEqual, Value: SliceOrValue[any]{"D"}},
+				{Field: "status", Operator: OperatorEqual, Value: SliceOrValue[any]{"F"}},
+			}, WithNot()),
+		},
+		{
+			name:     "relationship to target",
+			query:    `-[HAS_PORT]->Port.port = 443`,
+			expected: relationship("port", OperatorEqual, int64(443), "Port", "target", "HAS_PORT"),
+		},
+		{
+			name:     "relationship from source reversed",
+			query:    `"ipv4" = <-[DISCOVERED]-Asset.class`,
+			expected: relationship("class", OperatorEqual, "ipv4", "Asset", "source", "DISCOVERED", WithReverseOperands()),
+		},
+		{
+			name:  "relationship existence",
+			query: `NOT -[HAS_VULNERABILITY]->Risk`,
+			expected: Filter{Not: true, RelationshipFilter: RelationshipFilter{
+				RelationshipNodeLabel: "Risk",
+				RelationshipDirection: "target",
+				RelationshipLabel:     "HAS_VULNERABILITY",
+			}},
+		},
+		{
+			name:  "relationship with alias",
+			query: `m-[HAS_PORT]->Port.service IS NULL`,
+			expected: Filter{Field: "service", Operator: OperatorIsNull, Alias: "m", RelationshipFilter: RelationshipFilter{
+				RelationshipNodeLabel: "Port",
+				RelationshipDirection: "target",
+				RelationshipLabel:     "HAS_PORT",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseQuery(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, f)
+		})
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	tests := []struct {
+		query  string
+		column int
+	}{
+		{``, 1},
+		{`status`, 7},
+		{`status ~ "A"`, 8},
+		{`status = `, 10},
+		{`status = "A" AND`, 17},
+		{`status = "A" status`, 14},
+		{`(status = "A"`, 14},
+		{`name = "unterminated`, 8},
+		{`name STARTS "x"`, 13},
+		{`name = [1, 2`, 13},
+		{`name = [[1]]`, 9},
+		{`"x" = "y"`, 7},
+		{`"x" IS NULL`, 5},
+		{`-[HAS_PORT]-Port.port = 1`, 11},
+		{`-[HAS_PORT]->Port = 1`, 19},
+		{`é = 1`, 1},
+		{`name = 1 AND é = 1`, 14},
+		{"`name = 1", 1},
+		{`AND = 1`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQuery(tt.query)
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tt.column, parseErr.Column, err.Error())
+		})
+	}
+}
+
+func TestFormatQuery_RoundTrip(t *testing.T) {
+	queries := []string{
+		`status = "A"`,
+		`tags = ["a", "b"]`,
+		`score >= -1.5`,
+		`ttl > 1e+21`,
+		`flags IN [true, false, null]`,
+		`class IN ["ipv4"]`,
+		`attackSurface ANY_IN ["external"]`,
+		`capability ANY_STARTS_WITH ["port", "nuc"]`,
+		`name = "a \"quoted\" \\ é <b>"`,
+		`"prod" IN tags`,
+		`["a.com", "b.com"] ENDS WITH dns`,
+		`secret IS NULL`,
+		`NOT secret IS NOT NULL`,
+		"m.`odd field``` LIKE \".*\"",
+		"`in` CONTAINS \"x\"",
+		`status STARTS WITH "A" AND (class IN ["ipv4", "ipv6"] OR NOT dns ENDS WITH ".local")`,
+		`a = 1 OR b = 2 AND c = 3`,
+		`(a = 1 OR b = 2) AND c = 3`,
+		`(a = 1 AND b = 2) AND c = 3`,
+		`a = 1 AND NOT (b = 2 AND c = 3)`,
+		`NOT (status = "D" OR status = "F")`,
+		`-[HAS_PORT]->Port.port = 443 AND NOT -[HAS_VULNERABILITY]->Risk.status STARTS WITH "O"`,
+		`<-[DISCOVERED]-Asset`,
+		`m-[HAS_PORT]->Port.service IS NULL`,
+	}
+
+	for _, query := range queries {
+		t.Run(query, func(t *testing.T) {
+			f, err := ParseQuery(query)
+			require.NoError(t, err)
+
+			formatted, err := FormatQuery(f)
+			require.NoError(t, err)
+			assert.Equal(t, query, formatted)
+
+			// the parsed filter survives the JSON representation used by the API
+			data, err := json.Marshal(f)
+			require.NoError(t, err)
+			var decoded Filter
+			require.NoError(t, json.Unmarshal(data, &decoded))
+			formatted, err = FormatQuery(decoded)
+			require.NoError(t, err)
+			assert.Equal(t, query, formatted)
+		})
+	}
+}
+
+func TestFormatQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected string
+	}{
+		{"go values", NewFilter("port", OperatorIn, []int{80, 443}), `port IN [80, 443]`},
+		{"single value in", NewFilter("class", OperatorIn, "ipv4"), `class IN ["ipv4"]`},
+		{"float", NewFilter("score", OperatorGreaterThan, 7.5), `score > 7.5`},
+		{"logical with field", NewFilter("status", OperatorOr, []Filter{NewFilter("a", OperatorEqual, true)}), `a = true`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := FormatQuery(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, query)
+		})
+	}
+}
+
+func TestFormatQuery_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+	}{
+		{"empty logical", Filter{Operator: OperatorAnd}},
+		{"non-filter in logical", NewFilter("", OperatorOr, "x")},
+		{"missing field", NewFilter("", OperatorEqual, "x")},
+		{"unsupported value", NewFilter("when", OperatorEqual, struct{}{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FormatQuery(tt.filter)
+			assert.Error(t, err)
+		})
+	}
+}
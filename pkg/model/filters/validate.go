@@ -0,0 +1,228 @@
+package filters
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// ValidateFor checks f against the registered model typeName, in addition to Validate:
+//   - every Field must resolve to a property of the model, by neo4j or json tag
+//   - operators must suit the property's type: string operators need strings, comparisons need
+//     numbers or strings, ANY_* and reversed IN need lists, and values must match the property
+//   - RelationshipLabel must be the label of a registered relationship, and the fields of a
+//     relationship filter are checked against the registered model named by RelationshipNodeLabel
+//
+// The labels of relationships whose label depends on their content, such as ADRelationship, are
+// known through the aliases they are registered with.
+func ValidateFor(typeName string, f Filter) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	t, ok := registry.Registry.GetType(strings.ToLower(typeName))
+	if !ok {
+		return fmt.Errorf("unknown type %q", typeName)
+	}
+	return validateFor(typeName, t, f, relationshipLabels())
+}
+
+func validateFor(typeName string, t reflect.Type, f Filter, labels map[string]bool) error {
+	if f.Operator == OperatorAnd || f.Operator == OperatorOr {
+		for _, value := range f.Value {
+			nested, ok := value.(Filter)
+			if !ok {
+				return fmt.Errorf("%s filter contains non-filter value %T", f.Operator, value)
+			}
+			if err := validateFor(typeName, t, nested, labels); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if f.IsRelationshipFilter() {
+		if !labels[f.RelationshipLabel] && !labels[strings.ToLower(f.RelationshipLabel)] {
+			return fmt.Errorf("unknown relationship label %q", f.RelationshipLabel)
+		}
+		typeName = f.RelationshipNodeLabel
+		related, ok := registry.Registry.GetType(strings.ToLower(typeName))
+		if !ok {
+			return fmt.Errorf("unknown relationship node label %q", typeName)
+		}
+		t = related
+		if f.Field == "" && f.Operator == "" {
+			return nil
+		}
+	}
+
+	if f.Field == "" {
+		return fmt.Errorf("operator %q requires a field", f.Operator)
+	}
+	fld, ok := resolveField(t, f.Field)
+	if !ok {
+		return fmt.Errorf("unknown field %q on %s", f.Field, typeName)
+	}
+	if err := validateOperator(f, fld.Type); err != nil {
+		return fmt.Errorf("field %q on %s: %w", f.Field, typeName, err)
+	}
+	return nil
+}
+
+// kind is the coarse type of a property or value as far as operators are concerned
+type kind string
+
+const (
+	kindString kind = "string"
+	kindNumber kind = "number"
+	kindBool   kind = "bool"
+	kindList   kind = "list"
+	kindOther  kind = "other"
+)
+
+func kindOf(t reflect.Type) kind {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return kindString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return kindNumber
+	case reflect.Bool:
+		return kindBool
+	case reflect.Slice, reflect.Array:
+		return kindList
+	}
+	return kindOther
+}
+
+// elemType returns the element type of a list property
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Elem()
+}
+
+func valueKind(v any) kind {
+	switch v.(type) {
+	case string:
+		return kindString
+	case int64, float64:
+		return kindNumber
+	case bool:
+		return kindBool
+	case []any:
+		return kindList
+	}
+	return kindOther
+}
+
+// compatible reports whether value can be compared with a property of type t. Null is
+// compatible with everything, as are properties whose type has no graph representation.
+func compatible(t reflect.Type, value any) bool {
+	property := kindOf(t)
+	return value == nil || property == kindOther || property == valueKind(value)
+}
+
+func validateOperator(f Filter, t reflect.Type) error {
+	switch f.Operator {
+	case OperatorIsNull, OperatorIsNotNull:
+		return nil
+	}
+
+	values := operands(f.Value)
+	if len(values) == 0 {
+		return fmt.Errorf("operator %q requires a value", f.Operator)
+	}
+	property := kindOf(t)
+	list := property == kindList
+
+	// check verifies the values against the property, or against its elements for list operators
+	check := func(t reflect.Type) error {
+		for _, value := range values {
+			if !compatible(t, value) {
+				return fmt.Errorf("value %v (%T) is not comparable with %s", value, value, kindOf(t))
+			}
+		}
+		return nil
+	}
+
+	switch f.Operator {
+	case OperatorEqual:
+		if list {
+			return check(elemType(t))
+		}
+		if len(values) > 1 {
+			return fmt.Errorf("operator %q with several values requires a list, not %s", f.Operator, property)
+		}
+		return check(t)
+	case OperatorContains, OperatorStartsWith, OperatorEndsWith, OperatorLike:
+		if property != kindString {
+			return fmt.Errorf("operator %q requires a string, not %s", f.Operator, property)
+		}
+		return check(t)
+	case OperatorLessThan, OperatorLessThanEqualTo, OperatorGreaterThan, OperatorGreaterThanEqualTo:
+		if property != kindNumber && property != kindString {
+			return fmt.Errorf("operator %q requires a number or string, not %s", f.Operator, property)
+		}
+		if len(values) != 1 {
+			return fmt.Errorf("operator %q requires a single value", f.Operator)
+		}
+		return check(t)
+	case OperatorIn:
+		if f.ReverseOperands {
+			if !list {
+				return fmt.Errorf("operator %q with reversed operands requires a list, not %s", f.Operator, property)
+			}
+			return check(elemType(t))
+		}
+		if list {
+			return fmt.Errorf("operator %q requires a single value property, not %s", f.Operator, property)
+		}
+		return check(t)
+	case OperatorAnyIn:
+		if !list {
+			return fmt.Errorf("operator %q requires a list, not %s", f.Operator, property)
+		}
+		return check(elemType(t))
+	case OperatorAnyStartsWith:
+		if !list || kindOf(elemType(t)) != kindString {
+			return fmt.Errorf("operator %q requires a list of strings", f.Operator)
+		}
+		return check(elemType(t))
+	}
+	return fmt.Errorf("unsupported operator %q", f.Operator)
+}
+
+// relationshipLabels collects the labels of all registered relationships. Relationships are the
+// registered types with Label and Nodes methods.
+func relationshipLabels() map[string]bool {
+	labels := map[string]bool{}
+	for name, t := range registry.Registry.GetAllTypes() {
+		if _, ok := t.MethodByName("Nodes"); !ok {
+			continue
+		}
+		m, ok := registry.Registry.MakeType(name)
+		if !ok {
+			continue
+		}
+		labeler, ok := m.(interface{ Label() string })
+		if !ok {
+			continue
+		}
+		if label := labeler.Label(); label != "" {
+			labels[label] = true
+			continue
+		}
+		// registry aliases are lowercased, and are matched as such
+		if name != registry.Name(m) {
+			labels[name] = true
+		}
+	}
+	return labels
+}
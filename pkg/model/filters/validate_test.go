@@ -0,0 +1,75 @@
+package filters_test
+
+import (
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/model/filters"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		typeName string
+		filter   filters.Filter
+		valid    bool
+	}{
+		{"string equal", "asset", filters.NewFilter("dns", filters.OperatorEqual, "example.com"), true},
+		{"type name is case-insensitive", "Asset", filters.NewFilter("dns", filters.OperatorEqual, "example.com"), true},
+		{"embedded field", "asset", filters.NewFilter("class", filters.OperatorIn, []string{"ipv4", "ipv6"}), true},
+		{"json tag", "asset", filters.NewFilter("comment", filters.OperatorContains, "initial"), true},
+		{"numeric comparison", "port", filters.NewFilter("port", filters.OperatorGreaterThan, 1024), true},
+		{"string comparison", "asset", filters.NewFilter("created", filters.OperatorGreaterThanEqualTo, "2024-01-01"), true},
+		{"is null", "asset", filters.NewFilter("secret", filters.OperatorIsNull, nil), true},
+		{"any in on list", "asset", filters.NewFilter("attackSurface", filters.OperatorAnyIn, []string{"external"}), true},
+		{"any starts with on list", "asset", filters.NewFilter("attackSurface", filters.OperatorAnyStartsWith, "ext"), true},
+		{"reversed in on list", "asset", filters.NewFilter("attackSurface", filters.OperatorIn, "cloud", filters.WithReverseOperands()), true},
+		{"list equality", "asset", filters.NewFilter("attackSurface", filters.OperatorEqual, []string{"external"}), true},
+		{"null value", "asset", filters.NewFilter("dns", filters.OperatorEqual, nil), true},
+		{"logical", "asset", filters.NewFilter("", filters.OperatorAnd, []filters.Filter{
+			filters.NewFilter("status", filters.OperatorStartsWith, "A"),
+			filters.NewFilter("ttl", filters.OperatorLessThan, 10, filters.WithNot()),
+		}), true},
+		{"relationship", "asset", filters.Relationship("port", filters.OperatorEqual, 443, "Port", "target", model.HasPortLabel), true},
+		{"relationship existence", "asset", filters.Relationship("", "", nil, "Risk", "target", model.HasVulnerabilityLabel), true},
+		{"relationship with alias label", "adobject", filters.Relationship("", "", nil, "ADObject", "target", "GenericAll"), true},
+
+		{"unknown type", "nope", filters.NewFilter("dns", filters.OperatorEqual, "x"), false},
+		{"unknown field", "asset", filters.NewFilter("nope", filters.OperatorEqual, "x"), false},
+		{"go name of tagged field", "port", filters.NewFilter("Parent", filters.OperatorIsNull, nil), false},
+		{"unknown field in logical", "asset", filters.NewFilter("", filters.OperatorOr, []filters.Filter{
+			filters.NewFilter("dns", filters.OperatorEqual, "x"),
+			filters.NewFilter("nope", filters.OperatorEqual, "x"),
+		}), false},
+		{"missing field", "asset", filters.NewFilter("", filters.OperatorEqual, "x"), false},
+		{"missing value", "asset", filters.Filter{Field: "dns", Operator: filters.OperatorEqual}, false},
+		{"unsupported operator", "asset", filters.NewFilter("dns", "~", "x"), false},
+		{"comparison on list", "asset", filters.NewFilter("attackSurface", filters.OperatorGreaterThan, "a"), false},
+		{"numeric comparison with string", "port", filters.NewFilter("port", filters.OperatorGreaterThan, "1024"), false},
+		{"comparison with several values", "port", filters.NewFilter("port", filters.OperatorLessThan, []int{1, 2}), false},
+		{"string operator on number", "port", filters.NewFilter("port", filters.OperatorStartsWith, "80"), false},
+		{"wrong value type", "asset", filters.NewFilter("dns", filters.OperatorEqual, 1), false},
+		{"several values on scalar", "asset", filters.NewFilter("dns", filters.OperatorEqual, []string{"a", "b"}), false},
+		{"any in on scalar", "asset", filters.NewFilter("dns", filters.OperatorAnyIn, []string{"a"}), false},
+		{"any starts with on scalar", "asset", filters.NewFilter("dns", filters.OperatorAnyStartsWith, "a"), false},
+		{"in on list", "asset", filters.NewFilter("attackSurface", filters.OperatorIn, []string{"external"}), false},
+		{"reversed in on scalar", "asset", filters.NewFilter("dns", filters.OperatorIn, "a", filters.WithReverseOperands()), false},
+		{"unknown relationship label", "asset", filters.Relationship("port", filters.OperatorEqual, 443, "Port", "target", "HAS_PORTS"), false},
+		{"node label is not a relationship label", "asset", filters.Relationship("port", filters.OperatorEqual, 443, "Port", "target", "Port"), false},
+		{"unknown relationship node label", "asset", filters.Relationship("port", filters.OperatorEqual, 443, "Ports", "target", model.HasPortLabel), false},
+		{"unknown field on related node", "asset", filters.Relationship("dns", filters.OperatorEqual, "x", "Port", "target", model.HasPortLabel), false},
+		{"invalid relationship", "asset", filters.Relationship("port", filters.OperatorEqual, 443, "Port", "out", model.HasPortLabel), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := filters.ValidateFor(tt.typeName, tt.filter)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
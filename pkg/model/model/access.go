@@ -8,6 +8,7 @@ import (
 
 func init() {
 	registry.Registry.MustRegisterModel(&Access{})
+	registry.RegisterEnum(registry.Registry, APIKey, SignInWithGoogle, SSO, UsernamePassword)
 }
 
 type ChariotAccessType string
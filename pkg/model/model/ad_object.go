@@ -80,7 +80,7 @@ type ADObject struct {
 	BaseAsset
 	registry.ModelAlias
 	Label           string   `neo4j:"label" json:"label" desc:"Primary label of the object." example:"ADUser" capmodel:"ADObject"`
-	SecondaryLabels []string `neo4j:"-" json:"labels" desc:"Secondary labels of the object." example:"ADLocalGroup" capmodel:"ADObject"`
+	SecondaryLabels []string `neo4j:"-" json:"labels" desc:"Secondary labels of the object." example:"[\"ADLocalGroup\"]" capmodel:"ADObject"`
 	Domain          string   `neo4j:"domain" json:"domain" desc:"AD domain this object belongs to." example:"example.local" capmodel:"ADObject"`
 	ObjectID        string   `neo4j:"objectid" json:"objectid" desc:"Object identifier." example:"S-1-5-21-123456789-123456789-123456789-1001" capmodel:"ADObject"`
 	SID             string   `neo4j:"sid" json:"sid,omitempty" desc:"Security identifier." example:"S-1-5-21-123456789-123456789-123456789-1001" capmodel:"ADObject"`
@@ -332,8 +332,8 @@ type ADProperties struct {
 	SpoofSIDHistoryBlocked                  string   `neo4j:"spoofsidhistoryblocked" json:"spoofsidhistoryblocked,omitempty" desc:"Whether SID history spoofing is blocked" example:"true" capmodel:"ADObject"`
 	TrustedToAuth                           bool     `neo4j:"trustedtoauth" json:"trustedtoauth,omitempty" desc:"Account is trusted for constrained delegation with protocol transition" example:"false" capmodel:"ADObject"`
 	SAMAccountName                          string   `neo4j:"samaccountname" json:"samaccountname,omitempty" desc:"Pre-Windows 2000 logon name" example:"jsmith" capmodel:"ADObject"`
-	CertificateMappingMethodsRaw            int      `neo4j:"certificatemappingmethodsraw" json:"certificatemappingmethodsraw,omitempty" desc:"Raw certificate mapping methods value" example:"0x1F" capmodel:"ADObject"`
-	CertificateMappingMethods               []string `neo4j:"certificatemappingmethods" json:"certificatemappingmethods,omitempty" desc:"Certificate to account mapping methods" example:"Subject,Issuer,SAN" capmodel:"ADObject"`
+	CertificateMappingMethodsRaw            int      `neo4j:"certificatemappingmethodsraw" json:"certificatemappingmethodsraw,omitempty" desc:"Raw certificate mapping methods value" example:"31" capmodel:"ADObject"`
+	CertificateMappingMethods               []string `neo4j:"certificatemappingmethods" json:"certificatemappingmethods,omitempty" desc:"Certificate to account mapping methods" example:"[\"Subject\", \"Issuer\", \"SAN\"]" capmodel:"ADObject"`
 	StrongCertificateBindingEnforcementRaw  int      `neo4j:"strongcertificatebindingenforcementraw" json:"strongcertificatebindingenforcementraw,omitempty" desc:"Raw strong certificate binding enforcement value" example:"2" capmodel:"ADObject"`
 	StrongCertificateBindingEnforcement     string   `neo4j:"strongcertificatebindingenforcement" json:"strongcertificatebindingenforcement,omitempty" desc:"Level of strong certificate binding enforcement" example:"Full" capmodel:"ADObject"`
 	EKUs                                    []string `neo4j:"ekus" json:"ekus,omitempty" desc:"Extended Key Usage OIDs for certificates" example:"[\"1.3.6.1.5.5.7.3.2\", \"1.3.6.1.5.5.7.3.4\"]" capmodel:"ADObject"`
@@ -399,10 +399,10 @@ type ADProperties struct {
 	ADCSWebEnrollmentHTTP                   string   `neo4j:"adcswebenrollmenthttp" json:"adcswebenrollmenthttp,omitempty" desc:"ADCS web enrollment HTTP endpoint availability" example:"http://ca.contoso.local/certsrv" capmodel:"ADObject"`
 	ADCSWebEnrollmentHTTPS                  string   `neo4j:"adcswebenrollmenthttps" json:"adcswebenrollmenthttps,omitempty" desc:"ADCS web enrollment HTTPS endpoint availability" example:"https://ca.contoso.local/certsrv" capmodel:"ADObject"`
 	ADCSWebEnrollmentHTTPSEPA               string   `neo4j:"adcswebenrollmenthttpsepa" json:"adcswebenrollmenthttpsepa,omitempty" desc:"ADCS web enrollment HTTPS with Extended Protection" example:"https://ca.contoso.local/certsrv" capmodel:"ADObject"`
-	LDAPSigning                             bool     `neo4j:"ldapsigning" json:"ldapsigning,omitempty" desc:"LDAP signing requirement" example:"Required" capmodel:"ADObject"`
+	LDAPSigning                             bool     `neo4j:"ldapsigning" json:"ldapsigning,omitempty" desc:"LDAP signing requirement" example:"true" capmodel:"ADObject"`
 	LDAPAvailable                           bool     `neo4j:"ldapavailable" json:"ldapavailable,omitempty" desc:"Whether LDAP service is available" example:"true" capmodel:"ADObject"`
 	LDAPSAvailable                          bool     `neo4j:"ldapsavailable" json:"ldapsavailable,omitempty" desc:"Whether LDAPS (secure LDAP) is available" example:"true" capmodel:"ADObject"`
-	LDAPSEPA                                bool     `neo4j:"ldapsepa" json:"ldapsepa,omitempty" desc:"LDAPS with Extended Protection for Authentication" example:"Enabled" capmodel:"ADObject"`
+	LDAPSEPA                                bool     `neo4j:"ldapsepa" json:"ldapsepa,omitempty" desc:"LDAPS with Extended Protection for Authentication" example:"true" capmodel:"ADObject"`
 	IsDC                                    bool     `neo4j:"isdc" json:"isdc,omitempty" desc:"Whether computer is a Domain Controller" example:"true" capmodel:"ADObject"`
 	IsReadOnlyDC                            bool     `neo4j:"isreadonlydc" json:"isreadonlydc,omitempty" desc:"Whether computer is a Read-Only Domain Controller" example:"false" capmodel:"ADObject"`
 	HTTPEnrollmentEndpoints                 string   `neo4j:"httpenrollmentendpoints" json:"httpenrollmentendpoints,omitempty" desc:"List of HTTP certificate enrollment endpoints" example:"[\"http://ca1.contoso.local/certsrv\", \"http://ca2.contoso.local/certsrv\"]" capmodel:"ADObject"`
@@ -410,7 +410,7 @@ type ADProperties struct {
 	HasVulnerableEndpoint                   bool     `neo4j:"hasvulnerableendpoint" json:"hasvulnerableendpoint,omitempty" desc:"Whether object has vulnerable enrollment endpoints" example:"true" capmodel:"ADObject"`
 	RequireSecuritySignature                bool     `neo4j:"requiresecuritysignature" json:"requiresecuritysignature,omitempty" desc:"Whether security signature is required" example:"true" capmodel:"ADObject"`
 	EnableSecuritySignature                 bool     `neo4j:"enablesecuritysignature" json:"enablesecuritysignature,omitempty" desc:"Whether security signature is enabled" example:"true" capmodel:"ADObject"`
-	RestrictReceivingNTLMTraffic            bool     `neo4j:"restrictreceivingntmltraffic" json:"restrictreceivingntmltraffic,omitempty" desc:"Restriction policy for receiving NTLM traffic" example:"DenyAll" capmodel:"ADObject"`
+	RestrictReceivingNTLMTraffic            bool     `neo4j:"restrictreceivingntmltraffic" json:"restrictreceivingntmltraffic,omitempty" desc:"Restriction policy for receiving NTLM traffic" example:"true" capmodel:"ADObject"`
 	NTLMMinServerSec                        int      `neo4j:"ntlmminserversec" json:"ntlmminserversec,omitempty" desc:"Minimum security level for NTLM SSP server" example:"537395200" capmodel:"ADObject"`
 	NTLMMinClientSec                        int      `neo4j:"ntlmminclientsec" json:"ntlmminclientsec,omitempty" desc:"Minimum security level for NTLM SSP client" example:"537395200" capmodel:"ADObject"`
 	LMCompatibilityLevel                    string   `neo4j:"lmcompatibilitylevel" json:"lmcompatibilitylevel,omitempty" desc:"LAN Manager authentication compatibility level" example:"5" capmodel:"ADObject"`
@@ -420,7 +420,7 @@ type ADProperties struct {
 	GroupScope                              string   `neo4j:"groupscope" json:"groupscope,omitempty" desc:"Scope of the AD group" example:"Global" capmodel:"ADObject"`
 	NetBIOS                                 string   `neo4j:"netbios" json:"netbios,omitempty" desc:"NetBIOS name of the domain" example:"CONTOSO" capmodel:"ADObject"`
 	AdminSDHolderProtected                  string   `neo4j:"adminsdholderprotected" json:"adminsdholderprotected,omitempty" desc:"Whether object is protected by AdminSDHolder process" example:"true" capmodel:"ADObject"`
-	ServicePrincipalNames                   []string `neo4j:"serviceprincipalnames" json:"serviceprincipalnames,omitempty" desc:"The service principal name(s) associated with this account" example:"WSMAN/database" capmodel:"ADObject"`
+	ServicePrincipalNames                   []string `neo4j:"serviceprincipalnames" json:"serviceprincipalnames,omitempty" desc:"The service principal name(s) associated with this account" example:"[\"WSMAN/database\"]" capmodel:"ADObject"`
 	OperatingSystem                         string   `neo4j:"operatingsystem" json:"operatingsystem,omitempty" desc:"The operating system associated with this computer" example:"Windows Server 2019 SE" capmodel:"ADObject"`
 }
 
@@ -17,8 +17,8 @@ func init() {
 type AegisManagementTask struct {
 	registry.BaseModel
 	baseTableModel
-	Username                  string            `dynamodbav:"username" json:"username" desc:"Username who initiated the Aegis management task." example:"user@example.com"`
-	Key                       string            `dynamodbav:"key" json:"key" desc:"Unique key for the Aegis management task." example:"#aegis-mgmt#user@example.com#tunnel_management#2024-01-15T10:30:00Z"`
+	Username                  string            `dynamodbav:"username" table:"pk" json:"username" desc:"Username who initiated the Aegis management task." example:"user@example.com"`
+	Key                       string            `dynamodbav:"key" table:"sk" json:"key" desc:"Unique key for the Aegis management task." example:"#aegis-mgmt#user@example.com#tunnel_management#2024-01-15T10:30:00Z"`
 	AegisManagementCapability string            `dynamodbav:"aegisManagementCapability" json:"aegisManagementCapability" desc:"Name of the Aegis management capability." example:"tunnel_management"`
 	AegisClientID             string            `dynamodbav:"aegisClientId" json:"aegisClientId" desc:"Aegis client ID target for the management operation." example:"C.12345abcdef"`
 	AegisAgentID              string            `dynamodbav:"aegisAgentId,omitempty" json:"aegisAgentId,omitempty" desc:"Specific Aegis agent ID if targeting single agent." example:"agent-001"`
@@ -118,8 +118,7 @@ func (amt *AegisManagementTask) GetHooks() []registry.Hook {
 		{
 			Call: func() error {
 				if amt.Key == "" && amt.Username != "" && amt.AegisManagementCapability != "" {
-					taskID := uuid.New().String()
-					amt.Key = fmt.Sprintf("#aegis-mgmt#%s#%s#%s", amt.Username, amt.AegisManagementCapability, taskID)
+					amt.Key = AegisManagementTaskSortKey(amt.Username, amt.AegisManagementCapability, uuid.New().String())
 				}
 				return nil
 			},
@@ -127,6 +126,11 @@ func (amt *AegisManagementTask) GetHooks() []registry.Hook {
 	}
 }
 
+// AegisManagementTaskSortKey returns the sort key of the task username started with capability
+func AegisManagementTaskSortKey(username, capability, taskID string) string {
+	return fmt.Sprintf("#aegis-mgmt#%s#%s#%s", username, capability, taskID)
+}
+
 // GetDescription returns a description for the AegisManagementTask model
 func (amt *AegisManagementTask) GetDescription() string {
 	return "Represents an Aegis infrastructure management task, including its status, parameters, and results."
@@ -22,7 +22,7 @@ type Attribute struct {
 	Capability   string            `neo4j:"capability" json:"capability,omitempty" desc:"Capability that discovered this attribute." example:"portscan"`
 	TTL          int64             `neo4j:"ttl" json:"ttl" desc:"Time-to-live for the attribute record (Unix timestamp)." example:"1706353200"`
 	Metadata     map[string]string `neo4j:"metadata" json:"metadata,omitempty" desc:"Additional metadata associated with the attribute." example:"{\"tool\": \"masscan\"}"`
-	Parent       GraphModelWrapper `neo4j:"-" json:"parent" desc:"Attribute parent."`
+	Parent       GraphModelWrapper `neo4j:"-" json:"parent" desc:"Attribute parent." example:"{\"type\": \"asset\", \"model\": {\"key\": \"#asset#example.com#10.0.0.1\", \"dns\": \"example.com\", \"name\": \"10.0.0.1\"}}"`
 }
 
 const AttributeLabel = "Attribute"
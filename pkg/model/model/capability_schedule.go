@@ -10,6 +10,7 @@ import (
 
 func init() {
 	registry.Registry.MustRegisterModel(&CapabilitySchedule{}, "capability_schedule")
+	registry.RegisterEnum(registry.Registry, ScheduleStatusActive, ScheduleStatusPaused, ScheduleStatusExpired)
 }
 
 // WeeklySchedule defines execution times for each day of the week
@@ -45,9 +46,9 @@ type CapabilitySchedule struct {
 
 	ScheduleID string `json:"scheduleId" dynamodbav:"schedule_id" desc:"Unique identifier for this schedule"`
 
-	Key string `json:"key" dynamodbav:"key" desc:"DynamoDB partition key"`
+	Key string `json:"key" dynamodbav:"key" table:"sk" desc:"DynamoDB partition key"`
 
-	Username string `json:"username" dynamodbav:"username" desc:"Account owner email"`
+	Username string `json:"username" dynamodbav:"username" table:"pk" desc:"Account owner email"`
 
 	CapabilityName string `json:"capabilityName" dynamodbav:"capability_name" desc:"Capability name to execute"`
 
@@ -84,6 +85,11 @@ func (s *CapabilitySchedule) GetKey() string {
 	return s.Key
 }
 
+// CapabilityScheduleSortKey returns the sort key of the schedule with the given ID
+func CapabilityScheduleSortKey(scheduleID string) string {
+	return fmt.Sprintf("#capability_schedule#%s", scheduleID)
+}
+
 // NewCapabilitySchedule creates a new CapabilitySchedule with default values
 // Config should include all capability parameters including credentials
 func NewCapabilitySchedule(
@@ -98,7 +104,7 @@ func NewCapabilitySchedule(
 
 	schedule := &CapabilitySchedule{
 		ScheduleID:     scheduleID,
-		Key:            fmt.Sprintf("#capability_schedule#%s", scheduleID),
+		Key:            CapabilityScheduleSortKey(scheduleID),
 		Username:       username,
 		CapabilityName: capabilityName,
 		ClientID:       clientID,
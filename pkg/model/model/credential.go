@@ -184,4 +184,56 @@ func (c *Credential) SetName(name string) {
 
 func init() {
 	registry.Registry.MustRegisterModel(&Credential{})
+	registry.RegisterEnum(registry.Registry, CategoryInternal, CategoryIntegration, CategoryAdHoc)
+	registry.RegisterEnum(registry.Registry,
+		StaticCredential,
+		TokenCredential,
+		AWSCredential,
+		GCloudCredential,
+		AzureCredential,
+		AzureDevOpsCredential,
+		SSHKeyCredential,
+		JSONCredential,
+		AegisConfigCredential,
+		ActiveDirectoryCredential,
+		BurpSuiteAuthenticationCredential,
+		ApolloCredential,
+		AgentCredential,
+		MailgunCredentialGitHubPhishing,
+		AxoniousCredential,
+		BitbucketCredential,
+		BuiltWithCredential,
+		CloudflareCredential,
+		FastlyCredential,
+		FeaturebaseCredential,
+		DigitalOceanCredential,
+		GithubCredential,
+		GitlabCredential,
+		InsightVMCredential,
+		MicrosoftDefenderCredential,
+		CrowdStrikeFalconCredential,
+		ExtrahopCredential,
+		NessusCredential,
+		NS1Credential,
+		BurpSuiteCredential,
+		BurpSuiteInternalCredential,
+		QualysCredential,
+		SailPointCredential,
+		ShodanCredential,
+		TenableVMCredential,
+		WizCredential,
+		WhoxyCredential,
+		XpanseCredential,
+		InteractSHCredential,
+		RedTeamGcpCredential,
+		JenkinsCredential,
+		JFrogCredential,
+		CircleCICredential,
+		ConstantineCredential,
+		SnykCodeCredential,
+		LegacyCloudCredential,
+	)
+	registry.RegisterEnum(registry.Registry, CredentialFormatEnv, CredentialFormatFile, CredentialFormatToken, CredentialFormatAPIAuth)
+	registry.RegisterEnum(registry.Registry, CredentialLifecycleStatic, CredentialLifecycleTemporary)
+	registry.RegisterEnum(registry.Registry, CredentialOperationGet, CredentialOperationAdd, CredentialOperationDelete)
 }
@@ -17,6 +17,13 @@ func init() {
 	// If that ever happens again, this will have to be redone as a separate
 	// composite model
 	gob.Register([]any{})
+
+	registry.RegisterInterface[GraphModel](registry.Registry)
+	registry.RegisterInterface[Target](registry.Registry)
+	registry.RegisterInterface[Assetlike](registry.Registry)
+	registry.RegisterInterface[Seedable](registry.Registry)
+	registry.RegisterInterface[Hydratable](registry.Registry)
+	registry.RegisterInterface[TableModel](registry.Registry)
 }
 
 type Notification interface {
@@ -11,6 +11,10 @@ func NewGraphModelWrapper(model GraphModel) GraphModelWrapper {
 	return GraphModelWrapper{Type: registry.Name(model), Model: model}
 }
 
+func (t *GraphModelWrapper) Bind(r *registry.TypeRegistry) {
+	(*registry.Wrapper[GraphModel])(t).Bind(r)
+}
+
 func (t *GraphModelWrapper) UnmarshalJSON(data []byte) error {
 	return (*registry.Wrapper[GraphModel])(t).UnmarshalJSON(data)
 }
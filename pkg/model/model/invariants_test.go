@@ -0,0 +1,503 @@
+package model
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/praetorian-inc/tabularium/pkg/testutils"
+	"github.com/stretchr/testify/require"
+)
+
+// The tests in this file check the invariants of Merge and Visit against randomly generated pairs
+// of models. On failure, testing/quick reports the generated states that broke the invariant.
+
+var invariantConfig = &quick.Config{MaxCount: 100}
+
+// baseState is a randomly generated set of the BaseAsset fields that Merge and Visit read
+type baseState struct {
+	Status        string
+	Source        string
+	Origin        string
+	Comment       string
+	TTL           int64
+	Secret        string
+	Tags          []string
+	Origins       []string
+	AttackSurface []string
+	Capability    []string
+	Country       string
+	City          string
+	History       int
+	Remove        *int
+}
+
+func (baseState) Generate(r *rand.Rand, _ int) reflect.Value {
+	state := baseState{
+		Status:        pick(r, "", Active, ActiveHigh, ActiveLow, Pending, Frozen, Deleted),
+		Source:        pick(r, "", SeedSource, AccountSource, SelfSource, ProvidedSource),
+		Origin:        pick(r, "", "amazon", "whois"),
+		Comment:       pick(r, "", "", "checked by hand"),
+		TTL:           int64(pick(r, 0, 0, 1700000000, 1800000000)),
+		Secret:        pick(r, "", "#secret#1"),
+		Tags:          subset(r, "prod", "web", "legacy"),
+		Origins:       subset(r, "amazon", "ipv4", "whois"),
+		AttackSurface: subset(r, "internal", "external", "cloud"),
+		Capability:    subset(r, "portscan", "nuclei"),
+		Country:       pick(r, "", "US", "DE"),
+		City:          pick(r, "", "Austin"),
+		History:       r.Intn(3),
+	}
+	if r.Intn(10) == 0 {
+		remove := r.Intn(3)
+		state.Remove = &remove
+	}
+	return reflect.ValueOf(state)
+}
+
+func (s baseState) apply(base *BaseAsset) {
+	base.Status = s.Status
+	base.Source = s.Source
+	base.Origin = s.Origin
+	base.Comment = s.Comment
+	base.TTL = s.TTL
+	base.Secret = nil
+	if s.Secret != "" {
+		base.Secret = &s.Secret
+	}
+	base.Tags.Tags = s.Tags
+	base.Origins = s.Origins
+	base.AttackSurface = s.AttackSurface
+	base.Capability = s.Capability
+	base.Country = s.Country
+	base.City = s.City
+	base.History.History = nil
+	for i := range s.History {
+		base.History.History = append(base.History.History, HistoryRecord{To: Active, By: "user@example.com", Comment: string(rune('a' + i))})
+	}
+	base.History.Remove = s.Remove
+}
+
+// riskState is a randomly generated set of the Risk fields that Merge and Visit read
+type riskState struct {
+	Status          string
+	Source          string
+	Comment         string
+	TTL             int64
+	Title           string
+	ProofSufficient *bool
+	Tags            []string
+	Origins         []string
+	History         int
+}
+
+func (riskState) Generate(r *rand.Rand, _ int) reflect.Value {
+	state := riskState{
+		Status:  pick(r, "", TriageHigh, TriageLow, OpenHigh, OpenLow, AcceptedHigh, RemediatedHigh, RemediatedLow, DeletedHighOther),
+		Source:  pick(r, "", ProvidedSource, "user@example.com"),
+		Comment: pick(r, "", "", "needs review"),
+		TTL:     int64(pick(r, 0, 1700000000, 1800000000)),
+		Title:   pick(r, "", "Exposed admin panel"),
+		Tags:    subset(r, "prod", "web"),
+		Origins: subset(r, "amazon", "whois"),
+		History: r.Intn(3),
+	}
+	if r.Intn(2) == 0 {
+		sufficient := r.Intn(2) == 0
+		state.ProofSufficient = &sufficient
+	}
+	return reflect.ValueOf(state)
+}
+
+func (s riskState) risk() Risk {
+	risk := NewRisk(&Asset{DNS: "example.com", Name: "10.0.0.1"}, "finding", TriageHigh)
+	risk.Status = s.Status
+	risk.Source = s.Source
+	risk.Comment = s.Comment
+	risk.TTL = s.TTL
+	risk.Title = s.Title
+	risk.ProofSufficient = s.ProofSufficient
+	risk.Tags.Tags = s.Tags
+	risk.Origins = s.Origins
+	for i := range s.History {
+		risk.History.History = append(risk.History.History, HistoryRecord{To: OpenHigh, Comment: string(rune('a' + i))})
+	}
+	return risk
+}
+
+// relationshipState is a randomly generated set of the relationship fields that Visit reads
+type relationshipState struct {
+	Visited        string
+	Capability     string
+	AttachmentPath string
+	Actions        []string
+	Enforced       *bool
+}
+
+func (relationshipState) Generate(r *rand.Rand, _ int) reflect.Value {
+	state := relationshipState{
+		Visited:        pick(r, "2023-10-27T10:00:00Z", "2024-01-01T00:00:00Z"),
+		Capability:     pick(r, "", "portscan", "aws-iam"),
+		AttachmentPath: pick(r, "", "proofs/scan.txt"),
+		Actions:        subset(r, "s3:GetObject", "s3:PutObject", "iam:PassRole"),
+	}
+	if r.Intn(2) == 0 {
+		enforced := r.Intn(2) == 0
+		state.Enforced = &enforced
+	}
+	return reflect.ValueOf(state)
+}
+
+func (s relationshipState) apply(relationship GraphRelationship) {
+	base := relationship.Base()
+	base.Visited = s.Visited
+	base.Capability = s.Capability
+	base.AttachmentPath = s.AttachmentPath
+	switch r := relationship.(type) {
+	case *IAMAWSPermission:
+		r.Actions = s.Actions
+	case *ADRelationship:
+		r.Enforced = nil
+		if s.Enforced != nil {
+			enforced := GobSafeBool(*s.Enforced)
+			r.Enforced = &enforced
+		}
+	}
+}
+
+func TestInvariants_Assetlike(t *testing.T) {
+	for _, name := range sortedTypes[Assetlike]() {
+		t.Run(name, func(t *testing.T) {
+			example, err := testutils.ExampleOf(name)
+			require.NoError(t, err)
+			pair := func(current, other baseState) (Assetlike, Assetlike, Assetlike) {
+				a, b := clone(t, name, example).(Assetlike), clone(t, name, example).(Assetlike)
+				current.apply(a.GetBase())
+				other.apply(b.GetBase())
+				return a, clone(t, name, a).(Assetlike), b
+			}
+
+			check(t, "visit is idempotent", func(current, other baseState) bool {
+				a, _, b := pair(current, other)
+				a.Visit(b)
+				once := canonical(t, a)
+				a.Visit(b)
+				return reflect.DeepEqual(once, canonical(t, a))
+			})
+			check(t, "visit zeroes the TTL of permanent sources", func(current, other baseState) bool {
+				a, _, b := pair(current, other)
+				a.Visit(b)
+				permanent := IsPermanentSource(b.GetBase().Source) || b.GetBase().TTL == 0
+				return !permanent || a.GetBase().TTL == 0
+			})
+			check(t, "visit keeps history", func(current, other baseState) bool {
+				a, before, b := pair(current, other)
+				a.Visit(b)
+				return reflect.DeepEqual(before.GetBase().History.History, a.GetBase().History.History)
+			})
+			check(t, "visit accumulates tags and origination", func(current, other baseState) bool {
+				a, before, b := pair(current, other)
+				a.Visit(b)
+				after, old, update := a.GetBase(), before.GetBase(), b.GetBase()
+				return containsAll(after.Tags.Tags, old.Tags.Tags, update.Tags.Tags) &&
+					containsAll(after.Origins, old.Origins, update.Origins) &&
+					containsAll(after.AttackSurface, old.AttackSurface, update.AttackSurface) &&
+					containsAll(after.Capability, old.Capability, update.Capability)
+			})
+			check(t, "visit prefers non-empty metadata", func(current, other baseState) bool {
+				a, before, b := pair(current, other)
+				a.Visit(b)
+				return a.GetBase().Country == coalesce(b.GetBase().Country, before.GetBase().Country) &&
+					a.GetBase().City == coalesce(b.GetBase().City, before.GetBase().City)
+			})
+
+			for _, step := range []string{"merge", "visit"} {
+				check(t, step+" never demotes a permanent source", func(current, other baseState) bool {
+					a, before, b := pair(current, other)
+					if step == "merge" {
+						a.Merge(b)
+					} else {
+						a.Visit(b)
+					}
+					source := a.GetBase().Source
+					if IsPermanentSource(before.GetBase().Source) && !IsPermanentSource(source) {
+						return false
+					}
+					return source == before.GetBase().Source || source == SeedSource
+				})
+			}
+
+			check(t, "merge without a comment is idempotent", func(current, other baseState) bool {
+				a, _, b := pair(current, other)
+				b.GetBase().Comment = ""
+				b.GetBase().History.Remove = nil
+				a.Merge(b)
+				once := canonical(t, a)
+				a.Merge(b)
+				return reflect.DeepEqual(once, canonical(t, a))
+			})
+			check(t, "merge appends at most one history record", func(current, other baseState) bool {
+				a, before, b := pair(current, other)
+				a.Merge(b)
+				old, history := before.GetBase().History.History, a.GetBase().History.History
+				if removes(before, b) {
+					return len(history) <= len(old)
+				}
+				return len(history)-len(old) <= 1 && len(history) >= len(old) && historyPrefix(old, history)
+			})
+			check(t, "merge follows the status of updates", func(current, other baseState) bool {
+				a, before, b := pair(current, other)
+				a.Merge(b)
+				status := b.GetBase().Status
+				if status == "" || removes(before, b) {
+					return a.GetBase().Status == before.GetBase().Status
+				}
+				return a.GetBase().Status == status
+			})
+			check(t, "merge zeroes the TTL of inactive assets", func(current, other baseState) bool {
+				a, _, b := pair(current, other)
+				a.Merge(b)
+				return a.GetBase().IsStatus(Active) || a.GetBase().TTL == 0
+			})
+		})
+	}
+}
+
+func TestInvariants_Risk(t *testing.T) {
+	check(t, "remediated risks reopen on visit", func(current, other riskState) bool {
+		r, n, before := current.risk(), other.risk(), current.risk()
+		r.Visit(n)
+		return !before.Is(Remediated) || n.Is(Remediated) || r.Is(Open)
+	})
+	check(t, "visit is idempotent", func(current, other riskState) bool {
+		r, n := current.risk(), other.risk()
+		r.Visit(n)
+		once := canonical(t, &r)
+		r.Visit(n)
+		return reflect.DeepEqual(once, canonical(t, &r))
+	})
+	check(t, "visit only refreshes the TTL of triage risks", func(current, other riskState) bool {
+		r, n := current.risk(), other.risk()
+		r.Visit(n)
+		before := current.risk()
+		return r.TTL == before.TTL || r.TTL == 0 || (before.Is(Triage) && r.TTL == n.TTL)
+	})
+	check(t, "merge appends at most one history record", func(current, other riskState) bool {
+		r, n := current.risk(), other.risk()
+		r.Merge(n)
+		old := current.risk().History.History
+		return len(r.History.History)-len(old) <= 1 && historyPrefix(old, r.History.History)
+	})
+	check(t, "merge zeroes the TTL outside triage", func(current, other riskState) bool {
+		r, n := current.risk(), other.risk()
+		r.Merge(n)
+		return r.Is(Triage) || r.TTL == 0
+	})
+	check(t, "merge replaces tags and titles it is given", func(current, other riskState) bool {
+		r, n := current.risk(), other.risk()
+		r.Merge(n)
+		return reflect.DeepEqual(r.Tags.Tags, coalesceSlice(n.Tags.Tags, current.risk().Tags.Tags)) &&
+			r.Title == coalesce(n.Title, current.Title)
+	})
+}
+
+func TestInvariants_Webpage(t *testing.T) {
+	webpage := func(s baseState) Webpage {
+		w := NewWebpageFromString("https://example.com/login", nil)
+		w.TTL = s.TTL
+		w.Screenshot = s.Secret
+		w.Status = s.Status
+		return w
+	}
+	check(t, "visit keeps a zero TTL", func(current, other baseState) bool {
+		w, o := webpage(current), webpage(other)
+		require.NoError(t, w.Visit(o))
+		return current.TTL != 0 || w.TTL == 0
+	})
+	check(t, "visit is idempotent", func(current, other baseState) bool {
+		w, o := webpage(current), webpage(other)
+		require.NoError(t, w.Visit(o))
+		once := canonical(t, &w)
+		require.NoError(t, w.Visit(o))
+		w.Visited, once.(map[string]any)["visited"] = "", ""
+		return reflect.DeepEqual(once, canonical(t, &w))
+	})
+}
+
+func TestInvariants_Relationships(t *testing.T) {
+	for _, name := range sortedTypes[GraphRelationship]() {
+		t.Run(name, func(t *testing.T) {
+			example, err := testutils.ExampleOf(name)
+			require.NoError(t, err)
+			pair := func(current, other relationshipState) (GraphRelationship, GraphRelationship, GraphRelationship) {
+				a, b := clone(t, name, example).(GraphRelationship), clone(t, name, example).(GraphRelationship)
+				current.apply(a)
+				other.apply(b)
+				return a, clone(t, name, a).(GraphRelationship), b
+			}
+
+			check(t, "visit is idempotent", func(current, other relationshipState) bool {
+				a, _, b := pair(current, other)
+				a.Visit(b)
+				once := canonical(t, a)
+				a.Visit(b)
+				return reflect.DeepEqual(once, canonical(t, a))
+			})
+			check(t, "visit takes the visited time of the other", func(current, other relationshipState) bool {
+				a, _, b := pair(current, other)
+				a.Visit(b)
+				return a.Base().Visited == b.Base().Visited
+			})
+			check(t, "visit prefers a non-empty capability", func(current, other relationshipState) bool {
+				a, before, b := pair(current, other)
+				a.Visit(b)
+				return a.Base().Capability == coalesce(b.Base().Capability, before.Base().Capability)
+			})
+			if _, ok := example.(*IAMAWSPermission); ok {
+				check(t, "visit accumulates sorted actions", func(current, other relationshipState) bool {
+					a, before, b := pair(current, other)
+					a.Visit(b)
+					actions := a.(*IAMAWSPermission).Actions
+					return sort.StringsAreSorted(actions) &&
+						containsAll(actions, before.(*IAMAWSPermission).Actions, b.(*IAMAWSPermission).Actions) &&
+						len(actions) == len(slices.Compact(slices.Clone(actions)))
+				})
+			}
+		})
+	}
+}
+
+func check(t *testing.T, invariant string, property any) {
+	t.Helper()
+	t.Run(invariant, func(t *testing.T) {
+		if err := quick.Check(property, invariantConfig); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func sortedTypes[T registry.Model]() []string {
+	seen := map[reflect.Type]bool{}
+	var names []string
+	for _, name := range registry.GetTypes[T](registry.Registry) {
+		tipe, _ := registry.Registry.GetType(name)
+		if !seen[tipe] {
+			seen[tipe] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// clone copies m through JSON into a new instance of the model registered as name
+func clone(t *testing.T, name string, m registry.Model) registry.Model {
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	c, ok := registry.Registry.MakeType(name)
+	require.True(t, ok)
+	require.NoError(t, json.Unmarshal(data, c))
+	return c
+}
+
+// canonical returns the JSON of m with its string lists sorted, since some visits collect them
+// from sets
+func canonical(t *testing.T, m any) any {
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	var value any
+	require.NoError(t, json.Unmarshal(data, &value))
+	return sortStrings(value)
+}
+
+func sortStrings(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = sortStrings(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = sortStrings(item)
+		}
+		if slices.IndexFunc(v, func(item any) bool { _, ok := item.(string); return !ok }) < 0 {
+			sort.Slice(v, func(i, j int) bool { return v[i].(string) < v[j].(string) })
+		}
+	}
+	return value
+}
+
+func pick[T any](r *rand.Rand, options ...T) T {
+	return options[r.Intn(len(options))]
+}
+
+// subset returns nil or a random subset of options
+func subset(r *rand.Rand, options ...string) []string {
+	if r.Intn(3) == 0 {
+		return nil
+	}
+	out := []string{}
+	for _, option := range options {
+		if r.Intn(2) == 0 {
+			out = append(out, option)
+		}
+	}
+	return out
+}
+
+func containsAll(values []string, wanted ...[]string) bool {
+	for _, list := range wanted {
+		for _, w := range list {
+			if !slices.Contains(values, w) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func coalesce(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func coalesceSlice(values ...[]string) []string {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// removes reports whether merging update removes a history record of current instead of updating
+// it. Seed promotions record the promotion instead.
+func removes(current, update Assetlike) bool {
+	if IsLabelSettable(current) && IsSeedPromotion(current.GetBase(), update.GetBase()) {
+		return false
+	}
+	remove := update.GetBase().History.Remove
+	return remove != nil && *remove < len(current.GetBase().History.History)
+}
+
+// historyPrefix reports whether history starts with the records of old, ignoring their timestamps
+func historyPrefix(old, history []HistoryRecord) bool {
+	if len(history) < len(old) {
+		return false
+	}
+	for i := range old {
+		if old[i].From != history[i].From || old[i].To != history[i].To || old[i].Comment != history[i].Comment {
+			return false
+		}
+	}
+	return true
+}
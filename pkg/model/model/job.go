@@ -14,8 +14,8 @@ import (
 type Job struct {
 	registry.BaseModel
 	baseTableModel
-	Username string `dynamodbav:"username" json:"username" desc:"Username who initiated or owns the job." example:"user@example.com"`
-	Key      string `dynamodbav:"key" json:"key" desc:"Unique key for the job." example:"#job#example.com#asset#portscan"`
+	Username string `dynamodbav:"username" table:"pk" json:"username" desc:"Username who initiated or owns the job." example:"user@example.com"`
+	Key      string `dynamodbav:"key" table:"sk" json:"key" desc:"Unique key for the job." example:"#job#example.com#asset#portscan"`
 	// Attributes
 	DNS                   string            `dynamodbav:"dns" json:"dns" desc:"Primary DNS associated with the job's target." example:"example.com"`
 	Source                string            `dynamodbav:"source" json:"source" desc:"The source or capability that generated this job, ordered by updated" example:"portscan#2023-10-27T10:05:00Z"`
@@ -155,13 +155,9 @@ func (job *Job) GetHooks() []registry.Hook {
 		{
 			Call: func() error {
 				if job.Target.Model != nil {
-					group := job.Target.Model.Group()
-					identifier := job.Target.Model.Identifier()
-					template := fmt.Sprintf("#job#%%s#%s#%s", identifier, job.GetCapability())
-					if len(template) <= 1024 {
-						shortenedDNS := group[:min(1024-len(template), len(group))]
-						job.DNS = shortenedDNS
-						job.Key = fmt.Sprintf(template, shortenedDNS)
+					if key, dns, ok := JobSortKey(job.Target.Model.Group(), job.Target.Model.Identifier(), job.GetCapability()); ok {
+						job.DNS = dns
+						job.Key = key
 					}
 				}
 				job.initializeParameters()
@@ -173,6 +169,18 @@ func (job *Job) GetHooks() []registry.Hook {
 	}
 }
 
+// JobSortKey returns the sort key of the job running capability against the target with the given
+// group and identifier, and the group as stored in it. The group is truncated to keep the key within
+// DynamoDB's 1024 bytes; ok is false when the rest of the key does not fit.
+func JobSortKey(group, identifier, capability string) (key, dns string, ok bool) {
+	template := fmt.Sprintf("#job#%%s#%s#%s", identifier, capability)
+	if len(template) > 1024 {
+		return "", "", false
+	}
+	dns = group[:min(1024-len(template), len(group))]
+	return fmt.Sprintf(template, dns), dns, true
+}
+
 func (job *Job) initializeParameters() {
 	if job.Config == nil {
 		job.Config = make(map[string]string)
@@ -262,12 +270,13 @@ func (job *Job) IsTraced() bool {
 
 func NewSystemJob(source, id string) Job {
 	t := NewAsset(id, id) // not needed, but required for target to unmarshal. TODO make this a separate type
+	key, _, _ := JobSortKey(id, "system", source)
 	j := Job{
 		DNS:     id,
 		Created: Now(),
 		Updated: Now(),
 		Queue:   Standard,
-		Key:     fmt.Sprintf("#job#%s#system#%s", id, source),
+		Key:     key,
 		Target:  TargetWrapper{Model: &t},
 		stream:  make(chan []registry.Model),
 	}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// Schema versions of the models with migrations. Bump these when registering a migration.
+const (
+	RiskSchemaVersion    = 1
+	WebpageSchemaVersion = 1
+)
+
+func init() {
+	registry.Registry.MustRegisterMigration("risk", 0, migrateRiskLegacyStatus)
+	registry.Registry.MustRegisterMigration("webpage", 0, migrateWebpageMetadata)
+	registry.Registry.MustRegisterReplacement("iamrelationship", "iamawspermission", replaceIAMRelationship)
+}
+
+// migrateRiskLegacyStatus upgrades the risk status codes that predate the current risk states:
+// the closed state, C, is now remediated, which the legacy status labels still display as Closed,
+// and deleted risks now record why they were deleted, Other for risks deleted before the reasons.
+func migrateRiskLegacyStatus(props map[string]any) error {
+	key, value, _ := property(props, "status")
+	status, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	switch {
+	case strings.HasPrefix(status, "C"):
+		props[key] = Remediated + status[1:]
+	case strings.HasPrefix(status, Deleted) && len(status) == 2:
+		props[key] = status + "O"
+	}
+	return nil
+}
+
+// webpageMetadataFields are the deprecated metadata entries that now have fields of their own
+var webpageMetadataFields = []string{"details_filepath", "screenshot", "resources"}
+
+// migrateWebpageMetadata moves entries of the deprecated metadata into their fields, unless the
+// fields are already set
+func migrateWebpageMetadata(props map[string]any) error {
+	_, value, _ := property(props, "metadata")
+	metadata, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	for _, field := range webpageMetadataFields {
+		moved, ok := metadata[field]
+		if !ok {
+			continue
+		}
+		key, existing, found := property(props, field)
+		if !found {
+			key = field
+		}
+		if existing == nil || existing == "" {
+			props[key] = moved
+		}
+		delete(metadata, field)
+	}
+	return nil
+}
+
+// replaceIAMRelationship converts a per-action IAMRelationship into an IAMAWSPermission holding
+// its single action
+func replaceIAMRelationship(props map[string]any) error {
+	key, value, ok := property(props, "permission")
+	if !ok {
+		return nil
+	}
+	delete(props, key)
+	permission, _ := value.(string)
+	if permission == "" {
+		return nil
+	}
+	props["actions"] = []any{permission}
+
+	// relationship keys embed the label between the source and target keys
+	if key, value, ok := property(props, "key"); ok {
+		if k, ok := value.(string); ok {
+			props[key] = strings.Replace(k, "#"+permission+"#", "#"+IAMAWSPermissionLabel+"#", 1)
+		}
+	}
+	return nil
+}
+
+// property returns the key and value of the named property. Like encoding/json, it falls back to a
+// case-insensitive match, since DynamoDB items are keyed by field name unless tagged otherwise.
+func property(props map[string]any, name string) (string, any, bool) {
+	if value, ok := props[name]; ok {
+		return name, value, true
+	}
+	for key, value := range props {
+		if strings.EqualFold(key, name) {
+			return key, value, true
+		}
+	}
+	return "", nil, false
+}
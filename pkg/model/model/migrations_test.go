@@ -0,0 +1,114 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaVersions(t *testing.T) {
+	assert.Equal(t, RiskSchemaVersion, registry.Registry.SchemaVersion("risk"))
+	assert.Equal(t, WebpageSchemaVersion, registry.Registry.SchemaVersion("webpage"))
+
+	risk := NewRisk(&Asset{DNS: "example.com", Name: "10.0.0.1"}, "finding", TriageHigh)
+	assert.Equal(t, RiskSchemaVersion, risk.SchemaVersion)
+	webpage := NewWebpageFromString("https://example.com/login", nil)
+	assert.Equal(t, WebpageSchemaVersion, webpage.SchemaVersion)
+}
+
+func TestMigrations_Risk(t *testing.T) {
+	legacy := map[string]string{
+		"C":  Remediated,
+		"CE": RemediatedExposure,
+		"CI": RemediatedInfo,
+		"CL": RemediatedLow,
+		"CM": RemediatedMedium,
+		"CH": RemediatedHigh,
+		"CC": RemediatedCritical,
+		"DE": DeletedExposureOther,
+		"DI": DeletedInfoOther,
+		"DL": DeletedLowOther,
+		"DM": DeletedMediumOther,
+		"DH": DeletedHighOther,
+		"DC": DeletedCriticalOther,
+	}
+	current := []string{Triage, TriageHigh, OpenCritical, AcceptedLow, RemediatedMedium, Deleted, DeletedInfoFalsePositive, DeletedHighDuplicate}
+	for _, status := range current {
+		legacy[status] = status
+	}
+
+	for status, expected := range legacy {
+		t.Run(status, func(t *testing.T) {
+			fixture := fmt.Sprintf(`{"dns": "example.com", "name": "finding", "status": %q}`, status)
+			var risk Risk
+			require.NoError(t, registry.UnmarshalModel([]byte(fixture), &risk))
+			assert.Equal(t, expected, risk.Status)
+			assert.Equal(t, RiskSchemaVersion, risk.SchemaVersion)
+			assert.Equal(t, "#risk#example.com#finding", risk.Key)
+		})
+	}
+
+	t.Run("current version", func(t *testing.T) {
+		var risk Risk
+		require.NoError(t, registry.UnmarshalModel([]byte(`{"dns": "example.com", "name": "finding", "status": "CH", "schema_version": 1}`), &risk))
+		assert.Equal(t, "CH", risk.Status)
+	})
+}
+
+func TestMigrations_Webpage(t *testing.T) {
+	fixture := `{
+		"url": "https://example.com/login",
+		"screenshot": "",
+		"resources": "webpage/example.com/443/current.zip",
+		"metadata": {
+			"screenshot": "webpage/example.com/443/screenshot.jpeg",
+			"resources": "webpage/example.com/443/legacy.zip",
+			"details_filepath": "webpage/example.com/443/details.json",
+			"tool_source": "proxy"
+		}
+	}`
+
+	var webpage Webpage
+	require.NoError(t, registry.UnmarshalModel([]byte(fixture), &webpage))
+	assert.Equal(t, "webpage/example.com/443/screenshot.jpeg", webpage.Screenshot)
+	assert.Equal(t, "webpage/example.com/443/current.zip", webpage.Resources)
+	assert.Equal(t, "webpage/example.com/443/details.json", webpage.DetailsFilepath)
+	assert.Equal(t, map[string]any{"tool_source": "proxy"}, webpage.Metadata)
+	assert.Equal(t, WebpageSchemaVersion, webpage.SchemaVersion)
+}
+
+func TestMigrations_IAMRelationship(t *testing.T) {
+	fixture := `{
+		"type": "iamrelationship",
+		"model": {
+			"key": "#awsresource#123456789012#arn:aws:iam::123456789012:role/admin#s3:GetObject#awsresource#123456789012#arn:aws:s3:::bucket",
+			"permission": "s3:GetObject",
+			"capability": "aws-iam"
+		}
+	}`
+	expected := "#awsresource#123456789012#arn:aws:iam::123456789012:role/admin#IAM_AWS_PERMISSION#awsresource#123456789012#arn:aws:s3:::bucket"
+
+	// relationships embed a nil *BaseRelationship until decoded, so they cannot be defaulted
+	wrapper := registry.Wrapper[registry.Model]{SkipDefaulting: true}
+	require.NoError(t, json.Unmarshal([]byte(fixture), &wrapper))
+	assert.Equal(t, "iamawspermission", wrapper.Type)
+	permission, ok := wrapper.Model.(*IAMAWSPermission)
+	require.True(t, ok, "expected *IAMAWSPermission, got %T", wrapper.Model)
+	assert.Equal(t, []string{"s3:GetObject"}, permission.Actions)
+	assert.Equal(t, expected, permission.Key)
+	assert.Equal(t, "aws-iam", permission.Capability)
+
+	// DynamoDB items are upgraded too
+	var props map[string]any
+	require.NoError(t, json.Unmarshal([]byte(fixture), &props))
+	av, err := attributevalue.Marshal(props)
+	require.NoError(t, err)
+	wrapper = registry.Wrapper[registry.Model]{SkipDefaulting: true}
+	require.NoError(t, wrapper.UnmarshalDynamoDBAttributeValue(av))
+	assert.Equal(t, []string{"s3:GetObject"}, wrapper.Model.(*IAMAWSPermission).Actions)
+}
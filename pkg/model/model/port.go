@@ -28,7 +28,7 @@ type Port struct {
 	Visited    string            `neo4j:"visited" json:"visited" desc:"Timestamp when the port was last visited or confirmed (RFC3339)." example:"2023-10-27T11:00:00Z"`
 	Capability string            `neo4j:"capability" json:"capability,omitempty" desc:"Capability that discovered this port." example:"portscan"`
 	TTL        int64             `neo4j:"ttl" json:"ttl" desc:"Time-to-live for the port record (Unix timestamp)." example:"1706353200"`
-	Parent     GraphModelWrapper `neo4j:"-" json:"parent" desc:"Port parent asset." capmodel:"Port=parent(Asset)"`
+	Parent     GraphModelWrapper `neo4j:"-" json:"parent" desc:"Port parent asset." example:"{\"type\": \"asset\", \"model\": {\"key\": \"#asset#example.com#10.0.0.1\", \"dns\": \"example.com\", \"name\": \"10.0.0.1\"}}" capmodel:"Port=parent(Asset)"`
 	Tags
 }
 
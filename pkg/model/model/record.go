@@ -15,8 +15,8 @@ func init() {
 type JobRecord struct {
 	registry.BaseModel
 	baseTableModel
-	Username   string `dynamodbav:"username" json:"username" desc:"Chariot username associated with the account." example:"user@example.com"`
-	Key        string `dynamodbav:"key" json:"key" desc:"Unique key for the job." example:"#job#example.com#asset#portscan"`
+	Username   string `dynamodbav:"username" table:"pk" json:"username" desc:"Chariot username associated with the account." example:"user@example.com"`
+	Key        string `dynamodbav:"key" table:"sk" json:"key" desc:"Unique key for the job." example:"#job#example.com#asset#portscan"`
 	JobKey     string `dynamodbav:"jobKey" json:"jobKey" desc:"Key of the job that was recorded" example:"#job#example.com#asset#portscan"`
 	RecordTime string `dynamodbav:"recordTime" json:"recordTime" desc:"Timestamp when the recorded job was last updated (RFC3339)." example:"2023-10-27T10:00:00Z"`
 	Full       bool   `dynamodbav:"full" json:"full" desc:"Whether the record is full." example:"true"`
@@ -35,7 +35,7 @@ func (r *JobRecord) GetHooks() []registry.Hook {
 	return []registry.Hook{
 		{
 			Call: func() error {
-				r.Key = fmt.Sprintf("%s%s", RecordSearchKeyPrefix(Job{Key: r.JobKey}), r.RecordTime)
+				r.Key = JobRecordSortKey(r.JobKey, r.RecordTime)
 				return nil
 			},
 		},
@@ -55,6 +55,11 @@ func NewRecord(job Job) JobRecord {
 	return record
 }
 
+// JobRecordSortKey returns the sort key of the record of the job with jobKey, updated at recordTime
+func JobRecordSortKey(jobKey, recordTime string) string {
+	return fmt.Sprintf("%s%s", RecordSearchKeyPrefix(Job{Key: jobKey}), recordTime)
+}
+
 func RecordSearchKeyPrefix(job Job) string {
 	return fmt.Sprintf("#jobrecord%s#", job.Key)
 }
@@ -36,6 +36,7 @@ type Risk struct {
 	Target            Target `neo4j:"-" json:"-" capmodel:"Risk=target(Asset)"` // Internal use, not in schema
 	ProofUniquenessID string `neo4j:"-" json:"proofUniquenessID"`               // Explicit dedup ID for proof path (Tier 1)
 	SDKProof          []byte `neo4j:"-" json:"-" capmodel:"Risk=proof"`         // Proof bytes; only used by capmodelgen to generate the SDK field
+	SchemaVersion     int    `neo4j:"-" json:"schema_version,omitempty" dynamodbav:"schema_version,omitempty" desc:"Schema version the risk was written with." example:"1"`
 	History
 	MLProperties
 	Tags
@@ -354,6 +355,7 @@ func (r *Risk) Defaulted() {
 	r.Visited = Now()
 	r.TTL = Future(30 * 24)
 	r.GUID = uuid.New().String()
+	r.SchemaVersion = RiskSchemaVersion
 }
 
 func (r *Risk) GetHooks() []registry.Hook {
@@ -1,5 +1,11 @@
 package model
 
+import "github.com/praetorian-inc/tabularium/pkg/registry"
+
+func init() {
+	registry.RegisterEnum(registry.Registry, RoleAdmin, RoleAnalyst, RoleReadOnly)
+}
+
 type Role string
 
 const (
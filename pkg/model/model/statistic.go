@@ -34,8 +34,8 @@ func (s *Statistic) GetDescription() string {
 type Statistic struct {
 	registry.BaseModel
 	baseTableModel
-	Username string `dynamodbav:"username" json:"username" desc:"Chariot username associated with the statistic." example:"user@example.com"`
-	Key      string `dynamodbav:"key" json:"key" desc:"Unique key for the statistic record." example:"#statistic#asset_count#global#2023-10-27T00:00:00Z#all"`
+	Username string `dynamodbav:"username" table:"pk" json:"username" desc:"Chariot username associated with the statistic." example:"user@example.com"`
+	Key      string `dynamodbav:"key" table:"sk" json:"key" desc:"Unique key for the statistic record." example:"#statistic#asset_count#global#2023-10-27T00:00:00Z#all"`
 	Type     string `dynamodbav:"type" json:"type" desc:"The type or category of the statistic." example:"asset_count"`
 	Name     string `dynamodbav:"name" json:"name" desc:"The specific name or scope of the statistic." example:"global"`
 	Value    string `dynamodbav:"value" json:"value" desc:"A specific value or sub-category for the statistic." example:"all"`
@@ -53,21 +53,20 @@ func (s *Statistic) GetHooks() []registry.Hook {
 	return []registry.Hook{
 		{
 			Call: func() error {
-				tipe := s.Type
-				name := s.Name
-				value := s.Value
-				created := s.Created
-
-				template := fmt.Sprintf("#statistic#%s#%s#%s#%%s", tipe, name, created)
-				shortenedValue := value[:min(1024-len(template), len(value))]
-
-				s.Key = fmt.Sprintf(template, shortenedValue)
+				s.Key = StatisticSortKey(s.Type, s.Name, s.Created, s.Value)
 				return nil
 			},
 		},
 	}
 }
 
+// StatisticSortKey returns the sort key of the statistic of the given type, name and creation time
+// holding value, which is truncated to keep the key within DynamoDB's 1024 bytes
+func StatisticSortKey(tipe, name, created, value string) string {
+	template := fmt.Sprintf("#statistic#%s#%s#%s#%%s", tipe, name, created)
+	return fmt.Sprintf(template, value[:max(0, min(1024-len(template), len(value)))])
+}
+
 func NewStatistic(tipe, name, value, created string) Statistic {
 	s := Statistic{
 		Type:    tipe,
@@ -98,6 +98,10 @@ func (t TargetWrapper) MarshalJSON() ([]byte, error) {
 	return (registry.Wrapper[Target])(t).MarshalJSON()
 }
 
+func (t *TargetWrapper) Bind(r *registry.TypeRegistry) {
+	(*registry.Wrapper[Target])(t).Bind(r)
+}
+
 func (t *TargetWrapper) UnmarshalJSON(data []byte) error {
 	return (*registry.Wrapper[Target])(t).UnmarshalJSON(data)
 }
@@ -20,8 +20,8 @@ const (
 type TraceEvent struct {
 	registry.BaseModel
 	baseTableModel
-	Username     string            `dynamodbav:"username" json:"username" desc:"Account owner for tenant isolation."`
-	Key          string            `dynamodbav:"key" json:"key" desc:"Event key: #event#{trace_id}#{event_id}"`
+	Username     string            `dynamodbav:"username" table:"pk" json:"username" desc:"Account owner for tenant isolation."`
+	Key          string            `dynamodbav:"key" table:"sk" json:"key" desc:"Event key: #event#{trace_id}#{event_id}"`
 	TraceID      string            `dynamodbav:"trace_id" json:"trace_id" desc:"Root trace identifier (UUID)."`
 	SpanID       string            `dynamodbav:"span_id" json:"span_id" desc:"This span's identifier (UUID)."`
 	ParentSpanID string            `dynamodbav:"parent_span_id,omitempty" json:"parent_span_id,omitempty" desc:"Parent span ID."`
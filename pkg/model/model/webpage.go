@@ -82,6 +82,7 @@ type Webpage struct {
 	DetailsFilepath string                `neo4j:"details_filepath" json:"details_filepath" dynamodbav:"details_filepath" desc:"The path to the details file for the webpage." example:"webpage/1234567890/details-1234567890.json"`
 	Screenshot      string                `neo4j:"screenshot" json:"screenshot" desc:"Path to screenshot file" example:"webpage/example.com/443/screenshot.jpeg"`
 	Resources       string                `neo4j:"resources" json:"resources" desc:"Path to network resources zip" example:"webpage/example.com/443/network_resources.zip"`
+	SchemaVersion   int                   `neo4j:"-" json:"schema_version,omitempty" dynamodbav:"schema_version,omitempty" desc:"Schema version the webpage was written with." example:"1"`
 	EndpointFingerprint
 	// S3 / Hydratable fields
 	WebpageDetails
@@ -281,6 +282,7 @@ func (w *Webpage) Defaulted() {
 	w.Visited = Now()
 	w.TTL = Future(30 * 24)
 	w.Metadata = map[string]any{}
+	w.SchemaVersion = WebpageSchemaVersion
 }
 
 func (w *Webpage) GetHooks() []registry.Hook {
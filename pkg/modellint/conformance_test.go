@@ -0,0 +1,16 @@
+package modellint_test
+
+import (
+	"testing"
+
+	_ "github.com/praetorian-inc/tabularium/pkg/capmodel"
+	_ "github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/praetorian-inc/tabularium/pkg/modellint"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// TestConformance holds the registered models to the rules they already meet. Add rules here as
+// the remaining violations reported by cmd/modellint are fixed.
+func TestConformance(t *testing.T) {
+	modellint.AssertConformance(t, registry.Registry, modellint.RuleExampleType, modellint.RuleNeo4jName, modellint.RuleDynamoDB, modellint.RuleCapmodel)
+}
@@ -0,0 +1,328 @@
+// Package modellint checks registered models against the struct tag conventions that schemagen,
+// capmodelgen and the graph layer rely on.
+package modellint
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// Rule names a convention checked by Lint
+type Rule string
+
+const (
+	// RuleDesc requires a desc tag on every serialized field
+	RuleDesc Rule = "desc"
+	// RuleExample requires an example tag on every serialized field
+	RuleExample Rule = "example"
+	// RuleExampleType requires example values to parse into the type of their field
+	RuleExampleType Rule = "example-type"
+	// RuleNeo4jName requires the neo4j and json tags of a field to use the same name
+	RuleNeo4jName Rule = "neo4j-name"
+	// RuleDynamoDB requires a dynamodbav tag on every serialized field of a TableModel
+	RuleDynamoDB Rule = "dynamodbav"
+	// RuleCapmodel requires capmodel tags to reference capmodel types with a registered converter
+	// or extractor
+	RuleCapmodel Rule = "capmodel"
+)
+
+// Rules lists every rule, in the order violations of a field are reported
+var Rules = []Rule{RuleDesc, RuleExample, RuleExampleType, RuleNeo4jName, RuleDynamoDB, RuleCapmodel}
+
+// Violation is a field that breaks a rule. Fields are reported once, against the struct that
+// declares them, even when they are promoted into several models.
+type Violation struct {
+	Rule    Rule           `json:"rule"`
+	Type    string         `json:"type"`
+	Field   string         `json:"field"`
+	Message string         `json:"message"`
+	Pos     token.Position `json:"pos"`
+}
+
+func (v Violation) String() string {
+	location := v.Type + "." + v.Field
+	if v.Pos.IsValid() {
+		location = v.Pos.String() + ": " + location
+	}
+	return fmt.Sprintf("%s: %s (%s)", location, v.Message, v.Rule)
+}
+
+// Lint checks every model registered with r against rules, or against all Rules if none are given.
+// Violations are sorted by position, then by type and field.
+func Lint(r *registry.TypeRegistry, rules ...Rule) []Violation {
+	if len(rules) == 0 {
+		rules = Rules
+	}
+	l := &linter{
+		registry:  r,
+		rules:     rules,
+		seen:      map[reflect.Type]bool{},
+		seenTable: map[reflect.Type]bool{},
+		sources:   map[string]map[string]token.Position{},
+	}
+	l.tableModel, _ = r.GetInterface("TableModel")
+
+	types := map[reflect.Type]bool{}
+	for _, tipe := range r.GetAllTypes() {
+		types[tipe] = true
+	}
+	for tipe := range types {
+		table := l.tableModel != nil && tipe.Implements(l.tableModel)
+		l.lintStruct(tipe.Elem(), table)
+	}
+
+	sort.SliceStable(l.violations, func(i, j int) bool {
+		a, b := l.violations[i], l.violations[j]
+		if a.Pos.Filename != b.Pos.Filename {
+			return a.Pos.Filename < b.Pos.Filename
+		}
+		if a.Pos.Line != b.Pos.Line {
+			return a.Pos.Line < b.Pos.Line
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		return slices.Index(Rules, a.Rule) < slices.Index(Rules, b.Rule)
+	})
+	return l.violations
+}
+
+type linter struct {
+	registry   *registry.TypeRegistry
+	rules      []Rule
+	tableModel reflect.Type
+	seen       map[reflect.Type]bool
+	seenTable  map[reflect.Type]bool
+	// sources holds the position of each struct field declared in a package, by package path
+	// and then by Type.Field
+	sources    map[string]map[string]token.Position
+	violations []Violation
+}
+
+// lintStruct checks the fields of tipe, descending into embedded structs. Structs embedded in a
+// TableModel are checked for dynamodbav tags even if their other uses have been checked.
+func (l *linter) lintStruct(tipe reflect.Type, table bool) {
+	if tipe.Kind() != reflect.Struct {
+		return
+	}
+	general, dynamo := !l.seen[tipe], table && !l.seenTable[tipe]
+	if !general && !dynamo {
+		return
+	}
+	l.seen[tipe] = true
+	l.seenTable[tipe] = l.seenTable[tipe] || table
+
+	for i := 0; i < tipe.NumField(); i++ {
+		field := tipe.Field(i)
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && jsonName == "" {
+			inner := field.Type
+			if inner.Kind() == reflect.Pointer {
+				inner = inner.Elem()
+			}
+			if inner.Kind() == reflect.Struct {
+				l.lintStruct(inner, table)
+				continue
+			}
+		}
+		if !field.IsExported() || jsonName == "-" {
+			continue
+		}
+		for _, rule := range l.rules {
+			if (rule == RuleDynamoDB && !dynamo) || (rule != RuleDynamoDB && !general) {
+				continue
+			}
+			if msg := l.check(rule, field); msg != "" {
+				l.report(rule, tipe, field, msg)
+			}
+		}
+	}
+}
+
+// check returns a description of how field breaks rule, or "" if it does not
+func (l *linter) check(rule Rule, field reflect.StructField) string {
+	switch rule {
+	case RuleDesc:
+		if field.Tag.Get("desc") == "" {
+			return "missing desc tag"
+		}
+	case RuleExample:
+		if _, ok := field.Tag.Lookup("example"); !ok {
+			return "missing example tag"
+		}
+	case RuleExampleType:
+		if example, ok := field.Tag.Lookup("example"); ok {
+			if _, err := ParseExample(example, field.Type); err != nil {
+				return fmt.Sprintf("example %q does not parse as %s: %v", example, field.Type, err)
+			}
+		}
+	case RuleNeo4jName:
+		neo4j, ok := field.Tag.Lookup("neo4j")
+		neo4jName, _, _ := strings.Cut(neo4j, ",")
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if ok && neo4jName != "-" && neo4jName != "" && jsonName != "" && neo4jName != jsonName {
+			return fmt.Sprintf("neo4j name %q does not match json name %q", neo4jName, jsonName)
+		}
+	case RuleDynamoDB:
+		if _, ok := field.Tag.Lookup("dynamodbav"); !ok {
+			return "TableModel field is missing a dynamodbav tag"
+		}
+	case RuleCapmodel:
+		var problems []string
+		for _, entry := range strings.Split(field.Tag.Get("capmodel"), ",") {
+			if entry = strings.TrimSpace(entry); entry == "" {
+				continue
+			}
+			capType, embedType, err := parseCapmodelEntry(entry)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			for _, name := range []string{capType, embedType} {
+				if name != "" && !l.registry.HasConverter(name) && !l.registry.HasExtractor(name) {
+					problems = append(problems, fmt.Sprintf("unknown capmodel type %q", name))
+				}
+			}
+		}
+		return strings.Join(problems, "; ")
+	}
+	return ""
+}
+
+func (l *linter) report(rule Rule, tipe reflect.Type, field reflect.StructField, msg string) {
+	l.violations = append(l.violations, Violation{
+		Rule:    rule,
+		Type:    tipe.String(),
+		Field:   field.Name,
+		Message: msg,
+		Pos:     l.position(tipe, field.Name),
+	})
+}
+
+// position returns where the field of tipe is declared, if the source of its package can be found
+func (l *linter) position(tipe reflect.Type, field string) token.Position {
+	pkg := tipe.PkgPath()
+	if pkg == "" {
+		return token.Position{}
+	}
+	fields, ok := l.sources[pkg]
+	if !ok {
+		fields = parseFields(pkg)
+		l.sources[pkg] = fields
+	}
+	name, _, _ := strings.Cut(tipe.Name(), "[")
+	return fields[name+"."+field]
+}
+
+// parseFields returns the position of each struct field declared in the package at path, by
+// Type.Field. It returns nil if the package source cannot be found.
+func parseFields(path string) map[string]token.Position {
+	pkg, err := build.Import(path, ".", 0)
+	if err != nil {
+		return nil
+	}
+
+	fset := token.NewFileSet()
+	fields := map[string]token.Position{}
+	for _, name := range slices.Concat(pkg.GoFiles, pkg.TestGoFiles) {
+		file, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			if st, ok := spec.Type.(*ast.StructType); ok {
+				for _, field := range st.Fields.List {
+					for _, name := range field.Names {
+						fields[spec.Name.Name+"."+name.Name] = fset.Position(name.Pos())
+					}
+					if len(field.Names) == 0 {
+						fields[spec.Name.Name+"."+embeddedName(field.Type)] = fset.Position(field.Pos())
+					}
+				}
+			}
+			return true
+		})
+	}
+	return fields
+}
+
+// embeddedName returns the field name of an embedded type expression
+func embeddedName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// parseCapmodelEntry parses a capmodel tag entry, "Type[=json[(EmbedType)]]"
+func parseCapmodelEntry(entry string) (capType, embedType string, err error) {
+	capType, rest, _ := strings.Cut(entry, "=")
+	if capType == "" {
+		return "", "", fmt.Errorf("capmodel entry %q has no type", entry)
+	}
+	if open := strings.Index(rest, "("); open >= 0 {
+		if !strings.HasSuffix(rest, ")") || open == 0 {
+			return "", "", fmt.Errorf("malformed capmodel entry %q", entry)
+		}
+		embedType = rest[open+1 : len(rest)-1]
+	}
+	return capType, embedType, nil
+}
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// ParseExample decodes an example tag into a value of tipe, allocating pointers. Strings take the
+// example as it is, and types implementing encoding.TextUnmarshaler its text; other types take it
+// as JSON, or as a JSON string if that fails. Interfaces with methods cannot be decoded, and are
+// left nil.
+func ParseExample(example string, tipe reflect.Type) (reflect.Value, error) {
+	value := reflect.New(tipe).Elem()
+	target := value
+	for target.Kind() == reflect.Pointer {
+		target.Set(reflect.New(target.Type().Elem()))
+		target = target.Elem()
+	}
+
+	switch {
+	case target.Kind() == reflect.Interface && target.NumMethod() > 0:
+	case target.Addr().Type().Implements(textUnmarshaler):
+		if err := target.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(example)); err != nil {
+			return reflect.Value{}, err
+		}
+	case target.Kind() == reflect.String:
+		target.SetString(example)
+	default:
+		err := json.Unmarshal([]byte(example), target.Addr().Interface())
+		if err != nil && json.Unmarshal([]byte(strconv.Quote(example)), target.Addr().Interface()) != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,162 @@
+package modellint
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TableModel is registered under the name Lint looks up, like model.TableModel
+type TableModel interface {
+	registry.Model
+	Table()
+}
+
+type lintHistory struct {
+	Events []string `json:"events" dynamodbav:"events" desc:"Past events." example:"[\"created\"]"`
+}
+
+type lintModel struct {
+	registry.BaseModel
+	lintHistory
+	Key      string    `json:"key" neo4j:"key" desc:"The key." example:"#lint#1" capmodel:"Thing"`
+	Count    int       `json:"count" neo4j:"total" desc:"A count." example:"many"`
+	Seen     time.Time `json:"seen" desc:"When it was seen." example:"2023-10-27T10:00:00Z"`
+	Tags     []string  `json:"tags" desc:"Tags." example:"one"`
+	Label    string    `json:"label" example:"label"`
+	Parent   string    `json:"parent" desc:"The parent." example:"#lint#0" capmodel:"Thing=parent(Missing),Other"`
+	Ignored  string    `json:"-"`
+	Internal string    `json:"internal" neo4j:"-" desc:"Not stored in the graph." example:"x"`
+}
+
+func (m *lintModel) GetDescription() string { return "A model breaking every rule" }
+
+type lintTableModel struct {
+	registry.BaseModel
+	lintHistory
+	Key  string `json:"key" dynamodbav:"key" desc:"The key." example:"#table#1"`
+	Name string `json:"name" desc:"The name." example:"name"`
+}
+
+func (m *lintTableModel) GetDescription() string { return "A table model" }
+func (m *lintTableModel) Table()                 {}
+
+func lintRegistry() *registry.TypeRegistry {
+	r := registry.NewTypeRegistry()
+	r.MustRegisterModel(&lintModel{}, "lint")
+	r.MustRegisterModel(&lintTableModel{})
+	registry.RegisterInterface[TableModel](r)
+	r.MustRegisterConverter("Thing", func([]byte) (registry.Model, error) { return nil, nil })
+	r.MustRegisterExtractor("Other", func(registry.Model) (any, error) { return nil, nil })
+	return r
+}
+
+func TestLint(t *testing.T) {
+	violations := Lint(lintRegistry())
+
+	type found struct {
+		Rule  Rule
+		Type  string
+		Field string
+	}
+	var actual []found
+	for _, v := range violations {
+		actual = append(actual, found{v.Rule, v.Type, v.Field})
+	}
+	expected := []found{
+		{RuleExampleType, "modellint.lintModel", "Count"},
+		{RuleNeo4jName, "modellint.lintModel", "Count"},
+		{RuleExampleType, "modellint.lintModel", "Tags"},
+		{RuleDesc, "modellint.lintModel", "Label"},
+		{RuleCapmodel, "modellint.lintModel", "Parent"},
+		{RuleDynamoDB, "modellint.lintTableModel", "Name"},
+	}
+	assert.Equal(t, expected, actual)
+
+	for _, v := range violations {
+		assert.Equal(t, "modellint_test.go", filepath.Base(v.Pos.Filename), v)
+		assert.NotZero(t, v.Pos.Line, v)
+	}
+	assert.Contains(t, violations[4].String(), `modellint.lintModel.Parent: unknown capmodel type "Missing" (capmodel)`)
+}
+
+func TestLint_WithoutTableModel(t *testing.T) {
+	r := registry.NewTypeRegistry()
+	r.MustRegisterModel(&lintTableModel{})
+	assert.Empty(t, Lint(r))
+}
+
+func TestLint_Rules(t *testing.T) {
+	r := lintRegistry()
+	violations := Lint(r, RuleDesc, RuleExample)
+	require.Len(t, violations, 1)
+	assert.Equal(t, RuleDesc, violations[0].Rule)
+	assert.Equal(t, "Label", violations[0].Field)
+}
+
+func TestParseExample(t *testing.T) {
+	tests := []struct {
+		example string
+		value   any
+		ok      bool
+	}{
+		{"anything", "", true},
+		{"42", 0, true},
+		{"4.2", 0, false},
+		{"4.2", float32(0), true},
+		{"true", false, true},
+		{"yes", false, false},
+		{`["a", "b"]`, []string{}, true},
+		{"a", []string{}, false},
+		{`{"a": 1}`, map[string]int{}, true},
+		{`{"a": "b"}`, map[string]any{}, true},
+		{"2023-10-27T10:00:00Z", time.Time{}, true},
+		{"yesterday", time.Time{}, false},
+		{"1", new(int), true},
+		{"x", new(string), true},
+		{`{"key": "value"}`, struct{ Key string }{}, true},
+	}
+	for _, tt := range tests {
+		value, err := ParseExample(tt.example, reflect.TypeOf(tt.value))
+		assert.Equal(t, tt.ok, err == nil, "%q as %T: %v", tt.example, tt.value, err)
+		if err == nil {
+			assert.Equal(t, reflect.TypeOf(tt.value), value.Type())
+		}
+	}
+}
+
+func TestParseCapmodelEntry(t *testing.T) {
+	capType, embedType, err := parseCapmodelEntry("Port=parent(Asset)")
+	require.NoError(t, err)
+	assert.Equal(t, "Port", capType)
+	assert.Equal(t, "Asset", embedType)
+
+	capType, embedType, err = parseCapmodelEntry("IP=ip")
+	require.NoError(t, err)
+	assert.Equal(t, "IP", capType)
+	assert.Empty(t, embedType)
+
+	for _, entry := range []string{"=ip", "Port=parent(Asset", "Port=(Asset)"} {
+		_, _, err := parseCapmodelEntry(entry)
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestParseExample_Values(t *testing.T) {
+	value, err := ParseExample(`["a", "b"]`, reflect.TypeFor[*[]string]())
+	require.NoError(t, err)
+	assert.Equal(t, &[]string{"a", "b"}, value.Interface())
+
+	value, err = ParseExample("2023-10-27T10:00:00Z", reflect.TypeFor[time.Time]())
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 10, 27, 10, 0, 0, 0, time.UTC), value.Interface())
+
+	value, err = ParseExample("anything", reflect.TypeFor[registry.Model]())
+	require.NoError(t, err)
+	assert.True(t, value.IsNil())
+}
@@ -0,0 +1,18 @@
+package modellint
+
+import (
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// AssertConformance fails t with each violation of rules, or of all Rules if none are given, by the
+// models registered with r. It returns whether there were none.
+func AssertConformance(t testing.TB, r *registry.TypeRegistry, rules ...Rule) bool {
+	t.Helper()
+	violations := Lint(r, rules...)
+	for _, v := range violations {
+		t.Error(v)
+	}
+	return len(violations) == 0
+}
@@ -0,0 +1,151 @@
+// Package parquet maps registered models to Parquet message types, in the schema text format
+// accepted by parquet-mr and parquet-go. The mapping follows the models' Avro records, as
+// parquet-avro converts them: nested records become groups, pointers become optional fields,
+// and slices and maps use the LIST and MAP logical types.
+package parquet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/praetorian-inc/tabularium/pkg/avro"
+)
+
+// Field repetitions
+const (
+	Required = "required"
+	Optional = "optional"
+	Repeated = "repeated"
+)
+
+// GenerateSchema creates the Parquet message type of the registered model name
+func GenerateSchema(name string) (string, error) {
+	s, err := avro.GenerateSchema(name)
+	if err != nil {
+		return "", err
+	}
+	return FromAvro(s)
+}
+
+// FromAvro converts an Avro record schema into a Parquet message type. Recursive records have no
+// Parquet equivalent and are an error.
+func FromAvro(s *avro.Schema) (string, error) {
+	if s.Type != avro.TypeRecord {
+		return "", fmt.Errorf("avro schema is a %s, not a record", s.Type)
+	}
+	c := &converter{defs: s.Definitions(), active: make(map[string]bool)}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "message %s {\n", s.Name)
+	if err := c.fields(&b, 1, s); err != nil {
+		return "", err
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+type converter struct {
+	defs map[string]*avro.Schema
+	// active holds the records being converted, to detect recursion
+	active map[string]bool
+}
+
+func (c *converter) fields(b *strings.Builder, depth int, record *avro.Schema) error {
+	name := record.FullName()
+	if c.active[name] {
+		return fmt.Errorf("record %s is recursive", name)
+	}
+	c.active[name] = true
+	defer delete(c.active, name)
+
+	for _, f := range record.Fields {
+		if err := c.field(b, depth, Required, f.Name, f.Type); err != nil {
+			return fmt.Errorf("%s.%s: %w", record.Name, f.Name, err)
+		}
+	}
+	return nil
+}
+
+// field writes a single field. Records without fields are left out, as Parquet groups cannot be empty.
+func (c *converter) field(b *strings.Builder, depth int, repetition, name string, s *avro.Schema) error {
+	indent := strings.Repeat("  ", depth)
+
+	if s.Union != nil {
+		value, ok := optional(s)
+		if !ok {
+			return fmt.Errorf("unions other than with null are not supported")
+		}
+		return c.field(b, depth, Optional, name, value)
+	}
+
+	switch s.Type {
+	case avro.TypeBoolean:
+		fmt.Fprintf(b, "%s%s boolean %s;\n", indent, repetition, name)
+	case avro.TypeInt:
+		fmt.Fprintf(b, "%s%s int32 %s;\n", indent, repetition, name)
+	case avro.TypeLong:
+		fmt.Fprintf(b, "%s%s int64 %s;\n", indent, repetition, name)
+	case avro.TypeFloat:
+		fmt.Fprintf(b, "%s%s float %s;\n", indent, repetition, name)
+	case avro.TypeDouble:
+		fmt.Fprintf(b, "%s%s double %s;\n", indent, repetition, name)
+	case avro.TypeBytes:
+		fmt.Fprintf(b, "%s%s binary %s;\n", indent, repetition, name)
+	case avro.TypeString:
+		fmt.Fprintf(b, "%s%s binary %s (STRING);\n", indent, repetition, name)
+	case avro.TypeEnum:
+		fmt.Fprintf(b, "%s%s binary %s (ENUM);\n", indent, repetition, name)
+	case avro.TypeFixed:
+		fmt.Fprintf(b, "%s%s fixed_len_byte_array(%d) %s;\n", indent, repetition, s.Size, name)
+	case avro.TypeRecord:
+		var group strings.Builder
+		if err := c.fields(&group, depth+1, s); err != nil {
+			return err
+		}
+		if group.Len() > 0 {
+			fmt.Fprintf(b, "%s%s group %s {\n%s%s}\n", indent, repetition, name, group.String(), indent)
+		}
+	case avro.TypeArray:
+		var element strings.Builder
+		if err := c.field(&element, depth+2, Required, "element", s.Items); err != nil {
+			return err
+		}
+		if element.Len() > 0 {
+			fmt.Fprintf(b, "%s%s group %s (LIST) {\n", indent, repetition, name)
+			fmt.Fprintf(b, "%s  repeated group list {\n%s%s  }\n%s}\n", indent, element.String(), indent, indent)
+		}
+	case avro.TypeMap:
+		var value strings.Builder
+		if err := c.field(&value, depth+2, Required, "value", s.Values); err != nil {
+			return err
+		}
+		if value.Len() > 0 {
+			fmt.Fprintf(b, "%s%s group %s (MAP) {\n", indent, repetition, name)
+			fmt.Fprintf(b, "%s  repeated group key_value {\n", indent)
+			fmt.Fprintf(b, "%s    required binary key (STRING);\n%s%s  }\n%s}\n", indent, value.String(), indent, indent)
+		}
+	case avro.TypeNull:
+		return fmt.Errorf("null fields are not supported")
+	default:
+		def, ok := c.defs[s.Type]
+		if !ok {
+			return fmt.Errorf("unknown avro type %q", s.Type)
+		}
+		return c.field(b, depth, repetition, name, def)
+	}
+	return nil
+}
+
+// optional returns the non-null branch of a ["null", T] union
+func optional(s *avro.Schema) (*avro.Schema, bool) {
+	if len(s.Union) != 2 {
+		return nil, false
+	}
+	switch avro.TypeNull {
+	case s.Union[0].Type:
+		return s.Union[1], s.Union[1].Type != avro.TypeNull
+	case s.Union[1].Type:
+		return s.Union[0], true
+	}
+	return nil, false
+}
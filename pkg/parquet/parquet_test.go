@@ -0,0 +1,129 @@
+package parquet_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/avro"
+	_ "github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/praetorian-inc/tabularium/pkg/parquet"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseAvro(t *testing.T, schema string) *avro.Schema {
+	var s avro.Schema
+	require.NoError(t, json.Unmarshal([]byte(schema), &s))
+	return &s
+}
+
+func TestFromAvro(t *testing.T) {
+	s := parseAvro(t, `{
+		"type": "record", "name": "Sample", "namespace": "tabularium.test",
+		"fields": [
+			{"name": "key", "type": "string"},
+			{"name": "count", "type": "long"},
+			{"name": "small", "type": "int"},
+			{"name": "ratio", "type": "double"},
+			{"name": "logit", "type": ["null", "float"], "default": null},
+			{"name": "enabled", "type": "boolean"},
+			{"name": "raw", "type": "bytes"},
+			{"name": "tags", "type": {"type": "array", "items": "string"}},
+			{"name": "counts", "type": {"type": "map", "values": "long"}},
+			{"name": "audit", "type": {
+				"type": "record", "name": "Audit", "namespace": "tabularium.test",
+				"fields": [{"name": "by", "type": "string"}]
+			}},
+			{"name": "previous", "type": ["null", "tabularium.test.Audit"], "default": null},
+			{"name": "history", "type": {"type": "array", "items": "tabularium.test.Audit"}},
+			{"name": "empty", "type": {"type": "record", "name": "Empty", "fields": []}}
+		]
+	}`)
+
+	expected := `message Sample {
+  required binary key (STRING);
+  required int64 count;
+  required int32 small;
+  required double ratio;
+  optional float logit;
+  required boolean enabled;
+  required binary raw;
+  required group tags (LIST) {
+    repeated group list {
+      required binary element (STRING);
+    }
+  }
+  required group counts (MAP) {
+    repeated group key_value {
+      required binary key (STRING);
+      required int64 value;
+    }
+  }
+  required group audit {
+    required binary by (STRING);
+  }
+  optional group previous {
+    required binary by (STRING);
+  }
+  required group history (LIST) {
+    repeated group list {
+      required group element {
+        required binary by (STRING);
+      }
+    }
+  }
+}
+`
+	actual, err := parquet.FromAvro(s)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestFromAvro_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"not a record", `"string"`},
+		{"recursive", `{"type": "record", "name": "Node", "namespace": "test", "fields": [
+			{"name": "next", "type": ["null", "test.Node"]}
+		]}`},
+		{"union", `{"type": "record", "name": "Sample", "fields": [
+			{"name": "value", "type": ["string", "long"]}
+		]}`},
+		{"unknown type", `{"type": "record", "name": "Sample", "fields": [
+			{"name": "value", "type": "test.Missing"}
+		]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parquet.FromAvro(parseAvro(t, tt.schema))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGenerateSchema(t *testing.T) {
+	for _, name := range []string{"risk", "asset", "statistic", "job"} {
+		t.Run(name, func(t *testing.T) {
+			s, err := parquet.GenerateSchema(name)
+			require.NoError(t, err)
+			assert.Contains(t, s, "required binary key (STRING);")
+		})
+	}
+
+	s, err := parquet.GenerateSchema("asset")
+	require.NoError(t, err)
+	assert.Contains(t, s, "message Asset {\n")
+	assert.Contains(t, s, "  required group metadata {\n")
+	assert.Contains(t, s, "  required group history {\n")
+	assert.Contains(t, s, "  optional binary secret (STRING);\n")
+}
+
+func TestGenerateSchema_AllModels(t *testing.T) {
+	for name := range registry.Registry.GetAllTypes() {
+		_, err := parquet.GenerateSchema(name)
+		assert.NoError(t, err, name)
+	}
+}
@@ -0,0 +1,534 @@
+package protobuf
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// Wire types of the protobuf encoding
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("protobuf: truncated message")
+
+// Marshal encodes a registered model as its protobuf message
+func (s *Schema) Marshal(m registry.Model) ([]byte, error) {
+	v, msg, err := s.model(m)
+	if err != nil {
+		return nil, err
+	}
+	return s.appendMessage(nil, msg, v)
+}
+
+// Unmarshal decodes a protobuf message into a registered model. Unlike registry.UnmarshalModel,
+// the model is neither defaulted nor are its hooks called. As in proto3, empty slices and maps
+// are not written, so decode into a defaulted model where the difference from nil matters.
+func (s *Schema) Unmarshal(data []byte, m registry.Model) error {
+	v, msg, err := s.model(m)
+	if err != nil {
+		return err
+	}
+	return s.decodeMessage(data, msg, v)
+}
+
+func (s *Schema) model(m registry.Model) (reflect.Value, *message, error) {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return reflect.Value{}, nil, fmt.Errorf("protobuf: %T is not a non-nil pointer", m)
+	}
+	msg, ok := s.byType[v.Elem().Type()]
+	if !ok || msg.wrapper {
+		return reflect.Value{}, nil, fmt.Errorf("protobuf: %T is not a registered model", m)
+	}
+	return v.Elem(), msg, nil
+}
+
+func (s *Schema) appendMessage(b []byte, msg *message, v reflect.Value) ([]byte, error) {
+	var err error
+	for _, f := range msg.fields {
+		fv, ok := fieldByIndex(v, f.index, false)
+		if !ok {
+			continue
+		}
+		if b, err = s.appendField(b, f, fv); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", msg.name, f.name, err)
+		}
+	}
+	return b, nil
+}
+
+func (s *Schema) appendField(b []byte, f *field, v reflect.Value) ([]byte, error) {
+	switch f.shape {
+	case shapeOptional:
+		if v.IsNil() {
+			return b, nil
+		}
+		return s.appendValue(b, f.number, f.value, v.Elem())
+	case shapeRepeated:
+		if v.Len() == 0 {
+			return b, nil
+		}
+		if packable(f.value.kind) {
+			var packed []byte
+			for i := 0; i < v.Len(); i++ {
+				packed = appendScalar(packed, f.value.kind, v.Index(i))
+			}
+			return appendBytes(appendTag(b, f.number, wireBytes), packed), nil
+		}
+		var err error
+		for i := 0; i < v.Len(); i++ {
+			if b, err = s.appendValue(b, f.number, f.value, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case shapeMap:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface()) })
+		for _, key := range keys {
+			entry, err := s.appendValue(nil, 1, f.key, key)
+			if err != nil {
+				return nil, err
+			}
+			if entry, err = s.appendValue(entry, 2, f.value, v.MapIndex(key)); err != nil {
+				return nil, err
+			}
+			b = appendBytes(appendTag(b, f.number, wireBytes), entry)
+		}
+		return b, nil
+	}
+
+	// proto3 does not write singular fields holding their zero value
+	if v.IsZero() {
+		return b, nil
+	}
+	return s.appendValue(b, f.number, f.value, v)
+}
+
+func (s *Schema) appendValue(b []byte, number int, vt valueType, v reflect.Value) ([]byte, error) {
+	switch vt.kind {
+	case kindBool, kindInt, kindUint:
+		return appendScalar(appendTag(b, number, wireVarint), vt.kind, v), nil
+	case kindDouble:
+		return appendScalar(appendTag(b, number, wireFixed64), vt.kind, v), nil
+	case kindFloat:
+		return appendScalar(appendTag(b, number, wireFixed32), vt.kind, v), nil
+	case kindString:
+		return appendBytes(appendTag(b, number, wireBytes), []byte(v.String())), nil
+	case kindBytes:
+		return appendBytes(appendTag(b, number, wireBytes), v.Bytes()), nil
+	case kindText:
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(appendTag(b, number, wireBytes), text), nil
+	case kindJSON:
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(appendTag(b, number, wireBytes), data), nil
+	case kindMessage:
+		var nested []byte
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		if v.IsValid() {
+			var err error
+			if nested, err = s.appendMessage(nil, vt.message, v); err != nil {
+				return nil, err
+			}
+		}
+		return appendBytes(appendTag(b, number, wireBytes), nested), nil
+	case kindWrapper:
+		nested, err := s.appendWrapper(vt.message, v)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(appendTag(b, number, wireBytes), nested), nil
+	}
+	return nil, fmt.Errorf("unsupported value %s", v.Type())
+}
+
+// appendWrapper encodes the wrapped model as the oneof member named by the wrapper's type
+func (s *Schema) appendWrapper(msg *message, v reflect.Value) ([]byte, error) {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	model := v.FieldByName("Model")
+	if model.IsNil() {
+		return nil, nil
+	}
+
+	name := strings.ToLower(v.FieldByName("Type").String())
+	if name == "" {
+		name = registry.Name(model.Interface().(registry.Model))
+	}
+	m, ok := msg.membersByName[name]
+	if !ok {
+		return nil, fmt.Errorf("type %q is not a member of %s", name, msg.name)
+	}
+
+	concrete := model.Elem()
+	if concrete.Kind() != reflect.Ptr || s.byType[concrete.Type().Elem()] != m.message {
+		return nil, fmt.Errorf("type %q does not match model %s", name, concrete.Type())
+	}
+	nested, err := s.appendMessage(nil, m.message, concrete.Elem())
+	if err != nil {
+		return nil, err
+	}
+	return appendBytes(appendTag(nil, m.number, wireBytes), nested), nil
+}
+
+func (s *Schema) decodeMessage(data []byte, msg *message, v reflect.Value) error {
+	for len(data) > 0 {
+		number, wire, n := consumeTag(data)
+		if n < 0 {
+			return errTruncated
+		}
+		data = data[n:]
+
+		f, ok := msg.byNumber[number]
+		var fv reflect.Value
+		if ok {
+			fv, ok = fieldByIndex(v, f.index, true)
+		}
+		if !ok {
+			if n = skipValue(data, wire); n < 0 {
+				return errTruncated
+			}
+			data = data[n:]
+			continue
+		}
+
+		n, err := s.decodeField(data, wire, f, fv)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", msg.name, f.name, err)
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func (s *Schema) decodeField(data []byte, wire int, f *field, v reflect.Value) (int, error) {
+	switch f.shape {
+	case shapeOptional:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return s.decodeValue(data, wire, f.value, v.Elem())
+	case shapeRepeated:
+		if wire == wireBytes && packable(f.value.kind) {
+			packed, n := consumeBytes(data)
+			if n < 0 {
+				return 0, errTruncated
+			}
+			for len(packed) > 0 {
+				elem := reflect.New(v.Type().Elem()).Elem()
+				m, err := s.decodeValue(packed, expectedWire(f.value.kind), f.value, elem)
+				if err != nil {
+					return 0, err
+				}
+				packed = packed[m:]
+				v.Set(reflect.Append(v, elem))
+			}
+			return n, nil
+		}
+		elem := reflect.New(v.Type().Elem()).Elem()
+		n, err := s.decodeValue(data, wire, f.value, elem)
+		if err != nil {
+			return 0, err
+		}
+		v.Set(reflect.Append(v, elem))
+		return n, nil
+	case shapeMap:
+		if wire != wireBytes {
+			return 0, fmt.Errorf("unexpected wire type %d", wire)
+		}
+		entry, n := consumeBytes(data)
+		if n < 0 {
+			return 0, errTruncated
+		}
+		key := reflect.New(v.Type().Key()).Elem()
+		value := reflect.New(v.Type().Elem()).Elem()
+		for len(entry) > 0 {
+			number, entryWire, m := consumeTag(entry)
+			if m < 0 {
+				return 0, errTruncated
+			}
+			entry = entry[m:]
+			var err error
+			switch number {
+			case 1:
+				m, err = s.decodeValue(entry, entryWire, f.key, key)
+			case 2:
+				m, err = s.decodeValue(entry, entryWire, f.value, value)
+			default:
+				m = skipValue(entry, entryWire)
+			}
+			if err != nil {
+				return 0, err
+			}
+			if m < 0 {
+				return 0, errTruncated
+			}
+			entry = entry[m:]
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(key, value)
+		return n, nil
+	}
+	return s.decodeValue(data, wire, f.value, v)
+}
+
+func (s *Schema) decodeValue(data []byte, wire int, vt valueType, v reflect.Value) (int, error) {
+	if expected := expectedWire(vt.kind); wire != expected {
+		return 0, fmt.Errorf("unexpected wire type %d, expected %d", wire, expected)
+	}
+
+	switch vt.kind {
+	case kindBool, kindInt, kindUint:
+		x, n := consumeVarint(data)
+		if n < 0 {
+			return 0, errTruncated
+		}
+		switch vt.kind {
+		case kindBool:
+			v.SetBool(x != 0)
+		case kindInt:
+			v.SetInt(int64(x))
+		default:
+			v.SetUint(x)
+		}
+		return n, nil
+	case kindDouble:
+		if len(data) < 8 {
+			return 0, errTruncated
+		}
+		v.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(data)))
+		return 8, nil
+	case kindFloat:
+		if len(data) < 4 {
+			return 0, errTruncated
+		}
+		v.SetFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(data))))
+		return 4, nil
+	}
+
+	payload, n := consumeBytes(data)
+	if n < 0 {
+		return 0, errTruncated
+	}
+	switch vt.kind {
+	case kindString:
+		v.SetString(string(payload))
+	case kindBytes:
+		v.SetBytes(append([]byte{}, payload...))
+	case kindText:
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(payload); err != nil {
+			return 0, err
+		}
+	case kindJSON:
+		if err := json.Unmarshal(payload, v.Addr().Interface()); err != nil {
+			return 0, err
+		}
+	case kindMessage:
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		if err := s.decodeMessage(payload, vt.message, v); err != nil {
+			return 0, err
+		}
+	case kindWrapper:
+		if err := s.decodeWrapper(payload, vt.message, v); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// decodeWrapper makes the registered type named by the oneof member and decodes the model into it
+func (s *Schema) decodeWrapper(data []byte, msg *message, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	for len(data) > 0 {
+		number, wire, n := consumeTag(data)
+		if n < 0 {
+			return errTruncated
+		}
+		data = data[n:]
+
+		m, ok := msg.membersByIndex[number]
+		if !ok || wire != wireBytes {
+			if n = skipValue(data, wire); n < 0 {
+				return errTruncated
+			}
+			data = data[n:]
+			continue
+		}
+
+		payload, n := consumeBytes(data)
+		if n < 0 {
+			return errTruncated
+		}
+		data = data[n:]
+
+		model, ok := s.registry.MakeType(m.name)
+		if !ok {
+			return fmt.Errorf("failed to make type %s", m.name)
+		}
+		if err := s.decodeMessage(payload, m.message, reflect.ValueOf(model).Elem()); err != nil {
+			return err
+		}
+		v.FieldByName("Model").Set(reflect.ValueOf(model))
+		v.FieldByName("Type").SetString(m.name)
+	}
+	return nil
+}
+
+// fieldByIndex returns the nested field at index. Nil embedded pointers are allocated when alloc
+// is set, and otherwise report false.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func packable(k kind) bool {
+	return k == kindBool || k == kindInt || k == kindUint || k == kindDouble || k == kindFloat
+}
+
+func expectedWire(k kind) int {
+	switch k {
+	case kindBool, kindInt, kindUint:
+		return wireVarint
+	case kindDouble:
+		return wireFixed64
+	case kindFloat:
+		return wireFixed32
+	}
+	return wireBytes
+}
+
+func appendScalar(b []byte, k kind, v reflect.Value) []byte {
+	switch k {
+	case kindBool:
+		if v.Bool() {
+			return appendVarint(b, 1)
+		}
+		return appendVarint(b, 0)
+	case kindInt:
+		return appendVarint(b, uint64(v.Int()))
+	case kindUint:
+		return appendVarint(b, v.Uint())
+	case kindDouble:
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(v.Float()))
+	case kindFloat:
+		return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(v.Float())))
+	}
+	return b
+}
+
+func appendVarint(b []byte, x uint64) []byte {
+	for x >= 0x80 {
+		b = append(b, byte(x)|0x80)
+		x >>= 7
+	}
+	return append(b, byte(x))
+}
+
+func appendTag(b []byte, number, wire int) []byte {
+	return appendVarint(b, uint64(number)<<3|uint64(wire))
+}
+
+func appendBytes(b, data []byte) []byte {
+	return append(appendVarint(b, uint64(len(data))), data...)
+}
+
+// consumeVarint returns the varint at the start of b and its length, or a negative length if b is malformed
+func consumeVarint(b []byte) (uint64, int) {
+	var x uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		x |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i] < 0x80 {
+			return x, i + 1
+		}
+	}
+	return 0, -1
+}
+
+func consumeTag(b []byte) (int, int, int) {
+	x, n := consumeVarint(b)
+	if n < 0 || x>>3 == 0 || x>>3 > math.MaxInt32 {
+		return 0, 0, -1
+	}
+	return int(x >> 3), int(x & 7), n
+}
+
+func consumeBytes(b []byte) ([]byte, int) {
+	length, n := consumeVarint(b)
+	if n < 0 || length > uint64(len(b)-n) {
+		return nil, -1
+	}
+	return b[n : n+int(length)], n + int(length)
+}
+
+// skipValue returns the length of a value of an unknown field
+func skipValue(b []byte, wire int) int {
+	switch wire {
+	case wireVarint:
+		_, n := consumeVarint(b)
+		return n
+	case wireFixed64:
+		if len(b) < 8 {
+			return -1
+		}
+		return 8
+	case wireBytes:
+		_, n := consumeBytes(b)
+		return n
+	case wireFixed32:
+		if len(b) < 4 {
+			return -1
+		}
+		return 4
+	}
+	return -1
+}
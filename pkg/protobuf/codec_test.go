@@ -0,0 +1,152 @@
+package protobuf
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestSchema_MarshalWireFormat(t *testing.T) {
+	s := NewSchema(fixtureRegistry(), NewLock())
+
+	// count = 4 and name = 8, encoded in field number order as protoc-generated code would
+	data, err := s.Marshal(&Sample{Name: "a", Count: 150})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x20, 0x96, 0x01, 0x42, 0x01, 'a'}, data)
+
+	// repeated scalars are packed
+	data, err = s.Marshal(&Sample{Ports: []int32{1, 2, 300}})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x5a, 0x04, 0x01, 0x02, 0xac, 0x02}, data)
+
+	// a zero value writes nothing
+	data, err = s.Marshal(&Sample{})
+	require.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+// fullSample returns a Sample setting every kind of field
+func fullSample() *Sample {
+	optional := int64(0)
+	return &Sample{
+		Embedded: Embedded{Key: "#sample#a"},
+		Name:     "a",
+		Count:    -42,
+		Ratio:    0.5,
+		Enabled:  true,
+		Tags:     []string{"x", ""},
+		Ports:    []int32{80, -1},
+		Labels:   map[string]int{"a": 1, "b": 0},
+		Raw:      []byte{0, 1, 2},
+		When:     time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		Any:      map[string]any{"nested": []any{"value"}},
+		Optional: &optional,
+		Child:    &Child{Value: "child"},
+		Children: []Child{{Value: "first"}, {}},
+		Parent:   registry.Wrapper[fixture]{Type: "alias", Model: &Other{Key: "#other#b"}},
+		Ignored:  "not encoded",
+	}
+}
+
+func TestSchema_RoundTrip(t *testing.T) {
+	s := NewSchema(fixtureRegistry(), NewLock())
+	sample := fullSample()
+
+	data, err := s.Marshal(sample)
+	require.NoError(t, err)
+
+	decoded := &Sample{}
+	require.NoError(t, s.Unmarshal(data, decoded))
+
+	sample.Ignored = ""
+	assert.Equal(t, sample, decoded)
+}
+
+func TestSchema_WrapperType(t *testing.T) {
+	s := NewSchema(fixtureRegistry(), NewLock())
+
+	// without a type, the wrapper is encoded as the model's registered name
+	data, err := s.Marshal(&Sample{Parent: registry.Wrapper[fixture]{Model: &Other{Key: "k"}}})
+	require.NoError(t, err)
+	decoded := &Sample{}
+	require.NoError(t, s.Unmarshal(data, decoded))
+	assert.Equal(t, "other", decoded.Parent.Type)
+	assert.Equal(t, &Other{Key: "k"}, decoded.Parent.Model)
+
+	_, err = s.Marshal(&Sample{Parent: registry.Wrapper[fixture]{Type: "sample", Model: &Other{}}})
+	assert.Error(t, err, "type does not match the model")
+
+	_, err = s.Marshal(&Sample{Parent: registry.Wrapper[fixture]{Type: "nope", Model: &Other{}}})
+	assert.Error(t, err, "type is not registered")
+}
+
+func TestSchema_Unmarshal(t *testing.T) {
+	s := NewSchema(fixtureRegistry(), NewLock())
+
+	t.Run("unpacked repeated scalars", func(t *testing.T) {
+		decoded := &Sample{}
+		require.NoError(t, s.Unmarshal([]byte{0x58, 0x01, 0x58, 0x02}, decoded))
+		assert.Equal(t, []int32{1, 2}, decoded.Ports)
+	})
+
+	t.Run("unknown fields are skipped", func(t *testing.T) {
+		decoded := &Sample{}
+		data := []byte{0xf8, 0x06, 0x01, 0xfa, 0x06, 0x01, 'x', 0x42, 0x01, 'a'}
+		require.NoError(t, s.Unmarshal(data, decoded))
+		assert.Equal(t, &Sample{Name: "a"}, decoded)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		assert.Error(t, s.Unmarshal([]byte{0x42, 0x05, 'a'}, &Sample{}))
+		assert.Error(t, s.Unmarshal([]byte{0x20, 0x96}, &Sample{}))
+	})
+
+	t.Run("wrong wire type", func(t *testing.T) {
+		assert.Error(t, s.Unmarshal([]byte{0x40, 0x01}, &Sample{}))
+	})
+
+	t.Run("unregistered model", func(t *testing.T) {
+		assert.Error(t, s.Unmarshal(nil, &unregistered{}))
+	})
+}
+
+type unregistered struct {
+	registry.BaseModel
+}
+
+func (u *unregistered) GetDescription() string { return "" }
+
+// TestSchema_Dynamicpb compiles the fixture's .proto with protocompile, and checks that dynamicpb
+// decodes every field of the encoding and encodes it back to the same bytes
+func TestSchema_Dynamicpb(t *testing.T) {
+	s := NewSchema(fixtureRegistry(), NewLock())
+	compiler := protocompile.Compiler{Resolver: &protocompile.SourceResolver{
+		Accessor: protocompile.SourceAccessorFromMap(map[string]string{"fixture.proto": s.Proto()}),
+	}}
+	files, err := compiler.Compile(context.Background(), "fixture.proto")
+	require.NoError(t, err)
+	descriptor := files[0].Messages().ByName("Sample")
+	require.NotNil(t, descriptor)
+
+	data, err := s.Marshal(fullSample())
+	require.NoError(t, err)
+	message := dynamicpb.NewMessage(descriptor)
+	require.NoError(t, proto.Unmarshal(data, message))
+	assert.Empty(t, message.GetUnknown())
+	for _, name := range []protoreflect.Name{"name", "count", "ratio", "enabled", "tags", "ports", "labels", "raw", "when", "any", "optional", "child", "children", "parent"} {
+		assert.True(t, message.Has(descriptor.Fields().ByName(name)), "%s is not set", name)
+	}
+
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(data), hex.EncodeToString(encoded))
+}
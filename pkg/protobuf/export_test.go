@@ -0,0 +1,16 @@
+package protobuf
+
+import "github.com/praetorian-inc/tabularium/pkg/registry"
+
+// MessageName returns the name of the message m is encoded as
+func MessageName(m registry.Model) (string, error) {
+	s, err := defaultSchema()
+	if err != nil {
+		return "", err
+	}
+	_, msg, err := s.model(m)
+	if err != nil {
+		return "", err
+	}
+	return msg.name, nil
+}
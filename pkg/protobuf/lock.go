@@ -0,0 +1,120 @@
+package protobuf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// Field numbers 19000 through 19999 are reserved by the protobuf implementation
+const (
+	firstReservedNumber = 19000
+	lastReservedNumber  = 19999
+)
+
+// Lock pins the field number of every message field, so that renaming, reordering or removing
+// fields in pkg/model never renumbers the fields that remain
+type Lock struct {
+	Messages map[string]*MessageLock `json:"messages"`
+}
+
+// MessageLock holds the field numbers of a single message, keyed by JSON field name (or, for
+// wrapper messages, by registered type name). Numbers of removed fields are reserved and never
+// handed out again.
+type MessageLock struct {
+	Fields   map[string]int `json:"fields"`
+	Reserved []int          `json:"reserved,omitempty"`
+}
+
+// NewLock returns an empty lock
+func NewLock() *Lock {
+	return &Lock{Messages: make(map[string]*MessageLock)}
+}
+
+// ParseLock parses a lock from its JSON encoding
+func ParseLock(data []byte) (*Lock, error) {
+	lock := NewLock()
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse protobuf lock: %w", err)
+	}
+	if lock.Messages == nil {
+		lock.Messages = make(map[string]*MessageLock)
+	}
+	return lock, nil
+}
+
+// LoadLock reads a lock from disk. A missing file is an empty lock.
+func LoadLock(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewLock(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read protobuf lock %s: %w", path, err)
+	}
+	return ParseLock(data)
+}
+
+// Save writes the lock to disk
+func (l *Lock) Save(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal protobuf lock: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write protobuf lock %s: %w", path, err)
+	}
+	return nil
+}
+
+// assign returns the field numbers for the named message's current fields. Locked fields keep
+// their numbers, fields that are no longer present are reserved, and new fields are numbered
+// after every number the message has used, in the order given.
+func (l *Lock) assign(message string, fields []string) map[string]int {
+	locked, ok := l.Messages[message]
+	if !ok {
+		locked = &MessageLock{Fields: make(map[string]int)}
+		l.Messages[message] = locked
+	}
+	if locked.Fields == nil {
+		locked.Fields = make(map[string]int)
+	}
+
+	current := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		current[field] = true
+	}
+	for field, number := range locked.Fields {
+		if !current[field] {
+			locked.Reserved = append(locked.Reserved, number)
+			delete(locked.Fields, field)
+		}
+	}
+	sort.Ints(locked.Reserved)
+
+	next := 1
+	for _, number := range locked.Fields {
+		next = max(next, number+1)
+	}
+	for _, number := range locked.Reserved {
+		next = max(next, number+1)
+	}
+
+	numbers := make(map[string]int, len(fields))
+	for _, field := range fields {
+		number, ok := locked.Fields[field]
+		if !ok {
+			if next >= firstReservedNumber && next <= lastReservedNumber {
+				next = lastReservedNumber + 1
+			}
+			number = next
+			next++
+			locked.Fields[field] = number
+		}
+		numbers[field] = number
+	}
+	return numbers
+}
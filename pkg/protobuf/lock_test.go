@@ -0,0 +1,59 @@
+package protobuf
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock_Assign(t *testing.T) {
+	lock := NewLock()
+
+	numbers := lock.assign("Asset", []string{"dns", "name", "status"})
+	assert.Equal(t, map[string]int{"dns": 1, "name": 2, "status": 3}, numbers)
+
+	// reordering and adding fields keeps existing numbers
+	numbers = lock.assign("Asset", []string{"class", "status", "name", "dns"})
+	assert.Equal(t, map[string]int{"dns": 1, "name": 2, "status": 3, "class": 4}, numbers)
+
+	// removed fields are reserved and their numbers are never reused
+	numbers = lock.assign("Asset", []string{"class", "dns", "status"})
+	assert.Equal(t, map[string]int{"dns": 1, "status": 3, "class": 4}, numbers)
+	assert.Equal(t, []int{2}, lock.Messages["Asset"].Reserved)
+
+	numbers = lock.assign("Asset", []string{"class", "dns", "name", "status"})
+	assert.Equal(t, 5, numbers["name"], "a re-added field is a new field")
+	assert.Equal(t, []int{2}, lock.Messages["Asset"].Reserved)
+
+	// messages are numbered independently
+	assert.Equal(t, map[string]int{"port": 1}, lock.assign("Port", []string{"port"}))
+}
+
+func TestLock_AssignSkipsReservedRange(t *testing.T) {
+	lock := NewLock()
+	lock.Messages["Asset"] = &MessageLock{Fields: map[string]int{"dns": firstReservedNumber - 1}}
+
+	numbers := lock.assign("Asset", []string{"dns", "name"})
+	assert.Equal(t, lastReservedNumber+1, numbers["name"])
+}
+
+func TestLock_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFile)
+
+	lock, err := LoadLock(path)
+	require.NoError(t, err)
+	assert.Empty(t, lock.Messages, "a missing lockfile is an empty lock")
+
+	lock.assign("Asset", []string{"dns", "name"})
+	lock.assign("Asset", []string{"dns"})
+	require.NoError(t, lock.Save(path))
+
+	loaded, err := LoadLock(path)
+	require.NoError(t, err)
+	assert.Equal(t, lock, loaded)
+
+	_, err = ParseLock([]byte("not json"))
+	assert.Error(t, err)
+}
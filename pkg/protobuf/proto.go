@@ -0,0 +1,75 @@
+package protobuf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Proto returns the .proto source declaring every message in the schema. Wrapper fields such as
+// GraphModelWrapper and TargetWrapper are messages holding a oneof over the registered types.
+func (s *Schema) Proto() string {
+	var b strings.Builder
+	b.WriteString("// Code generated by cmd/codegen. DO NOT EDIT.\n\n")
+	b.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&b, "package %s;\n", Package)
+
+	messages := append([]*message{}, s.messages...)
+	sort.Slice(messages, func(i, j int) bool { return messages[i].name < messages[j].name })
+
+	for _, msg := range messages {
+		b.WriteString("\n")
+		writeComment(&b, "", msg.desc)
+		fmt.Fprintf(&b, "message %s {\n", msg.name)
+		if msg.wrapper {
+			b.WriteString("  oneof model {\n")
+			for _, m := range msg.members {
+				fmt.Fprintf(&b, "    %s %s = %d;\n", m.message.name, m.proto, m.number)
+			}
+			b.WriteString("  }\n")
+		}
+		for _, f := range msg.fields {
+			desc := f.desc
+			if f.value.kind == kindJSON && desc == "" {
+				desc = "JSON-encoded"
+			} else if f.value.kind == kindJSON {
+				desc += " (JSON-encoded)"
+			}
+			writeComment(&b, "  ", desc)
+			fmt.Fprintf(&b, "  %s %s = %d;\n", f.protoType(), f.proto, f.number)
+		}
+		if locked, ok := s.lock.Messages[msg.name]; ok && len(locked.Reserved) > 0 {
+			numbers := make([]string, 0, len(locked.Reserved))
+			for _, number := range locked.Reserved {
+				numbers = append(numbers, fmt.Sprint(number))
+			}
+			fmt.Fprintf(&b, "  reserved %s;\n", strings.Join(numbers, ", "))
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+func (f *field) protoType() string {
+	switch f.shape {
+	case shapeOptional:
+		if f.value.kind == kindMessage || f.value.kind == kindWrapper {
+			return f.value.proto
+		}
+		return "optional " + f.value.proto
+	case shapeRepeated:
+		return "repeated " + f.value.proto
+	case shapeMap:
+		return fmt.Sprintf("map<%s, %s>", f.key.proto, f.value.proto)
+	}
+	return f.value.proto
+}
+
+func writeComment(b *strings.Builder, indent, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
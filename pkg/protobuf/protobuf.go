@@ -0,0 +1,47 @@
+// Package protobuf maps registered models to protobuf messages. Field numbers are pinned by a
+// committed lockfile, so that the generated .proto stays wire compatible as models change, and
+// models are encoded to and decoded from the protobuf wire format directly.
+package protobuf
+
+import (
+	_ "embed"
+	"sync"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// Files written by the proto codegen target
+const (
+	ProtoFile = "tabularium.proto"
+	LockFile  = "tabularium.lock.json"
+)
+
+//go:embed tabularium.lock.json
+var lockfile []byte
+
+// defaultSchema is built on first use, once every model has been registered
+var defaultSchema = sync.OnceValues(func() (*Schema, error) {
+	lock, err := ParseLock(lockfile)
+	if err != nil {
+		return nil, err
+	}
+	return NewSchema(registry.Registry, lock), nil
+})
+
+// Marshal encodes a registered model as its protobuf message, using the committed field numbers
+func Marshal(m registry.Model) ([]byte, error) {
+	s, err := defaultSchema()
+	if err != nil {
+		return nil, err
+	}
+	return s.Marshal(m)
+}
+
+// Unmarshal decodes a protobuf message into a registered model, using the committed field numbers
+func Unmarshal(data []byte, m registry.Model) error {
+	s, err := defaultSchema()
+	if err != nil {
+		return err
+	}
+	return s.Unmarshal(data, m)
+}
@@ -0,0 +1,93 @@
+package protobuf_test
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/praetorian-inc/tabularium/pkg/protobuf"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshal_Models(t *testing.T) {
+	asset := model.NewAsset("example.com", "1.2.3.4")
+	asset.AttackSurface = []string{"external"}
+	port := model.NewPort("tcp", 443, &asset)
+	risk := model.NewRisk(&asset, "cve-2024-1234", model.TriageHigh)
+	job := model.NewJob("portscan", &asset)
+	job.Config = map[string]string{"test": "value"}
+	application := model.NewWebApplication("https://example.com", "example")
+	webpage := model.NewWebpage(url.URL{Scheme: "https", Host: "example.com", Path: "/login"}, &application)
+
+	tests := []struct {
+		name  string
+		model registry.Model
+		empty registry.Model
+	}{
+		{"asset", &asset, &model.Asset{}},
+		{"port", &port, &model.Port{}},
+		{"risk", &risk, &model.Risk{}},
+		{"job", &job, &model.Job{}},
+		{"webpage", &webpage, &model.Webpage{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := protobuf.Marshal(tt.model)
+			require.NoError(t, err)
+			require.NoError(t, protobuf.Unmarshal(data, tt.empty))
+
+			assert.Equal(t, normalize(t, tt.model), normalize(t, tt.empty))
+		})
+	}
+}
+
+func TestLockfileIsCurrent(t *testing.T) {
+	lock, err := protobuf.LoadLock(protobuf.LockFile)
+	require.NoError(t, err)
+	before, err := json.Marshal(lock)
+	require.NoError(t, err)
+
+	protobuf.NewSchema(registry.Registry, lock)
+	after, err := json.Marshal(lock)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, string(before), string(after), "run go run ./cmd/codegen -gen proto:pkg/protobuf to update the lockfile")
+}
+
+// normalize returns the JSON values of a model without empty slices and maps, which proto3 does
+// not distinguish from nil ones
+func normalize(t *testing.T, m registry.Model) any {
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	var value any
+	require.NoError(t, json.Unmarshal(data, &value))
+	return dropEmpty(value)
+}
+
+func dropEmpty(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if item = dropEmpty(item); item == nil {
+				delete(v, key)
+			} else {
+				v[key] = item
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []any:
+		if len(v) == 0 {
+			return nil
+		}
+		for i, item := range v {
+			v[i] = dropEmpty(item)
+		}
+	}
+	return value
+}
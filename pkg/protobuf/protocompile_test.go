@@ -0,0 +1,84 @@
+package protobuf_test
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/praetorian-inc/tabularium/pkg/protobuf"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/praetorian-inc/tabularium/pkg/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// compileProto compiles the committed .proto with protocompile
+func compileProto(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+	compiler := protocompile.Compiler{Resolver: &protocompile.SourceResolver{}}
+	files, err := compiler.Compile(context.Background(), protobuf.ProtoFile)
+	require.NoError(t, err)
+	return files[0]
+}
+
+// TestMarshal_Dynamicpb decodes the encoding of every registered model with the dynamicpb
+// messages of the compiled .proto, and checks that the encoding dynamicpb writes back decodes
+// to the same model
+func TestMarshal_Dynamicpb(t *testing.T) {
+	file := compileProto(t)
+
+	for name := range registry.Registry.GetAllTypes() {
+		t.Run(name, func(t *testing.T) {
+			example, err := testutils.ExampleOf(name)
+			require.NoError(t, err)
+			messageName, err := protobuf.MessageName(example)
+			require.NoError(t, err)
+			descriptor := file.Messages().ByName(protoreflect.Name(messageName))
+			require.NotNil(t, descriptor, "no message %s", messageName)
+
+			data, err := protobuf.Marshal(example)
+			require.NoError(t, err)
+			message := dynamicpb.NewMessage(descriptor)
+			require.NoError(t, proto.Unmarshal(data, message))
+			assertNoUnknown(t, message)
+
+			encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+			require.NoError(t, err)
+			assert.Equal(t, hex.EncodeToString(data), hex.EncodeToString(encoded), "encoding differs from dynamicpb's")
+			expected, ok := registry.Registry.MakeType(name)
+			require.True(t, ok)
+			require.NoError(t, protobuf.Unmarshal(data, expected))
+			decoded, _ := registry.Registry.MakeType(name)
+			require.NoError(t, protobuf.Unmarshal(encoded, decoded))
+			assert.Equal(t, normalize(t, expected), normalize(t, decoded))
+		})
+	}
+}
+
+// assertNoUnknown fails unless every field of message and its nested messages is declared by the .proto
+func assertNoUnknown(t *testing.T, message protoreflect.Message) {
+	t.Helper()
+	assert.Empty(t, message.GetUnknown(), "unknown fields in %s", message.Descriptor().FullName())
+	message.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+					assertNoUnknown(t, v.Message())
+					return true
+				})
+			}
+		case fd.Message() != nil && fd.IsList():
+			for i := 0; i < v.List().Len(); i++ {
+				assertNoUnknown(t, v.List().Get(i).Message())
+			}
+		case fd.Message() != nil:
+			assertNoUnknown(t, v.Message())
+		}
+		return true
+	})
+}
@@ -0,0 +1,442 @@
+package protobuf
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// Package is the protobuf package of the generated messages
+const Package = "tabularium"
+
+var (
+	jsonMarshaler   = reflect.TypeFor[json.Marshaler]()
+	textMarshaler   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+	modelType       = reflect.TypeFor[registry.Model]()
+
+	// nonIdentifier matches runs of characters that cannot appear in protobuf identifiers
+	nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// kind is how a single Go value is carried on the wire
+type kind int
+
+const (
+	kindBool kind = iota
+	kindInt
+	kindUint
+	kindDouble
+	kindFloat
+	kindString
+	kindBytes
+	// kindText values implement encoding.TextMarshaler, such as time.Time, and are carried as strings
+	kindText
+	// kindJSON values have no protobuf equivalent, such as any or nested slices, and are carried as JSON bytes
+	kindJSON
+	kindMessage
+	kindWrapper
+)
+
+type shape int
+
+const (
+	shapeSingular shape = iota
+	// shapeOptional fields are pointers, and are only written when set
+	shapeOptional
+	shapeRepeated
+	shapeMap
+)
+
+type valueType struct {
+	kind    kind
+	proto   string
+	message *message
+}
+
+type field struct {
+	name   string
+	proto  string
+	desc   string
+	number int
+	index  []int
+	shape  shape
+	key    valueType
+	value  valueType
+}
+
+type member struct {
+	name    string
+	proto   string
+	number  int
+	message *message
+}
+
+type message struct {
+	name     string
+	desc     string
+	typ      reflect.Type
+	fields   []*field
+	byNumber map[int]*field
+
+	// wrapper messages are a oneof over the registered implementations of an interface
+	wrapper        bool
+	members        []*member
+	membersByName  map[string]*member
+	membersByIndex map[int]*member
+}
+
+// Schema maps registered models, and the structs they reference, to protobuf messages
+type Schema struct {
+	registry *registry.TypeRegistry
+	lock     *Lock
+	messages []*message
+	byType   map[reflect.Type]*message
+	names    map[string]bool
+}
+
+// NewSchema builds the protobuf messages for the models in r. Field numbers are taken from lock,
+// and fields the lock does not know yet are added to it.
+func NewSchema(r *registry.TypeRegistry, lock *Lock) *Schema {
+	s := &Schema{
+		registry: r,
+		lock:     lock,
+		byType:   make(map[reflect.Type]*message),
+		names:    make(map[string]bool),
+	}
+
+	types := r.GetAllTypes()
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		typ := types[name]
+		if typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Struct {
+			s.structMessage(typ.Elem(), "")
+		}
+	}
+	return s
+}
+
+// Lock returns the lock, including any field numbers assigned by this schema
+func (s *Schema) Lock() *Lock {
+	return s.lock
+}
+
+func (s *Schema) structMessage(typ reflect.Type, fallback string) *message {
+	if msg, ok := s.byType[typ]; ok {
+		return msg
+	}
+
+	name := typ.Name()
+	if name == "" {
+		name = fallback
+	}
+	msg := &message{name: s.messageName(name, typ.PkgPath()), typ: typ, byNumber: make(map[int]*field)}
+	if reflect.PointerTo(typ).Implements(modelType) {
+		msg.desc = reflect.New(typ).Interface().(registry.Model).GetDescription()
+	}
+	// Registered before its fields are resolved, so recursive types terminate
+	s.byType[typ] = msg
+	s.messages = append(s.messages, msg)
+
+	var fields []*field
+	for _, f := range jsonFields(typ) {
+		fields = append(fields, s.field(msg, f))
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].name < fields[j].name })
+
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+	numbers := s.lock.assign(msg.name, names)
+	for _, f := range fields {
+		f.number = numbers[f.name]
+		msg.byNumber[f.number] = f
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].number < fields[j].number })
+	msg.fields = fields
+	uniqueProtoNames(fields)
+	return msg
+}
+
+func (s *Schema) wrapperMessage(iface reflect.Type) *message {
+	if msg, ok := s.byType[iface]; ok {
+		return msg
+	}
+
+	msg := &message{
+		name:           s.messageName(iface.Name()+"Wrapper", iface.PkgPath()),
+		desc:           fmt.Sprintf("A %s, discriminated by its registered type name", iface.Name()),
+		wrapper:        true,
+		membersByName:  make(map[string]*member),
+		membersByIndex: make(map[int]*member),
+	}
+	s.byType[iface] = msg
+	s.messages = append(s.messages, msg)
+
+	var names []string
+	types := s.registry.GetAllTypes()
+	for name, typ := range types {
+		if typ.Implements(iface) && typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Struct {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	numbers := s.lock.assign(msg.name, names)
+	for _, name := range names {
+		m := &member{
+			name:    name,
+			proto:   identifier(name),
+			number:  numbers[name],
+			message: s.structMessage(types[name].Elem(), ""),
+		}
+		msg.members = append(msg.members, m)
+		msg.membersByName[name] = m
+		msg.membersByIndex[m.number] = m
+	}
+	sort.Slice(msg.members, func(i, j int) bool { return msg.members[i].number < msg.members[j].number })
+	return msg
+}
+
+// messageName returns a unique message name, qualifying it with its package on conflict
+func (s *Schema) messageName(name, pkgPath string) string {
+	name = typeName(name)
+	if s.names[name] {
+		name = typeName(path.Base(pkgPath)) + name
+	}
+	unique := name
+	for i := 2; s.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	s.names[unique] = true
+	return unique
+}
+
+func (s *Schema) field(owner *message, f structField) *field {
+	out := &field{name: f.name, proto: identifier(f.name), desc: f.desc, index: f.index}
+	typ := f.typ
+
+	switch {
+	case typ.Kind() == reflect.Ptr:
+		elem, ok := s.value(typ.Elem(), owner.name+typeName(f.name))
+		if !ok || typ.Elem().Kind() == reflect.Ptr {
+			out.value = jsonValue()
+			break
+		}
+		out.shape = shapeOptional
+		out.value = elem
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8 && !isSpecial(typ):
+		elem, ok := s.value(typ.Elem(), owner.name+typeName(f.name))
+		if !ok {
+			out.value = jsonValue()
+			break
+		}
+		out.shape = shapeRepeated
+		out.value = elem
+	case typ.Kind() == reflect.Map && !isSpecial(typ):
+		key, keyOK := s.value(typ.Key(), "")
+		elem, elemOK := s.value(typ.Elem(), owner.name+typeName(f.name))
+		if !keyOK || !elemOK || !isMapKey(key.kind) {
+			out.value = jsonValue()
+			break
+		}
+		out.shape = shapeMap
+		out.key = key
+		out.value = elem
+	default:
+		value, ok := s.value(typ, owner.name+typeName(f.name))
+		if !ok {
+			value = jsonValue()
+		}
+		out.value = value
+	}
+	return out
+}
+
+// value describes a single value of typ. It reports false for values that cannot be a single
+// protobuf value, such as slices and maps.
+func (s *Schema) value(typ reflect.Type, fallback string) (valueType, bool) {
+	if iface, ok := wrappedInterface(typ); ok {
+		msg := s.wrapperMessage(iface)
+		return valueType{kind: kindWrapper, proto: msg.name, message: msg}, true
+	}
+	if isSpecial(typ) {
+		if typ.Implements(textMarshaler) && reflect.PointerTo(typ).Implements(textUnmarshaler) {
+			return valueType{kind: kindText, proto: "string"}, true
+		}
+		return jsonValue(), true
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return valueType{kind: kindBool, proto: "bool"}, true
+	case reflect.Int, reflect.Int64:
+		return valueType{kind: kindInt, proto: "int64"}, true
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return valueType{kind: kindInt, proto: "int32"}, true
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return valueType{kind: kindUint, proto: "uint64"}, true
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return valueType{kind: kindUint, proto: "uint32"}, true
+	case reflect.Float64:
+		return valueType{kind: kindDouble, proto: "double"}, true
+	case reflect.Float32:
+		return valueType{kind: kindFloat, proto: "float"}, true
+	case reflect.String:
+		return valueType{kind: kindString, proto: "string"}, true
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return valueType{kind: kindBytes, proto: "bytes"}, true
+		}
+	case reflect.Struct:
+		msg := s.structMessage(typ, fallback)
+		return valueType{kind: kindMessage, proto: msg.name, message: msg}, true
+	case reflect.Ptr:
+		if typ.Elem().Kind() == reflect.Struct && !isSpecial(typ.Elem()) {
+			if _, ok := wrappedInterface(typ.Elem()); !ok {
+				msg := s.structMessage(typ.Elem(), fallback)
+				return valueType{kind: kindMessage, proto: msg.name, message: msg}, true
+			}
+		}
+	case reflect.Interface, reflect.Array:
+		return jsonValue(), true
+	}
+	return valueType{}, false
+}
+
+func jsonValue() valueType {
+	return valueType{kind: kindJSON, proto: "bytes"}
+}
+
+func isMapKey(k kind) bool {
+	return k == kindBool || k == kindInt || k == kindUint || k == kindString
+}
+
+// isSpecial reports whether typ controls its own encoding through json.Marshaler or encoding.TextMarshaler
+func isSpecial(typ reflect.Type) bool {
+	if _, ok := wrappedInterface(typ); ok {
+		return false
+	}
+	return typ.Implements(jsonMarshaler) || typ.Implements(textMarshaler)
+}
+
+// wrappedInterface returns the interface wrapped by a registry.Wrapper, or a type defined from one
+func wrappedInterface(typ reflect.Type) (reflect.Type, bool) {
+	if typ.Kind() != reflect.Struct || typ.NumField() < 3 {
+		return nil, false
+	}
+	model, typeField, skip := typ.Field(0), typ.Field(1), typ.Field(2)
+	if model.Name != "Model" || typeField.Name != "Type" || skip.Name != "SkipDefaulting" {
+		return nil, false
+	}
+	if model.Type.Kind() != reflect.Interface || !model.Type.Implements(modelType) {
+		return nil, false
+	}
+	return model.Type, true
+}
+
+type structField struct {
+	name  string
+	desc  string
+	typ   reflect.Type
+	index []int
+}
+
+// jsonFields lists the fields of typ as encoding/json sees them. Fields of untagged embedded
+// structs are promoted, and shallower fields take precedence over promoted ones.
+func jsonFields(typ reflect.Type) []structField {
+	var all []structField
+	collectFields(typ, nil, &all)
+
+	byName := make(map[string]int)
+	var out []structField
+	for _, f := range all {
+		if i, ok := byName[f.name]; ok {
+			if len(f.index) < len(out[i].index) {
+				out[i] = f
+			}
+			continue
+		}
+		byName[f.name] = len(out)
+		out = append(out, f)
+	}
+	return out
+}
+
+func collectFields(typ reflect.Type, index []int, out *[]structField) {
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if sf.Anonymous && name == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Ptr {
+				if !sf.IsExported() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				collectFields(embedded, fieldIndex, out)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		*out = append(*out, structField{name: name, desc: sf.Tag.Get("desc"), typ: sf.Type, index: fieldIndex})
+	}
+}
+
+// uniqueProtoNames disambiguates fields whose JSON names map to the same protobuf identifier
+func uniqueProtoNames(fields []*field) {
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if seen[f.proto] {
+			f.proto = fmt.Sprintf("%s_%d", f.proto, f.number)
+		}
+		seen[f.proto] = true
+	}
+}
+
+// identifier converts a JSON or registry name into a protobuf field name
+func identifier(name string) string {
+	name = nonIdentifier.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || name[0] == '_' {
+		name = "f" + name
+	}
+	return name
+}
+
+// typeName converts a Go type name into a protobuf message name
+func typeName(name string) string {
+	var b strings.Builder
+	for _, part := range nonIdentifier.Split(name, -1) {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,170 @@
+package protobuf
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixture interface {
+	registry.Model
+	fixture()
+}
+
+type Embedded struct {
+	Key string `json:"key"`
+}
+
+type Child struct {
+	Value string `json:"value"`
+}
+
+type Sample struct {
+	registry.BaseModel
+	Embedded
+	Name     string                    `json:"name" desc:"The sample name"`
+	Count    int                       `json:"count"`
+	Ratio    float64                   `json:"ratio"`
+	Enabled  bool                      `json:"enabled"`
+	Tags     []string                  `json:"tags"`
+	Ports    []int32                   `json:"ports"`
+	Labels   map[string]int            `json:"labels"`
+	Raw      []byte                    `json:"raw"`
+	When     time.Time                 `json:"when"`
+	Any      any                       `json:"any"`
+	Optional *int64                    `json:"optional"`
+	Child    *Child                    `json:"child"`
+	Children []Child                   `json:"children"`
+	Parent   registry.Wrapper[fixture] `json:"parent"`
+	Ignored  string                    `json:"-"`
+	hidden   string
+}
+
+func (s *Sample) GetDescription() string { return "A sample model" }
+func (s *Sample) fixture()               {}
+
+type Other struct {
+	registry.BaseModel
+	Key string `json:"key"`
+}
+
+func (o *Other) GetDescription() string { return "Another model" }
+func (o *Other) fixture()               {}
+
+func fixtureRegistry() *registry.TypeRegistry {
+	r := registry.NewTypeRegistry()
+	r.MustRegisterModel(&Sample{})
+	r.MustRegisterModel(&Other{}, "alias")
+	return r
+}
+
+func TestSchema_Proto(t *testing.T) {
+	lock := NewLock()
+	// a field removed in the past stays reserved
+	lock.Messages["Other"] = &MessageLock{Fields: map[string]int{"key": 2}, Reserved: []int{1}}
+
+	s := NewSchema(fixtureRegistry(), lock)
+
+	expected := `// Code generated by cmd/codegen. DO NOT EDIT.
+
+syntax = "proto3";
+
+package tabularium;
+
+message Child {
+  string value = 1;
+}
+
+// A fixture, discriminated by its registered type name
+message FixtureWrapper {
+  oneof model {
+    Other alias = 1;
+    Other other = 2;
+    Sample sample = 3;
+  }
+}
+
+// Another model
+message Other {
+  string key = 2;
+  reserved 1;
+}
+
+// A sample model
+message Sample {
+  // JSON-encoded
+  bytes any = 1;
+  Child child = 2;
+  repeated Child children = 3;
+  int64 count = 4;
+  bool enabled = 5;
+  string key = 6;
+  map<string, int64> labels = 7;
+  // The sample name
+  string name = 8;
+  optional int64 optional = 9;
+  FixtureWrapper parent = 10;
+  repeated int32 ports = 11;
+  double ratio = 12;
+  bytes raw = 13;
+  repeated string tags = 14;
+  string when = 15;
+}
+`
+	assert.Equal(t, expected, s.Proto())
+	assert.Equal(t, map[string]int{"alias": 1, "other": 2, "sample": 3}, s.Lock().Messages["FixtureWrapper"].Fields)
+}
+
+func TestSchema_StableNumbers(t *testing.T) {
+	lock := NewLock()
+	// numbers from an older version of Sample, in which name came first
+	lock.Messages["Sample"] = &MessageLock{Fields: map[string]int{"name": 1, "count": 2, "removed": 3}}
+
+	s := NewSchema(fixtureRegistry(), lock)
+	sample := s.byType[reflectType[Sample]()]
+
+	numbers := map[string]int{}
+	for _, f := range sample.fields {
+		numbers[f.name] = f.number
+	}
+	assert.Equal(t, 1, numbers["name"])
+	assert.Equal(t, 2, numbers["count"])
+	assert.NotContains(t, numbers, "removed")
+	for name, number := range numbers {
+		if name != "name" && name != "count" {
+			assert.Greater(t, number, 3, name)
+		}
+	}
+	assert.Equal(t, []int{3}, lock.Messages["Sample"].Reserved)
+}
+
+func TestJSONFields(t *testing.T) {
+	type inner struct {
+		Name  string `json:"name"`
+		Shown string `json:"shown"`
+	}
+	type Tagged struct {
+		Hidden string `json:"hidden"`
+	}
+	type outer struct {
+		inner
+		Tagged `json:"tagged"`
+		Name   string `json:"name"`
+		Plain  int
+		Skip   string `json:"-"`
+		skip   string
+	}
+
+	var names []string
+	for _, f := range jsonFields(reflectType[outer]()) {
+		names = append(names, f.name)
+	}
+	assert.ElementsMatch(t, []string{"name", "shown", "tagged", "Plain"}, names)
+}
+
+func reflectType[T any]() reflect.Type {
+	return reflect.TypeFor[T]()
+}
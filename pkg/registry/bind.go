@@ -0,0 +1,201 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// binder is implemented by Wrapper, and by types defined from it such as model.GraphModelWrapper
+type binder interface {
+	Bind(*TypeRegistry)
+}
+
+var (
+	binderType      = reflect.TypeOf((*binder)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// Unmarshal decodes JSON into v, resolving the types of any wrappers within it with r. Wrappers are
+// bound when they are held directly, through pointers and embedded structs, or as the elements of
+// slices and maps held by the decoded struct.
+func (r *TypeRegistry) Unmarshal(data []byte, v any) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Pointer || val.IsNil() {
+		return json.Unmarshal(data, v)
+	}
+
+	elem := val.Elem()
+	if wrapperCollection(elem.Type()) {
+		return r.unmarshalCollection(data, elem)
+	}
+	r.bind(elem)
+	if elem.Kind() != reflect.Struct || val.Type().Implements(unmarshalerType) {
+		return json.Unmarshal(data, v)
+	}
+
+	collections := wrapperCollections(elem.Type())
+	if len(collections) == 0 {
+		return json.Unmarshal(data, v)
+	}
+
+	// decode the collections separately, since encoding/json creates their elements unbound
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(data, &props); err != nil || props == nil {
+		return json.Unmarshal(data, v)
+	}
+	pending := map[string]json.RawMessage{}
+	for key, value := range props {
+		for name := range collections {
+			if strings.EqualFold(key, name) {
+				pending[name] = value
+				delete(props, key)
+			}
+		}
+	}
+
+	rest, err := json.Marshal(props)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(rest, v); err != nil {
+		return err
+	}
+	for name, value := range pending {
+		field, err := elem.FieldByIndexErr(collections[name])
+		if err != nil {
+			return err
+		}
+		if err := r.unmarshalCollection(value, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalModel is UnmarshalModel, resolving the types of any wrappers within the model with r
+func (r *TypeRegistry) UnmarshalModel(b []byte, model Model) error {
+	defaultModel(model)
+	b, err := r.upgrade(Name(model), b)
+	if err != nil {
+		return err
+	}
+	err = r.Unmarshal(b, model)
+	if err != nil {
+		return err
+	}
+	return callHooks(model)
+}
+
+// bind binds the wrappers reachable from v to r
+func (r *TypeRegistry) bind(v reflect.Value) {
+	if v.CanAddr() && v.Addr().Type().Implements(binderType) {
+		if v.Addr().CanInterface() {
+			v.Addr().Interface().(binder).Bind(r)
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			r.bind(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.IsExported() || field.Anonymous {
+				r.bind(v.Field(i))
+			}
+		}
+	}
+}
+
+// unmarshalCollection decodes a slice or map of wrappers into v, binding each element to r
+func (r *TypeRegistry) unmarshalCollection(data []byte, v reflect.Value) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		v.SetZero()
+		return nil
+	}
+
+	tipe := v.Type()
+	if tipe.Kind() == reflect.Slice {
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		out := reflect.MakeSlice(tipe, len(items), len(items))
+		for i, item := range items {
+			if err := r.unmarshalElem(item, out.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(out)
+		return nil
+	}
+
+	var items map[string]json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	out := reflect.MakeMapWithSize(tipe, len(items))
+	for key, item := range items {
+		elem := reflect.New(tipe.Elem()).Elem()
+		if err := r.unmarshalElem(item, elem); err != nil {
+			return err
+		}
+		out.SetMapIndex(reflect.ValueOf(key).Convert(tipe.Key()), elem)
+	}
+	v.Set(out)
+	return nil
+}
+
+// unmarshalElem decodes a single wrapper, or pointer to a wrapper, into the addressable v
+func (r *TypeRegistry) unmarshalElem(data []byte, v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+	}
+	r.bind(v)
+	return json.Unmarshal(data, v.Addr().Interface())
+}
+
+// wrapperCollection reports whether tipe is a slice, or a map with string keys, of wrappers
+func wrapperCollection(tipe reflect.Type) bool {
+	switch tipe.Kind() {
+	case reflect.Slice:
+	case reflect.Map:
+		if tipe.Key().Kind() != reflect.String {
+			return false
+		}
+	default:
+		return false
+	}
+	elem := tipe.Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	return reflect.PointerTo(elem).Implements(binderType)
+}
+
+// wrapperCollections returns the index of each exported field of tipe holding a collection of
+// wrappers, by the JSON name of the field
+func wrapperCollections(tipe reflect.Type) map[string][]int {
+	var collections map[string][]int
+	for _, field := range reflect.VisibleFields(tipe) {
+		if !field.IsExported() || !wrapperCollection(field.Type) {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if collections == nil {
+			collections = map[string][]int{}
+		}
+		collections[name] = field.Index
+	}
+	return collections
+}
@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scopedModel is only registered with the registries created by these tests
+type scopedModel struct {
+	BaseModel
+	Key      string                     `json:"key"`
+	Child    Wrapper[Model]             `json:"child"`
+	Pointer  *Wrapper[Model]            `json:"pointer"`
+	Children []Wrapper[Model]           `json:"children,omitempty"`
+	ByName   map[string]*Wrapper[Model] `json:"byName,omitempty"`
+}
+
+func (m *scopedModel) GetDescription() string { return "A model registered with a scoped registry" }
+
+func scopedRegistry() *TypeRegistry {
+	r := NewTypeRegistry()
+	r.MustRegisterModel(&scopedModel{})
+	r.MustRegisterModel(&AnotherTestModel{})
+	return r
+}
+
+const scopedJSON = `{
+	"key": "#scopedmodel#parent",
+	"child": {"key": "#scopedmodel#child", "children": [{"type": "anothertestmodel", "model": {"id": "1"}}]},
+	"pointer": {"type": "anothertestmodel", "model": {"id": "2"}},
+	"Children": [{"key": "#scopedmodel#first"}, {"type": "anothertestmodel", "model": {"id": "3"}}],
+	"byName": {"one": {"type": "anothertestmodel", "model": {"id": "4"}}, "none": null}
+}`
+
+func TestTypeRegistry_Unmarshal(t *testing.T) {
+	r := scopedRegistry()
+
+	var m scopedModel
+	m.Pointer = &Wrapper[Model]{}
+	require.NoError(t, r.Unmarshal([]byte(scopedJSON), &m))
+
+	assert.Equal(t, "#scopedmodel#parent", m.Key)
+	child := m.Child.Model.(*scopedModel)
+	assert.Equal(t, "#scopedmodel#child", child.Key)
+	require.Len(t, child.Children, 1)
+	assert.Equal(t, "1", child.Children[0].Model.(*AnotherTestModel).ID)
+	assert.Equal(t, "2", m.Pointer.Model.(*AnotherTestModel).ID)
+	require.Len(t, m.Children, 2)
+	assert.Equal(t, "#scopedmodel#first", m.Children[0].Model.(*scopedModel).Key)
+	assert.Equal(t, "3", m.Children[1].Model.(*AnotherTestModel).ID)
+	require.Len(t, m.ByName, 2)
+	assert.Equal(t, "4", m.ByName["one"].Model.(*AnotherTestModel).ID)
+	assert.Nil(t, m.ByName["none"])
+
+	// the process registry does not know about scopedmodel
+	assert.Error(t, json.Unmarshal([]byte(scopedJSON), &scopedModel{}))
+}
+
+func TestTypeRegistry_Unmarshal_Collections(t *testing.T) {
+	r := scopedRegistry()
+
+	var wrappers []*Wrapper[Model]
+	require.NoError(t, r.Unmarshal([]byte(`[{"key": "#scopedmodel#a"}, null]`), &wrappers))
+	require.Len(t, wrappers, 2)
+	assert.Equal(t, "#scopedmodel#a", wrappers[0].Model.(*scopedModel).Key)
+	assert.Nil(t, wrappers[1])
+
+	require.NoError(t, r.Unmarshal([]byte(`null`), &wrappers))
+	assert.Nil(t, wrappers)
+
+	assert.Error(t, r.Unmarshal([]byte(`{"key": "#scopedmodel#a", "children": {}}`), &scopedModel{}))
+	assert.Error(t, r.Unmarshal([]byte(`[{"key": "#testmodelforwrapper#a"}]`), &wrappers))
+}
+
+func TestTypeRegistry_UnmarshalModel(t *testing.T) {
+	r := scopedRegistry()
+
+	var m scopedModel
+	require.NoError(t, r.UnmarshalModel([]byte(`{"child": {"type": "anothertestmodel", "model": {"id": "1"}}}`), &m))
+	assert.Equal(t, "1", m.Child.Model.(*AnotherTestModel).ID)
+}
+
+func TestWrapper_Bind(t *testing.T) {
+	r := scopedRegistry()
+	input := []byte(`{"type": "scopedmodel", "model": {"child": {"key": "#scopedmodel#child"}}}`)
+
+	var wrapper Wrapper[Model]
+	assert.Error(t, json.Unmarshal(input, &wrapper), "the process registry does not know about scopedmodel")
+
+	wrapper = Wrapper[Model]{}
+	wrapper.Bind(r)
+	require.NoError(t, json.Unmarshal(input, &wrapper))
+	child := wrapper.Model.(*scopedModel).Child.Model.(*scopedModel)
+	assert.Equal(t, "#scopedmodel#child", child.Key)
+
+	// a view only resolves the types it exposes
+	wrapper = Wrapper[Model]{}
+	wrapper.Bind(r.View("anothertestmodel"))
+	assert.Error(t, json.Unmarshal(input, &wrapper))
+	wrapper = Wrapper[Model]{}
+	wrapper.Bind(r.View("anothertestmodel"))
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "anothertestmodel", "model": {"id": "5"}}`), &wrapper))
+	assert.Equal(t, "5", wrapper.Model.(*AnotherTestModel).ID)
+
+	// binding does not change how a wrapper is encoded
+	data, err := json.Marshal(wrapper)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "anothertestmodel", "model": {"id": "5", "label": ""}}`, string(data))
+}
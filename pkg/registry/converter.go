@@ -1,12 +1,20 @@
 package registry
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ConverterFunc converts JSON-encoded capmodel data into a registered Model.
 type ConverterFunc func(data []byte) (Model, error)
 
 // RegisterConverter registers a converter function for the given type name.
 func (r *TypeRegistry) RegisterConverter(name string, fn ConverterFunc) error {
+	if err := r.writable(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if _, ok := r.converters[name]; ok {
 		return fmt.Errorf("converter %s already registered", name)
 	}
@@ -23,9 +31,37 @@ func (r *TypeRegistry) MustRegisterConverter(name string, fn ConverterFunc) {
 
 // Convert looks up and invokes the converter for the given type name.
 func (r *TypeRegistry) Convert(name string, data []byte) (Model, error) {
-	fn, ok := r.converters[name]
+	if !r.exposes(name) {
+		return nil, fmt.Errorf("no converter registered for %s", name)
+	}
+	root := r.root()
+	root.mu.RLock()
+	fn, ok := root.converters[name]
+	root.mu.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("no converter registered for %s", name)
 	}
 	return fn(data)
 }
+
+// HasConverter reports whether a converter is registered for the given type name.
+func (r *TypeRegistry) HasConverter(name string) bool {
+	if !r.exposes(name) {
+		return false
+	}
+	root := r.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	_, ok := root.converters[name]
+	return ok
+}
+
+// exposes reports whether r exposes the type named by the capmodel type name, such as Asset, of a
+// converter or extractor. Views only expose the converters and extractors of the types they keep.
+func (r *TypeRegistry) exposes(name string) bool {
+	if r.parent == nil {
+		return true
+	}
+	_, _, ok := r.lookup(strings.ToLower(name))
+	return ok
+}
@@ -0,0 +1,178 @@
+package registry
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Descriptor describes a registered model: its fields and tags, the well-known interfaces it
+// implements, and what else is registered for it
+type Descriptor struct {
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	Description string            `json:"description"`
+	Aliases     []string          `json:"aliases,omitempty"`
+	Interfaces  []string          `json:"interfaces,omitempty"`
+	Labels      []string          `json:"labels,omitempty"`
+	Hooks       []string          `json:"hooks,omitempty"`
+	Capmodel    []string          `json:"capmodel,omitempty"`
+	Converters  []string          `json:"converters,omitempty"`
+	Extractors  []string          `json:"extractors,omitempty"`
+	Fields      []FieldDescriptor `json:"fields"`
+}
+
+// FieldDescriptor describes a single field of a model. Fields promoted from embedded structs name
+// the struct they are declared in.
+type FieldDescriptor struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Embedded   string `json:"embedded,omitempty"`
+	JSON       string `json:"json,omitempty"`
+	Neo4j      string `json:"neo4j,omitempty"`
+	DynamoDBAV string `json:"dynamodbav,omitempty"`
+	Capmodel   string `json:"capmodel,omitempty"`
+	Desc       string `json:"desc,omitempty"`
+	Example    string `json:"example,omitempty"`
+}
+
+// RegisterInterface records a well-known interface, such as a model package's Target, so that
+// Describe can report which models implement it. T must be an interface type, and r must not be
+// a view.
+func RegisterInterface[T any](r *TypeRegistry) {
+	tipe := reflect.TypeFor[T]()
+	if tipe.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("%s is not an interface", tipe))
+	}
+	if err := r.writable(); err != nil {
+		panic(err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interfaces[tipe.Name()] = tipe
+}
+
+// GetInterface returns the well-known interface registered with RegisterInterface under name
+func (r *TypeRegistry) GetInterface(name string) (reflect.Type, bool) {
+	r = r.root()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	iface, ok := r.interfaces[name]
+	return iface, ok
+}
+
+// Describe returns the descriptor of the registered model name, using the process registry
+func Describe(name string) (*Descriptor, error) {
+	return Registry.Describe(name)
+}
+
+// Describe returns the descriptor of a registered model or alias
+func (r *TypeRegistry) Describe(name string) (*Descriptor, error) {
+	name = strings.ToLower(name)
+	tipe, canonical, ok := r.lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("type %q is not registered", name)
+	}
+	model, _ := r.MakeType(name)
+
+	d := &Descriptor{
+		Name:        canonical,
+		Type:        tipe.Elem().String(),
+		Description: model.GetDescription(),
+		Fields:      describeFields(tipe.Elem(), ""),
+	}
+	if aliases := r.GetAliases(canonical)[1:]; len(aliases) > 0 {
+		d.Aliases = aliases
+		sort.Strings(d.Aliases)
+	}
+
+	root := r.root()
+	root.mu.RLock()
+	for n, iface := range root.interfaces {
+		if tipe.Implements(iface) {
+			d.Interfaces = append(d.Interfaces, n)
+		}
+	}
+	root.mu.RUnlock()
+	sort.Strings(d.Interfaces)
+
+	// zero-valued models are not guaranteed to support these, so a panic leaves them out
+	if labeled, ok := model.(interface{ GetLabels() []string }); ok {
+		d.Labels = recovered(labeled.GetLabels)
+	}
+	for _, hook := range recovered(model.GetHooks) {
+		if hook.Description == "" {
+			hook.Description = "(no description)"
+		}
+		d.Hooks = append(d.Hooks, hook.Description)
+	}
+
+	capmodel := make(map[string]bool)
+	for _, f := range d.Fields {
+		for _, entry := range strings.Split(f.Capmodel, ",") {
+			if capType, _, _ := strings.Cut(entry, "="); strings.TrimSpace(capType) != "" {
+				capmodel[strings.TrimSpace(capType)] = true
+			}
+		}
+	}
+	for capType := range capmodel {
+		d.Capmodel = append(d.Capmodel, capType)
+	}
+	sort.Strings(d.Capmodel)
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	for _, capType := range d.Capmodel {
+		if _, ok := root.converters[capType]; ok {
+			d.Converters = append(d.Converters, capType)
+		}
+		if _, ok := root.extractors[capType]; ok {
+			d.Extractors = append(d.Extractors, capType)
+		}
+	}
+	return d, nil
+}
+
+// describeFields lists the exported fields of tipe, descending into embedded structs
+func describeFields(tipe reflect.Type, embedded string) []FieldDescriptor {
+	fields := []FieldDescriptor{}
+	for i := 0; i < tipe.NumField(); i++ {
+		field := tipe.Field(i)
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && jsonName == "" {
+			inner := field.Type
+			if inner.Kind() == reflect.Ptr {
+				inner = inner.Elem()
+			}
+			if inner.Kind() == reflect.Struct {
+				path := inner.Name()
+				if embedded != "" {
+					path = embedded + "." + path
+				}
+				fields = append(fields, describeFields(inner, path)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		fields = append(fields, FieldDescriptor{
+			Name:       field.Name,
+			Type:       field.Type.String(),
+			Embedded:   embedded,
+			JSON:       field.Tag.Get("json"),
+			Neo4j:      field.Tag.Get("neo4j"),
+			DynamoDBAV: field.Tag.Get("dynamodbav"),
+			Capmodel:   field.Tag.Get("capmodel"),
+			Desc:       field.Tag.Get("desc"),
+			Example:    field.Tag.Get("example"),
+		})
+	}
+	return fields
+}
+
+// recovered calls fn, returning the zero value if it panics
+func recovered[T any](fn func() T) T {
+	defer func() { recover() }()
+	return fn()
+}
@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type describeLabeled interface {
+	Model
+	GetLabels() []string
+}
+
+type describeUnimplemented interface {
+	Model
+	Unimplemented()
+}
+
+type describeHistory struct {
+	Events []string `json:"events,omitempty" neo4j:"events" desc:"Past events."`
+}
+
+type describeModel struct {
+	BaseModel
+	describeHistory
+	Key     string `json:"key" neo4j:"key" dynamodbav:"key" capmodel:"Thing,Other=id" desc:"The key." example:"#thing#1"`
+	Name    string `json:"name" capmodel:"Thing"`
+	Ignored string `json:"-" neo4j:"-"`
+	hidden  string
+}
+
+func (m *describeModel) GetDescription() string { return "A model to describe" }
+func (m *describeModel) GetLabels() []string    { return []string{"Thing"} }
+func (m *describeModel) GetHooks() []Hook {
+	return []Hook{{Description: "normalize the key"}, {}}
+}
+
+type describePanics struct {
+	BaseModel
+	Inner *describeModel
+}
+
+func (m *describePanics) GetDescription() string { return "A model whose zero value panics" }
+func (m *describePanics) GetLabels() []string    { return []string{m.Inner.Key} }
+
+func TestTypeRegistry_Describe(t *testing.T) {
+	r := NewTypeRegistry()
+	r.MustRegisterModel(&describeModel{}, "thing")
+	RegisterInterface[describeLabeled](r)
+	RegisterInterface[describeUnimplemented](r)
+	r.MustRegisterConverter("Thing", func([]byte) (Model, error) { return &describeModel{}, nil })
+	r.MustRegisterExtractor("Other", func(Model) (any, error) { return nil, nil })
+
+	expected := &Descriptor{
+		Name:        "describemodel",
+		Type:        "registry.describeModel",
+		Description: "A model to describe",
+		Aliases:     []string{"thing"},
+		Interfaces:  []string{"describeLabeled"},
+		Labels:      []string{"Thing"},
+		Hooks:       []string{"normalize the key", "(no description)"},
+		Capmodel:    []string{"Other", "Thing"},
+		Converters:  []string{"Thing"},
+		Extractors:  []string{"Other"},
+		Fields: []FieldDescriptor{
+			{Name: "Events", Type: "[]string", Embedded: "describeHistory", JSON: "events,omitempty", Neo4j: "events", Desc: "Past events."},
+			{Name: "Key", Type: "string", JSON: "key", Neo4j: "key", DynamoDBAV: "key", Capmodel: "Thing,Other=id", Desc: "The key.", Example: "#thing#1"},
+			{Name: "Name", Type: "string", JSON: "name", Capmodel: "Thing"},
+			{Name: "Ignored", Type: "string", JSON: "-", Neo4j: "-"},
+		},
+	}
+
+	d, err := r.Describe("describeModel")
+	require.NoError(t, err)
+	assert.Equal(t, expected, d)
+
+	// aliases describe the model they belong to
+	d, err = r.Describe("thing")
+	require.NoError(t, err)
+	assert.Equal(t, expected, d)
+
+	_, err = r.Describe("missing")
+	assert.Error(t, err)
+}
+
+func TestTypeRegistry_Describe_Panics(t *testing.T) {
+	r := NewTypeRegistry()
+	r.MustRegisterModel(&describePanics{})
+
+	d, err := r.Describe("describepanics")
+	require.NoError(t, err)
+	assert.Nil(t, d.Labels)
+	assert.Equal(t, []FieldDescriptor{{Name: "Inner", Type: "*registry.describeModel"}}, d.Fields)
+}
+
+func TestRegisterInterface_NotAnInterface(t *testing.T) {
+	assert.Panics(t, func() { RegisterInterface[describeModel](NewTypeRegistry()) })
+}
@@ -0,0 +1,32 @@
+package registry
+
+import (
+	"reflect"
+	"slices"
+)
+
+// RegisterEnum records the values of a set of typed constants, such as a status type, so that
+// schema generators can emit them as an enum. Values registered for the same type accumulate.
+// It panics if r is a view.
+func RegisterEnum[T comparable](r *TypeRegistry, values ...T) {
+	if err := r.writable(); err != nil {
+		panic(err)
+	}
+	tipe := reflect.TypeFor[T]()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, value := range values {
+		if !slices.Contains(r.enums[tipe], any(value)) {
+			r.enums[tipe] = append(r.enums[tipe], value)
+		}
+	}
+}
+
+// GetEnum returns the values registered for the constant type tipe
+func (r *TypeRegistry) GetEnum(tipe reflect.Type) ([]any, bool) {
+	r = r.root()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	values, ok := r.enums[tipe]
+	return slices.Clone(values), ok
+}
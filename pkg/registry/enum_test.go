@@ -0,0 +1,27 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type enumTestStatus string
+
+func TestRegisterEnum(t *testing.T) {
+	r := NewTypeRegistry()
+
+	_, ok := r.GetEnum(reflect.TypeFor[enumTestStatus]())
+	assert.False(t, ok)
+
+	RegisterEnum(r, enumTestStatus("active"), enumTestStatus("paused"))
+	RegisterEnum(r, enumTestStatus("paused"), enumTestStatus("expired"))
+
+	values, ok := r.GetEnum(reflect.TypeFor[enumTestStatus]())
+	assert.True(t, ok)
+	assert.Equal(t, []any{enumTestStatus("active"), enumTestStatus("paused"), enumTestStatus("expired")}, values)
+
+	_, ok = r.GetEnum(reflect.TypeFor[string]())
+	assert.False(t, ok, "enums are keyed by their named type")
+}
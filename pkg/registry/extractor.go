@@ -14,6 +14,11 @@ type ExtractorFunc func(m Model) (any, error)
 
 // RegisterExtractor registers an extractor function for the given type name.
 func (r *TypeRegistry) RegisterExtractor(name string, fn ExtractorFunc) error {
+	if err := r.writable(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if _, ok := r.extractors[name]; ok {
 		return fmt.Errorf("extractor %s already registered", name)
 	}
@@ -30,9 +35,27 @@ func (r *TypeRegistry) MustRegisterExtractor(name string, fn ExtractorFunc) {
 
 // Extract looks up and invokes the extractor for the given type name.
 func (r *TypeRegistry) Extract(name string, m Model) (any, error) {
-	fn, ok := r.extractors[name]
+	if !r.exposes(name) {
+		return nil, fmt.Errorf("no extractor registered for %s", name)
+	}
+	root := r.root()
+	root.mu.RLock()
+	fn, ok := root.extractors[name]
+	root.mu.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("no extractor registered for %s", name)
 	}
 	return fn(m)
 }
+
+// HasExtractor reports whether an extractor is registered for the given type name.
+func (r *TypeRegistry) HasExtractor(name string) bool {
+	if !r.exposes(name) {
+		return false
+	}
+	root := r.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	_, ok := root.extractors[name]
+	return ok
+}
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -126,4 +127,21 @@ func TestGetTypes_EdgeCases(t *testing.T) {
 		expected := []string{"gettypestestmodela"}
 		assert.ElementsMatch(t, expected, result)
 	})
+
+	t.Run("types registered after a lookup", func(t *testing.T) {
+		r := NewTypeRegistry()
+		r.MustRegisterModel(&getTypesTestModelA{}, "a")
+		view := r.View("gettypestestmodela", "gettypestestmodelb")
+		assert.ElementsMatch(t, []string{"gettypestestmodela", "a"}, GetTypes[Model](r))
+		assert.ElementsMatch(t, []string{"gettypestestmodela", "a"}, GetTypes[Model](view))
+
+		r.MustRegisterModel(&getTypesTestModelB{})
+		r.MustRegisterModel(&getTypesTestModelC{})
+		assert.ElementsMatch(t, []string{"gettypestestmodela", "a", "gettypestestmodelb", "gettypestestmodelc"}, GetTypes[Model](r))
+		assert.ElementsMatch(t, []string{"gettypestestmodela", "a", "gettypestestmodelb"}, GetTypes[Model](view))
+		assert.ElementsMatch(t, []string{"gettypestestmodela", "a"}, GetTypes[getTypesTestInterface](view))
+		assert.True(t, view.implements("gettypestestmodelb", reflect.TypeFor[Model]()))
+		assert.False(t, view.implements("gettypestestmodelc", reflect.TypeFor[Model]()))
+		assert.False(t, view.implements("gettypestestmodelb", reflect.TypeFor[getTypesTestInterface]()))
+	})
 }
@@ -0,0 +1,49 @@
+package registry
+
+import "reflect"
+
+// CallMethod invokes the named method of model, such as Merge or Visit, with other as its argument,
+// reporting whether model has a one-argument method of that name accepting other. Models declare
+// these methods with differing parameter types (Assetlike, Risk, Webpage, any), so other is passed
+// as a pointer or a value depending on what the method accepts. The error is the method's last
+// result, if that is an error.
+func CallMethod(model Model, method string, other Model) (bool, error) {
+	fn, arg, ok := bindMethod(model, method, other)
+	if !ok {
+		return false, nil
+	}
+
+	results := fn.Call([]reflect.Value{arg})
+	if len(results) == 0 {
+		return true, nil
+	}
+	if err, ok := results[len(results)-1].Interface().(error); ok {
+		return true, err
+	}
+	return true, nil
+}
+
+// AcceptsMethod reports whether CallMethod would call the named method of model with other
+func AcceptsMethod(model Model, method string, other Model) bool {
+	_, _, ok := bindMethod(model, method, other)
+	return ok
+}
+
+// bindMethod returns the named method of model and the argument it takes for other
+func bindMethod(model Model, method string, other Model) (reflect.Value, reflect.Value, bool) {
+	fn := reflect.ValueOf(model).MethodByName(method)
+	if !fn.IsValid() || fn.Type().NumIn() != 1 {
+		return reflect.Value{}, reflect.Value{}, false
+	}
+
+	param := fn.Type().In(0)
+	arg := reflect.ValueOf(other)
+	switch {
+	case arg.Type().AssignableTo(param):
+	case arg.Kind() == reflect.Pointer && arg.Elem().Type().AssignableTo(param):
+		arg = arg.Elem()
+	default:
+		return reflect.Value{}, reflect.Value{}, false
+	}
+	return fn, arg, true
+}
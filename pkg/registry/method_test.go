@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mergeable struct {
+	BaseModel
+	Name string
+}
+
+func (m *mergeable) GetDescription() string { return "A model with Merge and Visit methods" }
+
+// Merge takes its argument by value, so CallMethod passes the model a pointer refers to
+func (m *mergeable) Merge(other mergeable) { m.Name = other.Name }
+
+func (m *mergeable) Visit(other *mergeable) error {
+	if other.Name == "" {
+		return assert.AnError
+	}
+	m.Name += other.Name
+	return nil
+}
+
+func TestCallMethod(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		other   Model
+		applied bool
+		err     error
+		result  string
+	}{
+		{"value parameter", "Merge", &mergeable{Name: "b"}, true, nil, "b"},
+		{"pointer parameter", "Visit", &mergeable{Name: "b"}, true, nil, "ab"},
+		{"error result", "Visit", &mergeable{}, true, assert.AnError, "a"},
+		{"mismatched parameter", "Merge", &TestModel{}, false, nil, "a"},
+		{"missing method", "Apply", &mergeable{Name: "b"}, false, nil, "a"},
+		{"method without a parameter", "GetKey", &mergeable{Name: "b"}, false, nil, "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := &mergeable{Name: "a"}
+			assert.Equal(t, tt.applied, AcceptsMethod(stored, tt.method, tt.other))
+			applied, err := CallMethod(stored, tt.method, tt.other)
+			assert.Equal(t, tt.applied, applied)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.result, stored.Name)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SchemaVersionField is the JSON property holding the schema version a model was written with.
+// Models with migrations declare a field for it, and default it to their current version.
+const SchemaVersionField = "schema_version"
+
+// Migration upgrades the JSON properties of a model in place, from one schema version to the next
+type Migration func(props map[string]any) error
+
+type replacement struct {
+	name    string
+	upgrade Migration
+}
+
+// RegisterMigration registers the upgrade of the named model's properties from schema version
+// from to from+1. Migrations must be registered in order, starting from version 0; a model's
+// current version is one past its last migration.
+func (r *TypeRegistry) RegisterMigration(name string, from int, fn Migration) error {
+	if err := r.writable(); err != nil {
+		return err
+	}
+	name = strings.ToLower(name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current := len(r.migrations[name]); from != current {
+		return fmt.Errorf("migration of %s from version %d registered, expected version %d", name, from, current)
+	}
+	r.migrations[name] = append(r.migrations[name], fn)
+	return nil
+}
+
+// MustRegisterMigration registers a migration, panicking on failure
+func (r *TypeRegistry) MustRegisterMigration(name string, from int, fn Migration) {
+	if err := r.RegisterMigration(name, from, fn); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterReplacement records that the named legacy model has been replaced. Once a wrapped legacy
+// model is upgraded to its current version, fn converts its properties to those of the replacement,
+// and decoding continues as the replacement. fn should set SchemaVersionField if the replacement
+// has migrations; otherwise they are all applied to its output.
+func (r *TypeRegistry) RegisterReplacement(legacy, replacedBy string, fn Migration) error {
+	if err := r.writable(); err != nil {
+		return err
+	}
+	legacy = strings.ToLower(legacy)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.replacements[legacy]; ok {
+		return fmt.Errorf("replacement of %s already registered", legacy)
+	}
+	r.replacements[legacy] = replacement{name: strings.ToLower(replacedBy), upgrade: fn}
+	return nil
+}
+
+// MustRegisterReplacement registers a replacement, panicking on failure
+func (r *TypeRegistry) MustRegisterReplacement(legacy, replacedBy string, fn Migration) {
+	if err := r.RegisterReplacement(legacy, replacedBy, fn); err != nil {
+		panic(err)
+	}
+}
+
+// SchemaVersion returns the current schema version of the named model
+func (r *TypeRegistry) SchemaVersion(name string) int {
+	r = r.root()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.migrations[r.canonical(strings.ToLower(name))])
+}
+
+// Migrate upgrades the properties of the named model to its current schema version. If the model
+// has been replaced, the properties are converted to the replacement, whose name is returned.
+// Properties written by a newer version than this registry knows are left as they are.
+func (r *TypeRegistry) Migrate(name string, props map[string]any) (string, error) {
+	return r.migrate(name, props, true)
+}
+
+func (r *TypeRegistry) migrate(name string, props map[string]any, replace bool) (string, error) {
+	root := r.root()
+	seen := map[string]bool{}
+	for {
+		root.mu.RLock()
+		canonical := root.canonical(strings.ToLower(name))
+		steps := root.migrations[canonical]
+		next, replaced := root.replacements[canonical]
+		root.mu.RUnlock()
+
+		version, err := schemaVersion(props)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+		for ; version < len(steps); version++ {
+			if err := steps[version](props); err != nil {
+				return "", fmt.Errorf("failed to migrate %s from version %d: %w", name, version, err)
+			}
+			props[SchemaVersionField] = version + 1
+		}
+
+		if !replace || !replaced {
+			return name, nil
+		}
+		if seen[canonical] {
+			return "", fmt.Errorf("replacements of %s form a cycle", name)
+		}
+		seen[canonical] = true
+		if err := next.upgrade(props); err != nil {
+			return "", fmt.Errorf("failed to replace %s with %s: %w", name, next.name, err)
+		}
+		name = next.name
+	}
+}
+
+// upgrade migrates the JSON encoding of the named model, returning data unchanged when the model
+// has no migrations
+func (r *TypeRegistry) upgrade(name string, data []byte) ([]byte, error) {
+	if r.SchemaVersion(name) == 0 {
+		return data, nil
+	}
+	var props map[string]any
+	if err := json.Unmarshal(data, &props); err != nil || props == nil {
+		return data, nil
+	}
+	if _, err := r.migrate(name, props, false); err != nil {
+		return nil, err
+	}
+	return json.Marshal(props)
+}
+
+// Migrates reports whether the named model has migrations or has been replaced, so that its
+// properties must pass through Migrate before they are decoded
+func (r *TypeRegistry) Migrates(name string) bool {
+	root := r.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	canonical := root.canonical(strings.ToLower(name))
+	_, replaced := root.replacements[canonical]
+	return len(root.migrations[canonical]) > 0 || replaced
+}
+
+// canonical returns the registered name of the model name is an alias of, or name itself. The
+// caller must hold r's lock.
+func (r *TypeRegistry) canonical(name string) string {
+	if n, ok := r.aliases[name]; ok {
+		return n
+	}
+	return name
+}
+
+// schemaVersion returns the schema version recorded in props, which is 0 when there is none
+func schemaVersion(props map[string]any) (int, error) {
+	switch version := props[SchemaVersionField].(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return int(version), nil
+	case int:
+		return version, nil
+	case json.Number:
+		v, err := version.Int64()
+		return int(v), err
+	default:
+		return 0, fmt.Errorf("invalid %s %v", SchemaVersionField, version)
+	}
+}
@@ -0,0 +1,186 @@
+package registry
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type versionedModel struct {
+	BaseModel
+	Key           string   `json:"key"`
+	Name          string   `json:"name"`
+	Tags          []string `json:"tags"`
+	SchemaVersion int      `json:"schema_version,omitempty"`
+}
+
+func (m *versionedModel) GetDescription() string { return "A model with migrations" }
+func (m *versionedModel) Defaulted()             { m.SchemaVersion = 2 }
+
+type legacyModel struct {
+	BaseModel
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+func (m *legacyModel) GetDescription() string { return "A model replaced by versionedModel" }
+
+func migrationRegistry() *TypeRegistry {
+	r := NewTypeRegistry()
+	r.MustRegisterModel(&versionedModel{}, "versioned")
+	r.MustRegisterModel(&legacyModel{})
+	// version 0 stored the name as title, and version 1 stored tags as a comma separated string
+	r.MustRegisterMigration("versionedmodel", 0, func(props map[string]any) error {
+		props["name"] = props["title"]
+		delete(props, "title")
+		return nil
+	})
+	r.MustRegisterMigration("versionedModel", 1, func(props map[string]any) error {
+		if tags, ok := props["tags"].(string); ok {
+			props["tags"] = strings.Split(tags, ",")
+		}
+		return nil
+	})
+	r.MustRegisterMigration("legacymodel", 0, func(props map[string]any) error {
+		props["label"] = strings.ToUpper(props["label"].(string))
+		return nil
+	})
+	r.MustRegisterReplacement("legacymodel", "versionedmodel", func(props map[string]any) error {
+		props["name"] = props["label"]
+		props[SchemaVersionField] = 1
+		delete(props, "label")
+		return nil
+	})
+	return r
+}
+
+func TestTypeRegistry_RegisterMigration(t *testing.T) {
+	r := migrationRegistry()
+	assert.Equal(t, 2, r.SchemaVersion("versionedmodel"))
+	assert.Equal(t, 2, r.SchemaVersion("Versioned"))
+	assert.Equal(t, 1, r.SchemaVersion("legacymodel"))
+	assert.Equal(t, 0, r.SchemaVersion("missing"))
+	assert.True(t, r.Migrates("Versioned"))
+	assert.True(t, r.View().Migrates("legacymodel"))
+	r.MustRegisterModel(&TestModelForWrapper{})
+	assert.False(t, r.Migrates("testmodelforwrapper"))
+
+	assert.Error(t, r.RegisterMigration("versionedmodel", 1, nil), "version 1 is already migrated")
+	assert.Error(t, r.RegisterMigration("legacymodel", 3, nil), "version 2 has no migration")
+	assert.Error(t, r.RegisterReplacement("legacymodel", "versionedmodel", nil))
+	assert.Error(t, r.View().RegisterMigration("legacymodel", 1, nil))
+	assert.Error(t, r.View().RegisterReplacement("versionedmodel", "legacymodel", nil))
+}
+
+func TestTypeRegistry_Migrate(t *testing.T) {
+	r := migrationRegistry()
+
+	tests := []struct {
+		name     string
+		model    string
+		props    string
+		expected string
+		tipe     string
+	}{
+		{
+			name:     "from version 0",
+			model:    "versionedmodel",
+			props:    `{"title": "first", "tags": "a,b"}`,
+			expected: `{"name": "first", "tags": ["a", "b"], "schema_version": 2}`,
+			tipe:     "versionedmodel",
+		},
+		{
+			name:     "from version 1",
+			model:    "versioned",
+			props:    `{"name": "second", "tags": "c", "schema_version": 1}`,
+			expected: `{"name": "second", "tags": ["c"], "schema_version": 2}`,
+			tipe:     "versioned",
+		},
+		{
+			name:     "current",
+			model:    "versionedmodel",
+			props:    `{"name": "third", "tags": ["d"], "schema_version": 2}`,
+			expected: `{"name": "third", "tags": ["d"], "schema_version": 2}`,
+			tipe:     "versionedmodel",
+		},
+		{
+			name:     "newer than the registry",
+			model:    "versionedmodel",
+			props:    `{"name": "fourth", "tags": {"e": true}, "schema_version": 3}`,
+			expected: `{"name": "fourth", "tags": {"e": true}, "schema_version": 3}`,
+			tipe:     "versionedmodel",
+		},
+		{
+			name:     "replaced",
+			model:    "legacymodel",
+			props:    `{"key": "#legacymodel#1", "label": "fifth", "tags": "f,g"}`,
+			expected: `{"key": "#legacymodel#1", "name": "FIFTH", "tags": ["f", "g"], "schema_version": 2}`,
+			tipe:     "versionedmodel",
+		},
+		{
+			name:     "without migrations",
+			model:    "asset",
+			props:    `{"title": "sixth"}`,
+			expected: `{"title": "sixth"}`,
+			tipe:     "asset",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var props map[string]any
+			require.NoError(t, json.Unmarshal([]byte(tt.props), &props))
+			tipe, err := r.Migrate(tt.model, props)
+			require.NoError(t, err)
+			assert.Equal(t, tt.tipe, tipe)
+			actual, err := json.Marshal(props)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(actual))
+		})
+	}
+}
+
+func TestTypeRegistry_Migrate_Errors(t *testing.T) {
+	r := migrationRegistry()
+	r.MustRegisterMigration("asset", 0, func(map[string]any) error { return assert.AnError })
+	r.MustRegisterReplacement("versionedmodel", "legacymodel", func(map[string]any) error { return nil })
+
+	_, err := r.Migrate("asset", map[string]any{})
+	assert.ErrorIs(t, err, assert.AnError)
+	_, err = r.Migrate("versionedmodel", map[string]any{SchemaVersionField: "one"})
+	assert.Error(t, err)
+	_, err = r.Migrate("legacymodel", map[string]any{"label": "cycle"})
+	assert.ErrorContains(t, err, "cycle")
+}
+
+func TestTypeRegistry_UnmarshalModel_Migrates(t *testing.T) {
+	r := migrationRegistry()
+
+	var m versionedModel
+	require.NoError(t, r.UnmarshalModel([]byte(`{"key": "#versionedmodel#1", "title": "old", "tags": "a,b"}`), &m))
+	assert.Equal(t, versionedModel{Key: "#versionedmodel#1", Name: "old", Tags: []string{"a", "b"}, SchemaVersion: 2}, m)
+
+	// replacements only apply to wrappers, which may change type
+	var legacy legacyModel
+	require.NoError(t, r.UnmarshalModel([]byte(`{"key": "#legacymodel#1", "label": "old"}`), &legacy))
+	assert.Equal(t, "OLD", legacy.Label)
+
+	assert.Error(t, r.UnmarshalModel([]byte(`{"schema_version": "one"}`), &versionedModel{}))
+}
+
+func TestWrapper_Migrates(t *testing.T) {
+	r := migrationRegistry()
+
+	var wrapper Wrapper[Model]
+	wrapper.Bind(r)
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "legacymodel", "model": {"key": "#legacymodel#1", "label": "old", "tags": "a"}}`), &wrapper))
+	assert.Equal(t, "versionedmodel", wrapper.Type)
+	assert.Equal(t, &versionedModel{Key: "#legacymodel#1", Name: "OLD", Tags: []string{"a"}, SchemaVersion: 2}, wrapper.Model)
+
+	wrapper = Wrapper[Model]{}
+	wrapper.Bind(r)
+	require.NoError(t, json.Unmarshal([]byte(`{"key": "#versionedmodel#2", "title": "older"}`), &wrapper))
+	assert.Equal(t, "older", wrapper.Model.(*versionedModel).Name)
+}
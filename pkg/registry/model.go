@@ -33,12 +33,17 @@ func (BaseModel) Defaulted() {}
 
 // UnmarshalModel unmarshals a model, by:
 //   - setting its default field values
+//   - upgrading the JSON to the model's current schema version
 //   - unmarshalling into the model
 //   - calling the model's hooks
 //   - recursively calling hooks on any submodels
 func UnmarshalModel(b []byte, model Model) error {
 	defaultModel(model)
-	err := json.Unmarshal(b, model)
+	b, err := Registry.upgrade(Name(model), b)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(b, model)
 	if err != nil {
 		return err
 	}
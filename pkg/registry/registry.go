@@ -3,8 +3,10 @@ package registry
 import (
 	"encoding/gob"
 	"fmt"
+	"maps"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 // Registry is a singleton type registry for this process
@@ -38,22 +40,71 @@ func Name(model Model) string {
 	return strings.ToLower(tipe.Name())
 }
 
-// TypeRegistry holds information about all registered types
+// TypeRegistry holds information about all registered types. It is safe for concurrent use.
 type TypeRegistry struct {
-	types      map[string]reflect.Type
-	aliases    map[string]string
-	converters map[string]ConverterFunc
-	extractors map[string]ExtractorFunc
+	mu           sync.RWMutex
+	types        map[string]reflect.Type
+	aliases      map[string]string
+	converters   map[string]ConverterFunc
+	extractors   map[string]ExtractorFunc
+	enums        map[reflect.Type][]any
+	interfaces   map[string]reflect.Type
+	migrations   map[string][]Migration
+	replacements map[string]replacement
+
+	// implementers indexes the names of the types that are, or implement, a type, for GetTypes and
+	// Wrapper. It is built as types are asked for, and reset when a model is registered.
+	implementers map[reflect.Type]map[string]bool
+
+	// views have a parent, and expose only the parent's types that keep accepts
+	parent *TypeRegistry
+	keep   func(name string, tipe reflect.Type) bool
 }
 
 // NewTypeRegistry creates a new type registry
 func NewTypeRegistry() *TypeRegistry {
 	return &TypeRegistry{
-		types:      make(map[string]reflect.Type),
-		aliases:    make(map[string]string),
-		converters: make(map[string]ConverterFunc),
-		extractors: make(map[string]ExtractorFunc),
+		types:        make(map[string]reflect.Type),
+		aliases:      make(map[string]string),
+		converters:   make(map[string]ConverterFunc),
+		extractors:   make(map[string]ExtractorFunc),
+		enums:        make(map[reflect.Type][]any),
+		interfaces:   make(map[string]reflect.Type),
+		migrations:   make(map[string][]Migration),
+		replacements: make(map[string]replacement),
+	}
+}
+
+// View returns a read-only registry exposing only the named types of r, and their aliases
+func (r *TypeRegistry) View(names ...string) *TypeRegistry {
+	allowed := make(map[string]bool, len(names))
+	for _, name := range names {
+		allowed[strings.ToLower(name)] = true
+	}
+	return r.ViewFunc(func(name string, _ reflect.Type) bool { return allowed[name] })
+}
+
+// ViewFunc returns a read-only registry exposing only the types of r for which keep returns true.
+// keep is called with the registered name of a type, never an alias; an alias is exposed when the
+// type it belongs to is. The view is live: models registered with r later are filtered the same way.
+func (r *TypeRegistry) ViewFunc(keep func(name string, tipe reflect.Type) bool) *TypeRegistry {
+	return &TypeRegistry{parent: r, keep: keep}
+}
+
+// root returns the registry that r is a view of, or r itself
+func (r *TypeRegistry) root() *TypeRegistry {
+	for r.parent != nil {
+		r = r.parent
 	}
+	return r
+}
+
+// writable returns an error if r is a view
+func (r *TypeRegistry) writable() error {
+	if r.parent != nil {
+		return fmt.Errorf("registry view is read-only")
+	}
+	return nil
 }
 
 // MustRegisterModel registers a model, and panics on failure. Useful for registering models in init()
@@ -68,15 +119,21 @@ func (r *TypeRegistry) MustRegisterModel(model Model, aliases ...string) {
 // It returns an error if the type is already registered or if it doesn't
 // implement the registry.Model interface.
 func (r *TypeRegistry) RegisterModel(model Model, aliases ...string) error {
+	if err := r.writable(); err != nil {
+		return err
+	}
 	gob.Register(model)
 	tipe := reflect.TypeOf(model)
 	name := Name(model)
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if _, ok := r.types[name]; ok {
 		return fmt.Errorf("type %s already registered", name)
 	}
 
 	r.types[name] = tipe
+	r.implementers = nil
 	for _, alias := range aliases {
 		r.types[strings.ToLower(alias)] = tipe
 		r.aliases[strings.ToLower(alias)] = name
@@ -86,7 +143,16 @@ func (r *TypeRegistry) RegisterModel(model Model, aliases ...string) error {
 }
 
 func (r *TypeRegistry) GetAliases(name string) []string {
+	if r.parent != nil {
+		if _, _, ok := r.lookup(name); !ok {
+			return []string{name}
+		}
+		return r.parent.GetAliases(name)
+	}
+
 	aliases := []string{name}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	for alias, n := range r.aliases {
 		if n == name {
 			aliases = append(aliases, alias)
@@ -95,16 +161,35 @@ func (r *TypeRegistry) GetAliases(name string) []string {
 	return aliases
 }
 
+// lookup returns the type registered under name, and the name of the model it was registered as
+func (r *TypeRegistry) lookup(name string) (reflect.Type, string, bool) {
+	if r.parent != nil {
+		typ, canonical, ok := r.parent.lookup(name)
+		if !ok || !r.keep(canonical, typ) {
+			return nil, "", false
+		}
+		return typ, canonical, true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	typ, ok := r.types[name]
+	if !ok {
+		return nil, "", false
+	}
+	return typ, r.canonical(name), true
+}
+
 // GetType returns the registered type for a given name
 func (r *TypeRegistry) GetType(name string) (reflect.Type, bool) {
-	typ, ok := r.types[name]
+	typ, _, ok := r.lookup(name)
 	return typ, ok
 }
 
 // MakeType returns an instance of the registered type for a given name
 func (r *TypeRegistry) MakeType(name string) (Model, bool) {
 	name = strings.ToLower(name)
-	typ, ok := r.types[name]
+	typ, ok := r.GetType(name)
 	if !ok {
 		return nil, false
 	}
@@ -117,19 +202,71 @@ func (r *TypeRegistry) MakeType(name string) (Model, bool) {
 	return model, true
 }
 
-// GetAllTypes returns all registered types
+// GetAllTypes returns all registered types. The returned map is a copy, and may be modified.
 func (r *TypeRegistry) GetAllTypes() map[string]reflect.Type {
-	return r.types
+	if r.parent != nil {
+		types := r.parent.GetAllTypes()
+		maps.DeleteFunc(types, func(name string, _ reflect.Type) bool {
+			_, _, ok := r.lookup(name)
+			return !ok
+		})
+		return types
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.types)
 }
 
 // GetTypes retrieves all type names from a registry that have type T, or implement T
 func GetTypes[T Model](r *TypeRegistry) []string {
 	out := []string{}
-	for name, tipe := range r.GetAllTypes() {
-		tt := reflect.TypeOf((*T)(nil)).Elem()
-		if tt.AssignableTo(tipe) || (tt.Kind() == reflect.Interface && tipe.Implements(tt)) {
+	for name := range r.root().implementersOf(reflect.TypeFor[T]()) {
+		if r.parent == nil {
+			out = append(out, name)
+		} else if _, _, ok := r.lookup(name); ok {
 			out = append(out, name)
 		}
 	}
 	return out
 }
+
+// implements reports whether the type registered under name is tt, or implements it
+func (r *TypeRegistry) implements(name string, tt reflect.Type) bool {
+	if !r.root().implementersOf(tt)[name] {
+		return false
+	}
+	if r.parent != nil {
+		_, _, ok := r.lookup(name)
+		return ok
+	}
+	return true
+}
+
+// implementersOf returns the names, including aliases, of the types registered with r that are tt
+// or implement it. The returned map is shared, and must not be modified.
+func (r *TypeRegistry) implementersOf(tt reflect.Type) map[string]bool {
+	r.mu.RLock()
+	names, ok := r.implementers[tt]
+	r.mu.RUnlock()
+	if ok {
+		return names
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if names, ok := r.implementers[tt]; ok {
+		return names
+	}
+	names = map[string]bool{}
+	for name, tipe := range r.types {
+		if tt.AssignableTo(tipe) || (tt.Kind() == reflect.Interface && tipe.Implements(tt)) {
+			names[name] = true
+		}
+	}
+	if r.implementers == nil {
+		r.implementers = map[reflect.Type]map[string]bool{}
+	}
+	r.implementers[tt] = names
+	return names
+}
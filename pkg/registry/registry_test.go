@@ -1,6 +1,11 @@
 package registry
 
 import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -74,3 +79,94 @@ func TestTypeRegistry_MakeType(t *testing.T) {
 		})
 	}
 }
+
+func TestTypeRegistry_View(t *testing.T) {
+	r := NewTypeRegistry()
+	r.MustRegisterModel(&asset{}, "alias1")
+	r.MustRegisterModel(&TestModelForWrapper{})
+
+	view := r.View("Asset")
+	_, ok := view.GetType("asset")
+	assert.True(t, ok)
+	_, ok = view.GetType("alias1")
+	assert.True(t, ok, "aliases follow the type they belong to")
+	_, ok = view.MakeType("testmodelforwrapper")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"alias1", "asset"}, sortedKeys(view.GetAllTypes()))
+	assert.Equal(t, []string{"asset", "alias1"}, view.GetAliases("asset"))
+	assert.Equal(t, []string{"testmodelforwrapper"}, GetTypes[*TestModelForWrapper](r))
+	assert.Empty(t, GetTypes[*TestModelForWrapper](view))
+
+	_, err := view.Describe("testmodelforwrapper")
+	assert.Error(t, err)
+	assert.Error(t, view.RegisterModel(&AnotherTestModel{}))
+	assert.Error(t, view.RegisterConverter("Asset", nil))
+	assert.Error(t, view.RegisterExtractor("Asset", nil))
+	assert.Panics(t, func() { RegisterEnum(view, "value") })
+
+	// converters and extractors follow the types the view exposes
+	convert := func([]byte) (Model, error) { return &asset{}, nil }
+	extract := func(Model) (any, error) { return nil, nil }
+	r.MustRegisterConverter("Asset", convert)
+	r.MustRegisterConverter("TestModelForWrapper", convert)
+	r.MustRegisterExtractor("Asset", extract)
+	r.MustRegisterExtractor("TestModelForWrapper", extract)
+	assert.True(t, view.HasConverter("Asset"))
+	assert.True(t, view.HasExtractor("Asset"))
+	assert.False(t, view.HasConverter("TestModelForWrapper"))
+	assert.False(t, view.HasExtractor("TestModelForWrapper"))
+	_, err = view.Convert("TestModelForWrapper", nil)
+	assert.Error(t, err)
+	_, err = view.Extract("TestModelForWrapper", &TestModelForWrapper{})
+	assert.Error(t, err)
+	_, err = view.Convert("Asset", nil)
+	assert.NoError(t, err)
+
+	// views are live, and may be narrowed further
+	narrowed := r.ViewFunc(func(name string, _ reflect.Type) bool { return name != "asset" })
+	r.MustRegisterModel(&AnotherTestModel{})
+	assert.Equal(t, []string{"anothertestmodel", "testmodelforwrapper"}, sortedKeys(narrowed.GetAllTypes()))
+	assert.Equal(t, []string{"anothertestmodel"}, sortedKeys(narrowed.View("anothertestmodel", "asset").GetAllTypes()))
+}
+
+func TestTypeRegistry_GetAllTypesCopy(t *testing.T) {
+	r := NewTypeRegistry()
+	r.MustRegisterModel(&asset{})
+	delete(r.GetAllTypes(), "asset")
+	_, ok := r.GetType("asset")
+	assert.True(t, ok)
+}
+
+func TestTypeRegistry_Concurrent(t *testing.T) {
+	r := NewTypeRegistry()
+	view := r.View("asset")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			alias := fmt.Sprintf("concurrent%d", i)
+			_ = r.RegisterModel(&asset{}, alias)
+			_ = r.RegisterConverter(alias, func([]byte) (Model, error) { return &asset{}, nil })
+			RegisterEnum(r, i)
+			for j := 0; j < 100; j++ {
+				r.MakeType(alias)
+				view.GetAllTypes()
+				GetTypes[Model](r)
+				_, _ = r.Convert(alias, nil)
+				_, _ = view.Describe("asset")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	values, _ := r.GetEnum(reflect.TypeFor[int]())
+	assert.Len(t, values, 8)
+	assert.Len(t, view.GetAllTypes(), 2)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := slices.Collect(maps.Keys(m))
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,161 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// DefaultMaxLineSize is the longest line a StreamDecoder reads unless its MaxLineSize is set
+const DefaultMaxLineSize = 16 << 20
+
+// StreamEncoder writes models as newline-delimited JSON, one {"type", "model"} envelope per line,
+// so that streams may mix models of any registered type
+type StreamEncoder struct {
+	enc *json.Encoder
+}
+
+// NewStreamEncoder returns an encoder writing to w
+func NewStreamEncoder(w io.Writer) *StreamEncoder {
+	return &StreamEncoder{enc: json.NewEncoder(w)}
+}
+
+// Encode writes model as a single line
+func (e *StreamEncoder) Encode(model Model) error {
+	if model == nil {
+		return fmt.Errorf("cannot encode a nil model")
+	}
+	return e.enc.Encode(Wrapper[Model]{Model: model, Type: Name(model)})
+}
+
+// LineError is an error decoding a single line of a stream. The lines after it can still be read.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// StreamDecoder reads models written by a StreamEncoder, or any newline-delimited JSON whose lines a
+// Wrapper can decode. Each model is defaulted and has its hooks called, as UnmarshalModel does.
+type StreamDecoder struct {
+	// MaxLineSize bounds the memory used for a line; longer lines are skipped with a LineError.
+	// DefaultMaxLineSize is used if it is zero.
+	MaxLineSize int
+
+	reader   *bufio.Reader
+	registry *TypeRegistry
+	line     int
+	buf      []byte
+	err      error
+}
+
+// NewStreamDecoder returns a decoder reading from r
+func NewStreamDecoder(r io.Reader) *StreamDecoder {
+	return &StreamDecoder{reader: bufio.NewReader(r)}
+}
+
+// Bind resolves the types of decoded models with r rather than the process Registry
+func (d *StreamDecoder) Bind(r *TypeRegistry) *StreamDecoder {
+	d.registry = r
+	return d
+}
+
+// Decode returns the model on the next line that is not blank. A line that cannot be decoded
+// returns a *LineError, and the following call moves on to the next line. Decode returns io.EOF at
+// the end of the stream, and any error reading it on every later call.
+func (d *StreamDecoder) Decode() (Model, error) {
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		wrapper := Wrapper[Model]{registry: d.registry, hooks: true}
+		if err := json.Unmarshal(line, &wrapper); err != nil {
+			return nil, &LineError{Line: d.line, Err: err}
+		}
+		if wrapper.Model == nil {
+			return nil, &LineError{Line: d.line, Err: fmt.Errorf("line holds no model")}
+		}
+		return wrapper.Model, nil
+	}
+}
+
+// All iterates over the models in the stream, yielding each line that cannot be decoded as a
+// *LineError. It stops at the end of the stream, or after yielding an error reading it.
+func (d *StreamDecoder) All() iter.Seq2[Model, error] {
+	return func(yield func(Model, error) bool) {
+		for {
+			model, err := d.Decode()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(model, err) {
+				return
+			}
+			var lineErr *LineError
+			if err != nil && !errors.As(err, &lineErr) {
+				return
+			}
+		}
+	}
+}
+
+// readLine returns the next line without its line ending. Lines longer than MaxLineSize are read
+// through but not kept, and return a *LineError.
+func (d *StreamDecoder) readLine() ([]byte, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	limit := d.MaxLineSize
+	if limit <= 0 {
+		limit = DefaultMaxLineSize
+	}
+
+	d.buf = d.buf[:0]
+	read, tooLong := false, false
+	for {
+		chunk, err := d.reader.ReadSlice('\n')
+		read = read || len(chunk) > 0
+		if !tooLong && len(d.buf)+len(bytes.TrimRight(chunk, "\r\n")) > limit {
+			tooLong = true
+			d.buf = d.buf[:0]
+		}
+		if !tooLong {
+			d.buf = append(d.buf, chunk...)
+		}
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			d.err = err
+			return nil, err
+		}
+		if err != nil && !read {
+			d.err = io.EOF
+			return nil, io.EOF
+		}
+		break
+	}
+
+	d.line++
+	if tooLong {
+		return nil, &LineError{Line: d.line, Err: fmt.Errorf("line is longer than %d bytes", limit)}
+	}
+	return bytes.TrimSpace(d.buf), nil
+}
@@ -0,0 +1,174 @@
+package registry
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func streamRegistry() *TypeRegistry {
+	r := NewTypeRegistry()
+	r.MustRegisterModel(&TestModelForWrapper{})
+	r.MustRegisterModel(&AnotherTestModel{})
+	r.MustRegisterModel(&OuterModel{})
+	r.MustRegisterModel(&TestModel{})
+	r.MustRegisterModel(&TestModelWithErrorHook{})
+	return r
+}
+
+func TestStream_RoundTrip(t *testing.T) {
+	models := []Model{
+		&TestModelForWrapper{Name: "first", Value: 1},
+		&AnotherTestModel{ID: "2", Label: "second"},
+		&TestModelForWrapper{Name: "third", Value: 3, Type: "custom"},
+	}
+
+	var buf bytes.Buffer
+	enc := NewStreamEncoder(&buf)
+	for _, model := range models {
+		require.NoError(t, enc.Encode(model))
+	}
+	assert.Error(t, enc.Encode(nil))
+	assert.Equal(t, len(models), strings.Count(buf.String(), "\n"))
+	assert.True(t, strings.HasPrefix(buf.String(), `{"model":{"name":"first","value":1,"type":""},"type":"testmodelforwrapper"}`+"\n"))
+
+	var decoded []Model
+	for model, err := range NewStreamDecoder(&buf).Bind(streamRegistry()).All() {
+		require.NoError(t, err)
+		decoded = append(decoded, model)
+	}
+	assert.Equal(t, models, decoded)
+}
+
+func TestStreamDecoder_Defaults(t *testing.T) {
+	input := `{"type": "outermodel", "model": {"name": "outer", "inner": {"name": "inner"}}}
+{"key": "#testmodel#1", "name": "flat"}`
+
+	dec := NewStreamDecoder(strings.NewReader(input)).Bind(streamRegistry())
+	model, err := dec.Decode()
+	require.NoError(t, err)
+	outer := model.(*OuterModel)
+	assert.True(t, outer.DefaultedCalled)
+	assert.True(t, outer.Inner.DefaultedCalled, "submodels are defaulted")
+	assert.Equal(t, "outer", outer.Key, "hooks are called")
+	assert.Equal(t, "inner", outer.Inner.Key, "hooks of submodels are called")
+
+	model, err = dec.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, "flat", model.(*TestModel).Name)
+	assert.Equal(t, 42, model.(*TestModel).Value)
+	assert.True(t, model.(*TestModel).hookCalled)
+
+	_, err = dec.Decode()
+	assert.ErrorIs(t, err, io.EOF)
+	_, err = dec.Decode()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestStreamDecoder_LineErrors(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type": "testmodelforwrapper", "model": {"name": "one"}}`,
+		`{invalid`,
+		``,
+		`   `,
+		`{"type": "unknowntype", "model": {}}`,
+		`{"type": "testmodelwitherrorhook", "model": {"name": "hook"}}`,
+		`{"model": null}`,
+		`null`,
+		`{"type": "testmodelforwrapper", "model": {"name": "` + strings.Repeat("x", 100) + `"}}`,
+		`{"type": "anothertestmodel", "model": {"id": "last"}}` + "\r",
+	}, "\n")
+
+	dec := NewStreamDecoder(strings.NewReader(input)).Bind(streamRegistry())
+	dec.MaxLineSize = 80
+
+	var names []string
+	var lines []int
+	for model, err := range dec.All() {
+		if err != nil {
+			var lineErr *LineError
+			require.ErrorAs(t, err, &lineErr)
+			lines = append(lines, lineErr.Line)
+			continue
+		}
+		names = append(names, Name(model))
+	}
+	assert.Equal(t, []string{"testmodelforwrapper", "anothertestmodel"}, names)
+	assert.Equal(t, []int{2, 5, 6, 7, 8, 9}, lines)
+}
+
+func TestStreamDecoder_LongLines(t *testing.T) {
+	long := `{"type": "testmodelforwrapper", "model": {"name": "` + strings.Repeat("x", 10000) + `"}}`
+	input := long + "\n" + long
+
+	// lines longer than the reader's buffer are assembled from several reads
+	dec := NewStreamDecoder(iotest.OneByteReader(strings.NewReader(input))).Bind(streamRegistry())
+	for range 2 {
+		model, err := dec.Decode()
+		require.NoError(t, err)
+		assert.Len(t, model.(*TestModelForWrapper).Name, 10000)
+	}
+
+	dec = NewStreamDecoder(strings.NewReader(input + "\n" + `{"type": "anothertestmodel", "model": {}}`))
+	dec.MaxLineSize = len(long) - 1
+	_, err := dec.Decode()
+	assert.ErrorContains(t, err, "line 1: line is longer than")
+	_, err = dec.Decode()
+	assert.ErrorContains(t, err, "line 2: line is longer than")
+	model, err := dec.Decode()
+	require.NoError(t, err)
+	assert.IsType(t, &AnotherTestModel{}, model)
+	assert.Less(t, cap(dec.buf), len(long)+4096, "skipped lines are not kept")
+}
+
+func TestStreamDecoder_ReadError(t *testing.T) {
+	input := io.MultiReader(
+		strings.NewReader(`{"type": "anothertestmodel", "model": {"id": "1"}}`+"\n"),
+		iotest.ErrReader(errors.New("connection reset")),
+	)
+
+	var models []Model
+	var errs []error
+	for model, err := range NewStreamDecoder(input).All() {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		models = append(models, model)
+	}
+	assert.Len(t, models, 1)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "connection reset")
+}
+
+func TestStreamDecoder_Stop(t *testing.T) {
+	input := strings.Repeat(`{"type": "anothertestmodel", "model": {}}`+"\n", 3)
+	dec := NewStreamDecoder(strings.NewReader(input))
+	for range dec.All() {
+		break
+	}
+	count := 0
+	for _, err := range dec.All() {
+		require.NoError(t, err)
+		count++
+	}
+	assert.Equal(t, 2, count, "iteration resumes after the lines already read")
+}
+
+func TestStreamDecoder_View(t *testing.T) {
+	input := `{"type": "testmodelforwrapper", "model": {}}
+{"type": "anothertestmodel", "model": {}}`
+
+	dec := NewStreamDecoder(strings.NewReader(input)).Bind(Registry.View("anothertestmodel"))
+	_, err := dec.Decode()
+	assert.ErrorContains(t, err, "line 1: provided type")
+	model, err := dec.Decode()
+	require.NoError(t, err)
+	assert.IsType(t, &AnotherTestModel{}, model)
+}
@@ -1,10 +1,10 @@
 package registry
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"reflect"
-	"slices"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -17,6 +17,24 @@ type Wrapper[T Model] struct {
 	Model          T      `dynamodbav:"model" json:"model"`
 	Type           string `dynamodbav:"type" json:"type"`
 	SkipDefaulting bool   `dynamodbav:"-" json:"-"`
+
+	// registry resolves types when unmarshalling; the process Registry is used if it is nil
+	registry *TypeRegistry
+	// hooks defaults submodels and calls hooks when unmarshalling, as UnmarshalModel does
+	hooks bool
+}
+
+// Bind makes the wrapper resolve types with r when it is unmarshalled, instead of the process
+// Registry. Wrappers within the unmarshalled model are bound to r as well.
+func (t *Wrapper[T]) Bind(r *TypeRegistry) {
+	t.registry = r
+}
+
+func (t *Wrapper[T]) types() *TypeRegistry {
+	if t.registry != nil {
+		return t.registry
+	}
+	return Registry
 }
 
 func (t Wrapper[T]) MarshalJSON() ([]byte, error) {
@@ -28,8 +46,10 @@ func (t Wrapper[T]) MarshalJSON() ([]byte, error) {
 	return json.Marshal(alias)
 }
 
+// UnmarshalJSON finds the type of the wrapped model from the wrapper's type, or the key of the
+// wrapper or its model, and decodes the model in a single pass
 func (t *Wrapper[T]) UnmarshalJSON(data []byte) error {
-	props := map[string]any{}
+	props := map[string]json.RawMessage{}
 	if err := json.Unmarshal(data, &props); err != nil {
 		return err
 	}
@@ -44,23 +64,17 @@ func (t *Wrapper[T]) UnmarshalJSON(data []byte) error {
 	}
 	t.Type = tipe
 
-	fromModel := func(model any) error {
-		if m, ok := model.(map[string]any); ok {
-			return t.fromProps(m)
-		} else {
-			return nil
-		}
-	}
-
 	model, ok := props["model"]
-	if ok {
-		return fromModel(model)
+	if !ok {
+		model, ok = props["Model"]
 	}
-	model, ok = props["Model"]
-	if ok {
-		return fromModel(model)
+	if !ok {
+		return t.decode(data)
+	}
+	if !isObject(model) {
+		return nil
 	}
-	return t.fromProps(props)
+	return t.decode(model)
 }
 
 func (t Wrapper[T]) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
@@ -72,6 +86,8 @@ func (t Wrapper[T]) MarshalDynamoDBAttributeValue() (types.AttributeValue, error
 	return attributevalue.Marshal(alias)
 }
 
+// UnmarshalDynamoDBAttributeValue decodes the wrapper from a DynamoDB map through its JSON form, so
+// that types are found as UnmarshalJSON finds them
 func (t *Wrapper[T]) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
 	if _, ok := av.(*types.AttributeValueMemberNULL); ok {
 		return nil
@@ -88,67 +104,45 @@ func (t *Wrapper[T]) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) er
 		return err
 	}
 
-	tipe, err := t.getType(props)
-	if tipe == "" && t.isEmpty(props) {
-		return nil
-	}
-
+	data, err := json.Marshal(props)
 	if err != nil {
 		return err
 	}
-	t.Type = tipe
-
-	fromModel := func(model any) error {
-		if m, ok := model.(map[string]any); ok {
-			return t.fromProps(m)
-		} else {
-			return nil
-		}
-	}
-
-	model, ok := props["model"]
-	if ok {
-		return fromModel(model)
-	}
-	model, ok = props["Model"]
-	if ok {
-		return fromModel(model)
-	}
-
-	return t.fromProps(props)
+	return t.UnmarshalJSON(data)
 }
 
-func (t *Wrapper[T]) isEmpty(props map[string]any) bool {
+func (t *Wrapper[T]) isEmpty(props map[string]json.RawMessage) bool {
 	model, ok := props["model"]
-	if ok && model == nil {
+	if ok && isNull(model) {
 		return true
 	}
 
 	return len(props) == 0
 }
 
-func (t *Wrapper[T]) getType(props map[string]any) (string, error) {
+func (t *Wrapper[T]) getType(props map[string]json.RawMessage) (string, error) {
 	if t.Type != "" {
 		return t.Type, nil
 		// DynamoDB uses title case by default, and most of our models define dynamodb field tags
 		// Furthermore, JSON uses lowercase, so we need to check both title-case and lowercase for any field that could be defined on the model
 		// Fortunately, of these fields, only "key" is relevant here, so we must check both 'Key' and 'key'
-	} else if k, ok := props["Key"].(string); ok {
+	} else if k, ok := stringProp(props, "Key"); ok {
 		v := strings.Split(k, "#")
 		if len(v) >= 2 && v[1] != "" {
 			return v[1], nil
 		}
-	} else if k, ok := props["key"].(string); ok {
+	} else if k, ok := stringProp(props, "key"); ok {
 		v := strings.Split(k, "#")
 		if len(v) >= 2 && v[1] != "" {
 			return v[1], nil
 		}
-	} else if t, ok := props["type"].(string); ok && t != "" {
+	} else if t, ok := stringProp(props, "type"); ok && t != "" {
 		return t, nil
 	}
 
-	if model, ok := props["model"]; ok {
-		if m, ok := model.(map[string]any); ok {
+	if model, ok := props["model"]; ok && isObject(model) {
+		m := map[string]json.RawMessage{}
+		if err := json.Unmarshal(model, &m); err == nil {
 			return t.getType(m)
 		}
 	}
@@ -156,36 +150,78 @@ func (t *Wrapper[T]) getType(props map[string]any) (string, error) {
 	return "", fmt.Errorf("wrapper contains neither type nor key with type")
 }
 
-func (t *Wrapper[T]) fromProps(props map[string]any) error {
-	tipe := t.Type
-	tipes := GetTypes[T](Registry)
-	if !slices.Contains(tipes, strings.ToLower(tipe)) {
-		return fmt.Errorf("provided type %q not known or does not implement %T", tipe, t.Model)
+// decode unmarshals data into a new model of type t.Type. Its properties are first migrated if the
+// type has migrations or has been replaced, or if they may hold a schema version to be checked.
+func (t *Wrapper[T]) decode(data []byte) error {
+	r := t.types()
+	if r.Migrates(t.Type) || bytes.Contains(data, []byte(`"`+SchemaVersionField+`"`)) {
+		var props map[string]any
+		if err := json.Unmarshal(data, &props); err != nil {
+			return err
+		}
+		if props != nil {
+			tipe, err := r.Migrate(t.Type, props)
+			if err != nil {
+				return err
+			}
+			t.Type = tipe
+			if data, err = json.Marshal(props); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !r.implements(strings.ToLower(t.Type), reflect.TypeFor[T]()) {
+		return fmt.Errorf("provided type %q not known or does not implement %T", t.Type, t.Model)
 	}
 
-	model, ok := Registry.MakeType(tipe)
+	model, ok := r.MakeType(t.Type)
 	if !ok {
-		return fmt.Errorf("failed to make type %v", tipe)
+		return fmt.Errorf("failed to make type %v", t.Type)
 	}
 
-	if !t.SkipDefaulting {
+	switch {
+	case t.SkipDefaulting:
+	case t.hooks:
+		defaultModel(model)
+	default:
 		model.Defaulted()
 	}
 
 	t.Model, ok = model.(T)
 	if !ok {
-		return fmt.Errorf("failed to convert %v to %T", tipe, t.Model)
+		return fmt.Errorf("failed to convert %v to %T", t.Type, t.Model)
 	}
 
-	bytes, err := json.Marshal(props)
-	if err != nil {
+	var err error
+	if t.registry != nil {
+		err = t.registry.Unmarshal(data, t.Model)
+	} else {
+		err = json.Unmarshal(data, t.Model)
+	}
+	if err != nil || !t.hooks {
 		return err
 	}
+	return callHooks(t.Model)
+}
 
-	err = json.Unmarshal(bytes, t.Model)
-	if err != nil {
-		return err
+// stringProp returns the named property if it is a JSON string
+func stringProp(props map[string]json.RawMessage, name string) (string, bool) {
+	raw, ok := props[name]
+	if !ok || !bytes.HasPrefix(bytes.TrimSpace(raw), []byte(`"`)) {
+		return "", false
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", false
 	}
+	return s, true
+}
+
+func isObject(raw json.RawMessage) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{"))
+}
 
-	return nil
+func isNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
 }
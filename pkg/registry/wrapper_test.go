@@ -2,9 +2,16 @@ package registry
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -313,3 +320,263 @@ func TestWrapper_MarshalNil(t *testing.T) {
 	_, err := json.Marshal(wrapper)
 	require.NoError(t, err)
 }
+
+// legacyWrapper decodes wrappers as Wrapper did before it decoded in a single pass, through a map
+// of properties that is marshalled again for the model. Wrapper must decode every payload as it did.
+type legacyWrapper[T Model] struct {
+	Wrapper[T]
+}
+
+func (t *legacyWrapper[T]) UnmarshalJSON(data []byte) error {
+	props := map[string]any{}
+	if err := json.Unmarshal(data, &props); err != nil {
+		return err
+	}
+
+	tipe, err := t.getType(props)
+	if tipe == "" && t.isEmpty(props) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+	t.Type = tipe
+
+	fromModel := func(model any) error {
+		if m, ok := model.(map[string]any); ok {
+			return t.fromProps(m)
+		}
+		return nil
+	}
+
+	model, ok := props["model"]
+	if ok {
+		return fromModel(model)
+	}
+	model, ok = props["Model"]
+	if ok {
+		return fromModel(model)
+	}
+	return t.fromProps(props)
+}
+
+func (t *legacyWrapper[T]) isEmpty(props map[string]any) bool {
+	model, ok := props["model"]
+	if ok && model == nil {
+		return true
+	}
+	return len(props) == 0
+}
+
+func (t *legacyWrapper[T]) getType(props map[string]any) (string, error) {
+	if t.Type != "" {
+		return t.Type, nil
+	} else if k, ok := props["Key"].(string); ok {
+		v := strings.Split(k, "#")
+		if len(v) >= 2 && v[1] != "" {
+			return v[1], nil
+		}
+	} else if k, ok := props["key"].(string); ok {
+		v := strings.Split(k, "#")
+		if len(v) >= 2 && v[1] != "" {
+			return v[1], nil
+		}
+	} else if t, ok := props["type"].(string); ok && t != "" {
+		return t, nil
+	}
+
+	if model, ok := props["model"]; ok {
+		if m, ok := model.(map[string]any); ok {
+			return t.getType(m)
+		}
+	}
+
+	return "", fmt.Errorf("wrapper contains neither type nor key with type")
+}
+
+func (t *legacyWrapper[T]) fromProps(props map[string]any) error {
+	tipe, err := t.types().Migrate(t.Type, props)
+	if err != nil {
+		return err
+	}
+	t.Type = tipe
+
+	// scan the registry as GetTypes did before it was indexed
+	var tipes []string
+	tt := reflect.TypeFor[T]()
+	for name, candidate := range t.types().GetAllTypes() {
+		if tt.AssignableTo(candidate) || (tt.Kind() == reflect.Interface && candidate.Implements(tt)) {
+			tipes = append(tipes, name)
+		}
+	}
+	if !slices.Contains(tipes, strings.ToLower(tipe)) {
+		return fmt.Errorf("provided type %q not known or does not implement %T", tipe, t.Model)
+	}
+
+	model, ok := t.types().MakeType(tipe)
+	if !ok {
+		return fmt.Errorf("failed to make type %v", tipe)
+	}
+	if !t.SkipDefaulting {
+		model.Defaulted()
+	}
+	t.Model, ok = model.(T)
+	if !ok {
+		return fmt.Errorf("failed to convert %v to %T", tipe, t.Model)
+	}
+
+	data, err := json.Marshal(props)
+	if err != nil {
+		return err
+	}
+	if t.registry != nil {
+		return t.registry.Unmarshal(data, t.Model)
+	}
+	return json.Unmarshal(data, t.Model)
+}
+
+var wrapperPayloads = []string{
+	`{"type": "testmodelforwrapper", "model": {"name": "test", "value": 42}}`,
+	`{"type": "TestModelForWrapper", "model": {"name": "test"}}`,
+	`{"Key": "#testmodelforwrapper#test", "model": {"name": "test"}}`,
+	`{"key": "#anothertestmodel#1", "model": {"id": "1", "label": "one"}}`,
+	`{"Key": "#", "key": "#testmodelforwrapper#test", "model": {"name": "test"}}`,
+	`{"Key": 1, "key": "#testmodelforwrapper#test", "model": {"name": "test"}}`,
+	`{"key": "#testmodelforwrapper#test", "type": "anothertestmodel", "model": {"name": "test"}}`,
+	`{"key": "invalid", "type": "testmodelforwrapper", "model": {"name": "test"}}`,
+	`{"key": "invalid", "model": {"type": "anothertestmodel", "id": "2"}}`,
+	`{"model": {"key": "#anothertestmodel#3", "id": "3"}}`,
+	`{"model": {"model": {"type": "testmodelforwrapper"}}}`,
+	`{"key": "#testmodelforwrapper#flat", "name": "flat", "value": 7}`,
+	`{"type": "testmodelforwrapper", "name": "flat", "value": 7}`,
+	`{"type": "testmodelforwrapper", "Model": {"name": "titled"}}`,
+	`{"type": "testmodelforwrapper", "model": {"name": "lower"}, "Model": {"name": "titled"}}`,
+	`{"type": "testmodelforwrapper", "model": "not a model"}`,
+	`{"type": "testmodelforwrapper", "model": []}`,
+	`{"type": "testmodelforwrapper", "model": null}`,
+	`{"model": null}`,
+	`{}`,
+	`null`,
+	`[]`,
+	`{"type": ""}`,
+	`{"type": 1, "model": {}}`,
+	`{"type": "unknowntype", "model": {}}`,
+	`{"type": "testmodelforwrapper", "model": {"value": "not a number"}}`,
+	`{"type": "testmodelforwrapper", "model": {"value": 12345678901234567890}}`,
+	`{"type": "testmodelforwrapper", "model": {"schema_version": "one"}}`,
+	`{"type": "testmodelforwrapper", "model": {"schema_version": 1, "name": "versioned"}}`,
+	`{"type": "testmodelforwrapper", "model": {"name": "dup", "name": "licate"}}`,
+	`{invalid`,
+}
+
+func TestWrapper_UnmarshalJSON_MatchesLegacy(t *testing.T) {
+	check := func(t *testing.T, payload string, bind func(*Wrapper[Model])) {
+		var expected legacyWrapper[Model]
+		var actual Wrapper[Model]
+		bind(&expected.Wrapper)
+		bind(&actual)
+		expectedErr := json.Unmarshal([]byte(payload), &expected)
+		actualErr := json.Unmarshal([]byte(payload), &actual)
+
+		assert.Equal(t, expectedErr == nil, actualErr == nil, "legacy: %v, current: %v", expectedErr, actualErr)
+		assert.Equal(t, expected.Type, actual.Type)
+		assert.Equal(t, expected.Model, actual.Model)
+	}
+
+	for _, payload := range wrapperPayloads {
+		t.Run(payload, func(t *testing.T) {
+			check(t, payload, func(*Wrapper[Model]) {})
+			check(t, payload, func(w *Wrapper[Model]) { w.SkipDefaulting = true })
+			check(t, payload, func(w *Wrapper[Model]) { w.Type = "anothertestmodel" })
+			check(t, payload, func(w *Wrapper[Model]) { w.Bind(Registry.View("anothertestmodel")) })
+		})
+	}
+}
+
+func TestWrapper_UnmarshalJSON_MatchesLegacy_Concrete(t *testing.T) {
+	for _, payload := range wrapperPayloads {
+		var expected legacyWrapper[*AnotherTestModel]
+		var actual Wrapper[*AnotherTestModel]
+		expectedErr := json.Unmarshal([]byte(payload), &expected)
+		actualErr := json.Unmarshal([]byte(payload), &actual)
+
+		assert.Equal(t, expectedErr == nil, actualErr == nil, "%s: legacy: %v, current: %v", payload, expectedErr, actualErr)
+		assert.Equal(t, expected.Type, actual.Type, payload)
+		assert.Equal(t, expected.Model, actual.Model, payload)
+	}
+}
+
+func TestWrapper_UnmarshalJSON_MatchesLegacy_Migrations(t *testing.T) {
+	payloads := []string{
+		`{"type": "legacymodel", "model": {"key": "#legacymodel#1", "label": "old", "tags": "a"}}`,
+		`{"key": "#versionedmodel#2", "title": "older"}`,
+		`{"type": "versioned", "model": {"name": "aliased", "schema_version": 2}}`,
+		`{"type": "versionedmodel", "model": {"schema_version": "two"}}`,
+		`{"type": "versionedmodel", "model": {}}`,
+	}
+	r := migrationRegistry()
+	for _, payload := range payloads {
+		var expected legacyWrapper[Model]
+		var actual Wrapper[Model]
+		expected.Bind(r)
+		actual.Bind(r)
+		expectedErr := json.Unmarshal([]byte(payload), &expected)
+		actualErr := json.Unmarshal([]byte(payload), &actual)
+
+		assert.Equal(t, expectedErr == nil, actualErr == nil, "%s: legacy: %v, current: %v", payload, expectedErr, actualErr)
+		assert.Equal(t, expected.Type, actual.Type, payload)
+		assert.Equal(t, expected.Model, actual.Model, payload)
+	}
+}
+
+func TestWrapper_UnmarshalDynamoDBAttributeValue(t *testing.T) {
+	for _, payload := range []string{
+		`{"Key": "#testmodelforwrapper#test", "Model": {"name": "test", "value": 42}}`,
+		`{"type": "anothertestmodel", "model": {"id": "1"}}`,
+		`{"key": "#anothertestmodel#1", "id": "1"}`,
+		`{"model": null}`,
+	} {
+		var props map[string]any
+		require.NoError(t, json.Unmarshal([]byte(payload), &props))
+		av, err := attributevalue.Marshal(props)
+		require.NoError(t, err)
+
+		var expected, actual Wrapper[Model]
+		require.NoError(t, json.Unmarshal([]byte(payload), &expected), payload)
+		require.NoError(t, actual.UnmarshalDynamoDBAttributeValue(av), payload)
+		assert.Equal(t, expected, actual, payload)
+	}
+
+	var wrapper Wrapper[Model]
+	assert.NoError(t, wrapper.UnmarshalDynamoDBAttributeValue(&types.AttributeValueMemberNULL{Value: true}))
+	assert.Error(t, wrapper.UnmarshalDynamoDBAttributeValue(&types.AttributeValueMemberS{Value: "model"}))
+}
+
+func BenchmarkWrapper_UnmarshalJSON(b *testing.B) {
+	batch := []byte(`[` + strings.Join([]string{
+		`{"type": "testmodelforwrapper", "model": {"name": "test", "value": 42}}`,
+		`{"key": "#anothertestmodel#1", "model": {"id": "1", "label": "one"}}`,
+		`{"model": {"key": "#anothertestmodel#2", "id": "2", "label": "two"}}`,
+		`{"key": "#testmodelforwrapper#flat", "name": "flat", "value": 7}`,
+	}, ",") + `]`)
+
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var wrappers []legacyWrapper[Model]
+			if err := json.Unmarshal(batch, &wrappers); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("current", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var wrappers []Wrapper[Model]
+			if err := json.Unmarshal(batch, &wrappers); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,278 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Severity classifies a schema change by its effect on existing clients
+type Severity string
+
+const (
+	// SeverityBreaking changes can break clients generated from the base schema
+	SeverityBreaking Severity = "breaking"
+	// SeverityAdditive changes are compatible with clients generated from the base schema
+	SeverityAdditive Severity = "additive"
+)
+
+// Kinds of schema change reported by DiffOpenAPISchemas
+const (
+	ChangeModelRemoved    = "model_removed"
+	ChangeModelAdded      = "model_added"
+	ChangePropertyRemoved = "property_removed"
+	ChangePropertyAdded   = "property_added"
+	ChangeTypeChanged     = "type_changed"
+	ChangeRequiredAdded   = "required_added"
+	ChangeRequiredRemoved = "required_removed"
+	ChangeEnumRemoved     = "enum_value_removed"
+	ChangeEnumAdded       = "enum_value_added"
+	ChangeVariantRemoved  = "variant_removed"
+	ChangeVariantAdded    = "variant_added"
+)
+
+// Change is a single difference between two OpenAPI documents
+type Change struct {
+	Severity Severity `json:"severity"`
+	Kind     string   `json:"kind"`
+	Model    string   `json:"model"`
+	Path     string   `json:"path,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// DiffReport lists the changes between a base and a head schema
+type DiffReport struct {
+	Breaking bool     `json:"breaking"`
+	Changes  []Change `json:"changes"`
+}
+
+// LoadOpenAPISchema reads an OpenAPI document, such as client/api.yaml, from disk
+func LoadOpenAPISchema(path string) (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI schema %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// DiffOpenAPISchemas compares the component schemas of two OpenAPI documents. Removed models
+// (including aliases) and properties, type changes, newly required properties, removed enum
+// values and removed wrapper variants are breaking; everything else is additive. Descriptions
+// and examples are ignored.
+func DiffOpenAPISchemas(base, head *openapi3.T) DiffReport {
+	d := &differ{}
+	baseSchemas, headSchemas := componentSchemas(base), componentSchemas(head)
+
+	for _, name := range sortedKeys(baseSchemas) {
+		headSchema, ok := headSchemas[name]
+		if !ok {
+			d.add(SeverityBreaking, ChangeModelRemoved, name, "", "model %s was removed", name)
+			continue
+		}
+		d.compare(name, "", baseSchemas[name], headSchema)
+	}
+	for _, name := range sortedKeys(headSchemas) {
+		if _, ok := baseSchemas[name]; !ok {
+			d.add(SeverityAdditive, ChangeModelAdded, name, "", "model %s was added", name)
+		}
+	}
+
+	report := DiffReport{Changes: d.changes}
+	if report.Changes == nil {
+		report.Changes = []Change{}
+	}
+	for _, change := range report.Changes {
+		if change.Severity == SeverityBreaking {
+			report.Breaking = true
+			break
+		}
+	}
+	return report
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(severity Severity, kind, model, path, format string, args ...any) {
+	d.changes = append(d.changes, Change{
+		Severity: severity,
+		Kind:     kind,
+		Model:    model,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// compare reports the differences between two schemas found at path within a model. Referenced
+// components are compared once, by name, rather than at every reference.
+func (d *differ) compare(model, path string, base, head *openapi3.SchemaRef) {
+	baseType, headType := describeType(base), describeType(head)
+	if baseType != headType {
+		d.add(SeverityBreaking, ChangeTypeChanged, model, path, "%s changed type from %s to %s", location(model, path), baseType, headType)
+		return
+	}
+	if base.Ref != "" || base.Value == nil || head.Value == nil {
+		return
+	}
+
+	d.compareEnum(model, path, base.Value.Enum, head.Value.Enum)
+	d.compareVariants(model, path, base.Value.OneOf, head.Value.OneOf)
+
+	if base.Value.Items != nil && head.Value.Items != nil {
+		d.compare(model, path+"[]", base.Value.Items, head.Value.Items)
+	}
+	baseValues, headValues := base.Value.AdditionalProperties.Schema, head.Value.AdditionalProperties.Schema
+	if baseValues != nil && headValues != nil {
+		d.compare(model, path+"{}", baseValues, headValues)
+	}
+	d.compareProperties(model, path, base.Value, head.Value)
+}
+
+func (d *differ) compareProperties(model, path string, base, head *openapi3.Schema) {
+	baseRequired, headRequired := toSet(base.Required), toSet(head.Required)
+
+	for _, name := range sortedKeys(base.Properties) {
+		property := join(path, name)
+		headProperty, ok := head.Properties[name]
+		if !ok {
+			d.add(SeverityBreaking, ChangePropertyRemoved, model, property, "%s was removed", location(model, property))
+			continue
+		}
+		switch {
+		case !baseRequired[name] && headRequired[name]:
+			d.add(SeverityBreaking, ChangeRequiredAdded, model, property, "%s is now required", location(model, property))
+		case baseRequired[name] && !headRequired[name]:
+			d.add(SeverityAdditive, ChangeRequiredRemoved, model, property, "%s is no longer required", location(model, property))
+		}
+		d.compare(model, property, base.Properties[name], headProperty)
+	}
+
+	for _, name := range sortedKeys(head.Properties) {
+		if _, ok := base.Properties[name]; ok {
+			continue
+		}
+		property := join(path, name)
+		if headRequired[name] {
+			d.add(SeverityBreaking, ChangeRequiredAdded, model, property, "required property %s was added", location(model, property))
+			continue
+		}
+		d.add(SeverityAdditive, ChangePropertyAdded, model, property, "%s was added", location(model, property))
+	}
+}
+
+func (d *differ) compareEnum(model, path string, base, head []any) {
+	// An enum appearing or disappearing entirely changes the accepted values without removing any
+	// existing value, so only values that both lists could hold are compared.
+	if len(base) == 0 || len(head) == 0 {
+		return
+	}
+	baseValues, headValues := enumSet(base), enumSet(head)
+	for _, value := range sortedKeys(baseValues) {
+		if !headValues[value] {
+			d.add(SeverityBreaking, ChangeEnumRemoved, model, path, "%s no longer allows %q", location(model, path), value)
+		}
+	}
+	for _, value := range sortedKeys(headValues) {
+		if !baseValues[value] {
+			d.add(SeverityAdditive, ChangeEnumAdded, model, path, "%s now allows %q", location(model, path), value)
+		}
+	}
+}
+
+func (d *differ) compareVariants(model, path string, base, head openapi3.SchemaRefs) {
+	baseRefs, headRefs := refSet(base), refSet(head)
+	for _, ref := range sortedKeys(baseRefs) {
+		if !headRefs[ref] {
+			d.add(SeverityBreaking, ChangeVariantRemoved, model, path, "%s no longer accepts %s", location(model, path), ref)
+		}
+	}
+	for _, ref := range sortedKeys(headRefs) {
+		if !baseRefs[ref] {
+			d.add(SeverityAdditive, ChangeVariantAdded, model, path, "%s now accepts %s", location(model, path), ref)
+		}
+	}
+}
+
+// describeType summarises the shape of a schema: the component it references, or its type and format
+func describeType(s *openapi3.SchemaRef) string {
+	if s == nil {
+		return "none"
+	}
+	if s.Ref != "" {
+		return refName(s.Ref)
+	}
+	if s.Value == nil || s.Value.Type == nil || len(*s.Value.Type) == 0 {
+		if s.Value != nil && len(s.Value.OneOf) > 0 {
+			return "oneOf"
+		}
+		return "any"
+	}
+	description := strings.Join(s.Value.Type.Slice(), "|")
+	if s.Value.Format != "" {
+		description += "(" + s.Value.Format + ")"
+	}
+	return description
+}
+
+func componentSchemas(doc *openapi3.T) openapi3.Schemas {
+	if doc == nil || doc.Components == nil {
+		return openapi3.Schemas{}
+	}
+	return doc.Components.Schemas
+}
+
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, "#/components/schemas/")
+}
+
+func refSet(refs openapi3.SchemaRefs) map[string]bool {
+	set := make(map[string]bool)
+	for _, ref := range refs {
+		if ref != nil && ref.Ref != "" {
+			set[refName(ref.Ref)] = true
+		}
+	}
+	return set
+}
+
+func enumSet(values []any) map[string]bool {
+	set := make(map[string]bool)
+	for _, value := range values {
+		set[fmt.Sprint(value)] = true
+	}
+	return set
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func location(model, path string) string {
+	if path == "" {
+		return model
+	}
+	return model + "." + path
+}
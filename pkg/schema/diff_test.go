@@ -0,0 +1,134 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func diffFixture(modify func(schemas openapi3.Schemas)) *openapi3.T {
+	asset := &openapi3.Schema{
+		Type: &openapi3.Types{openapi3.TypeObject},
+		Properties: openapi3.Schemas{
+			"dns":    openapi3.NewStringSchema().NewRef(),
+			"ttl":    openapi3.NewInt64Schema().NewRef(),
+			"status": &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}, Enum: []any{"A", "F"}}},
+			"tags":   openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema()).NewRef(),
+			"parent": &openapi3.SchemaRef{Value: &openapi3.Schema{OneOf: openapi3.SchemaRefs{
+				openapi3.NewSchemaRef("#/components/schemas/asset_wrapper", nil),
+			}}},
+			"schedule": openapi3.NewSchemaRef("#/components/schemas/schedule", nil),
+		},
+		Required: []string{"dns"},
+	}
+	schemas := openapi3.Schemas{
+		"asset":    &openapi3.SchemaRef{Value: asset},
+		"schedule": openapi3.NewObjectSchema().WithProperty("day", openapi3.NewStringSchema()).NewRef(),
+	}
+	if modify != nil {
+		modify(schemas)
+	}
+	return &openapi3.T{Components: &openapi3.Components{Schemas: schemas}}
+}
+
+func TestDiffOpenAPISchemas(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(schemas openapi3.Schemas)
+		expected []Change
+	}{
+		{"unchanged", nil, nil},
+		{"model removed", func(s openapi3.Schemas) { delete(s, "schedule") }, []Change{
+			{SeverityBreaking, ChangeModelRemoved, "schedule", "", "model schedule was removed"},
+		}},
+		{"model added", func(s openapi3.Schemas) { s["port"] = openapi3.NewObjectSchema().NewRef() }, []Change{
+			{SeverityAdditive, ChangeModelAdded, "port", "", "model port was added"},
+		}},
+		{"property removed", func(s openapi3.Schemas) { delete(s["asset"].Value.Properties, "ttl") }, []Change{
+			{SeverityBreaking, ChangePropertyRemoved, "asset", "ttl", "asset.ttl was removed"},
+		}},
+		{"optional property added", func(s openapi3.Schemas) {
+			s["asset"].Value.Properties["comment"] = openapi3.NewStringSchema().NewRef()
+		}, []Change{
+			{SeverityAdditive, ChangePropertyAdded, "asset", "comment", "asset.comment was added"},
+		}},
+		{"required property added", func(s openapi3.Schemas) {
+			s["asset"].Value.Properties["comment"] = openapi3.NewStringSchema().NewRef()
+			s["asset"].Value.Required = []string{"comment", "dns"}
+		}, []Change{
+			{SeverityBreaking, ChangeRequiredAdded, "asset", "comment", "required property asset.comment was added"},
+		}},
+		{"property became required", func(s openapi3.Schemas) { s["asset"].Value.Required = []string{"dns", "ttl"} }, []Change{
+			{SeverityBreaking, ChangeRequiredAdded, "asset", "ttl", "asset.ttl is now required"},
+		}},
+		{"property became optional", func(s openapi3.Schemas) { s["asset"].Value.Required = nil }, []Change{
+			{SeverityAdditive, ChangeRequiredRemoved, "asset", "dns", "asset.dns is no longer required"},
+		}},
+		{"type changed", func(s openapi3.Schemas) { s["asset"].Value.Properties["ttl"] = openapi3.NewStringSchema().NewRef() }, []Change{
+			{SeverityBreaking, ChangeTypeChanged, "asset", "ttl", "asset.ttl changed type from integer(int64) to string"},
+		}},
+		{"item type changed", func(s openapi3.Schemas) {
+			s["asset"].Value.Properties["tags"] = openapi3.NewArraySchema().WithItems(openapi3.NewInt64Schema()).NewRef()
+		}, []Change{
+			{SeverityBreaking, ChangeTypeChanged, "asset", "tags[]", "asset.tags[] changed type from string to integer(int64)"},
+		}},
+		{"reference changed", func(s openapi3.Schemas) {
+			s["asset"].Value.Properties["schedule"] = openapi3.NewSchemaRef("#/components/schemas/weeklyschedule", nil)
+		}, []Change{
+			{SeverityBreaking, ChangeTypeChanged, "asset", "schedule", "asset.schedule changed type from schedule to weeklyschedule"},
+		}},
+		{"referenced model changed", func(s openapi3.Schemas) { s["schedule"].Value.Properties["day"] = openapi3.NewBoolSchema().NewRef() }, []Change{
+			{SeverityBreaking, ChangeTypeChanged, "schedule", "day", "schedule.day changed type from string to boolean"},
+		}},
+		{"enum value removed", func(s openapi3.Schemas) { s["asset"].Value.Properties["status"].Value.Enum = []any{"A"} }, []Change{
+			{SeverityBreaking, ChangeEnumRemoved, "asset", "status", `asset.status no longer allows "F"`},
+		}},
+		{"enum value added", func(s openapi3.Schemas) { s["asset"].Value.Properties["status"].Value.Enum = []any{"A", "F", "D"} }, []Change{
+			{SeverityAdditive, ChangeEnumAdded, "asset", "status", `asset.status now allows "D"`},
+		}},
+		{"wrapper variants changed", func(s openapi3.Schemas) {
+			s["asset"].Value.Properties["parent"].Value.OneOf = openapi3.SchemaRefs{
+				openapi3.NewSchemaRef("#/components/schemas/port_wrapper", nil),
+			}
+		}, []Change{
+			{SeverityBreaking, ChangeVariantRemoved, "asset", "parent", "asset.parent no longer accepts asset_wrapper"},
+			{SeverityAdditive, ChangeVariantAdded, "asset", "parent", "asset.parent now accepts port_wrapper"},
+		}},
+		{"description changed", func(s openapi3.Schemas) { s["asset"].Value.Properties["dns"].Value.Description = "changed" }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := DiffOpenAPISchemas(diffFixture(nil), diffFixture(tt.modify))
+
+			breaking := false
+			for _, change := range tt.expected {
+				breaking = breaking || change.Severity == SeverityBreaking
+			}
+			assert.Equal(t, breaking, report.Breaking)
+			if tt.expected == nil {
+				assert.Empty(t, report.Changes)
+				return
+			}
+			assert.Equal(t, tt.expected, report.Changes)
+		})
+	}
+}
+
+func TestDiffOpenAPISchemas_Generated(t *testing.T) {
+	base, err := GenerateOpenAPISchema()
+	require.NoError(t, err)
+	head, err := GenerateOpenAPISchema()
+	require.NoError(t, err)
+
+	report := DiffOpenAPISchemas(base, head)
+	assert.False(t, report.Breaking)
+	assert.Empty(t, report.Changes)
+
+	delete(head.Components.Schemas, "aduser")
+	report = DiffOpenAPISchemas(base, head)
+	assert.True(t, report.Breaking)
+	assert.Contains(t, report.Changes, Change{SeverityBreaking, ChangeModelRemoved, "aduser", "", "model aduser was removed"})
+}
@@ -0,0 +1,393 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// JSONSchemaDialect is the JSON Schema draft generated documents conform to
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is a JSON Schema (draft 2020-12) document or subschema
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	ContentEncoding      string                 `json:"contentEncoding,omitempty"`
+	Const                any                    `json:"const,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Examples             []any                  `json:"examples,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+	Defs                 map[string]*JSONSchema `json:"$defs,omitempty"`
+}
+
+// GenerateJSONSchema creates a standalone JSON Schema document for the registered model name.
+// Registered types referenced by the model are emitted under $defs, and Wrapper fields such as
+// GraphModelWrapper and TargetWrapper are a oneOf over the registered implementations,
+// discriminated by the wrapper's type property.
+func GenerateJSONSchema(name string) (*JSONSchema, error) {
+	types := registry.Registry.GetAllTypes()
+	typ, ok := types[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("type %q is not registered", name)
+	}
+
+	g := &jsonSchemaGenerator{types: types, defs: map[string]*JSONSchema{}}
+	canonical := canonicalName(typ)
+	doc := g.definition(canonical, typ)
+	doc.Schema = JSONSchemaDialect
+	doc.Title = canonical
+
+	// the root model is the document itself, so references to it point at the root
+	delete(g.defs, canonical)
+	if len(g.defs) > 0 {
+		doc.Defs = g.defs
+	}
+	rewriteRefs(doc, "#/$defs/"+canonical, "#")
+	return doc, nil
+}
+
+// GenerateJSONSchemas creates a JSON Schema document for every registered model, keyed by the
+// model's name. Aliases are not repeated.
+func GenerateJSONSchemas() (map[string]*JSONSchema, error) {
+	docs := map[string]*JSONSchema{}
+	for name, typ := range registry.Registry.GetAllTypes() {
+		if name != canonicalName(typ) {
+			continue
+		}
+		doc, err := GenerateJSONSchema(name)
+		if err != nil {
+			return nil, err
+		}
+		docs[name] = doc
+	}
+	return docs, nil
+}
+
+type jsonSchemaGenerator struct {
+	types map[string]reflect.Type
+	defs  map[string]*JSONSchema
+	// inline tracks unregistered structs being generated, to cut off recursive types
+	inline map[reflect.Type]bool
+}
+
+// definition generates the object schema of a registered type
+func (g *jsonSchemaGenerator) definition(name string, typ reflect.Type) *JSONSchema {
+	if def, ok := g.defs[name]; ok {
+		return def
+	}
+	def := &JSONSchema{}
+	// registered before it is populated, so self references terminate
+	g.defs[name] = def
+
+	*def = *g.object(typ)
+	if model, ok := reflect.New(indirect(typ)).Interface().(registry.Model); ok {
+		def.Description = model.GetDescription()
+	}
+	return def
+}
+
+func (g *jsonSchemaGenerator) ref(typ reflect.Type) *JSONSchema {
+	name := canonicalName(typ)
+	g.definition(name, g.types[name])
+	return &JSONSchema{Ref: "#/$defs/" + name}
+}
+
+// object generates the schema of a struct, flattening embedded structs as encoding/json does
+func (g *jsonSchemaGenerator) object(typ reflect.Type) *JSONSchema {
+	s := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+	required := map[string]struct{}{}
+	g.addFields(s, required, indirect(typ))
+
+	if len(required) > 0 {
+		s.Required = make([]string, 0, len(required))
+		for name := range required {
+			s.Required = append(s.Required, name)
+		}
+		sort.Strings(s.Required)
+	}
+	return s
+}
+
+func (g *jsonSchemaGenerator) addFields(s *JSONSchema, required map[string]struct{}, typ reflect.Type) {
+	var embedded []reflect.Type
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if isFlattened(field) {
+			embedded = append(embedded, indirect(field.Type))
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name, omitempty, include := getJSONInfo(field)
+		if !include {
+			continue
+		}
+
+		prop := g.schemaFor(field.Type)
+		desc, example := getDocs(field)
+		if desc != "" {
+			prop.Description = desc
+		}
+		if example != "" {
+			prop.Examples = []any{exampleValue(field.Type, example)}
+		}
+		if enum := getEnumTag(field); enum != nil {
+			if prop.Items != nil {
+				prop.Items.Enum = enum
+			} else {
+				prop.Enum = enum
+			}
+		}
+		s.Properties[name] = prop
+		if !omitempty {
+			required[name] = struct{}{}
+		}
+	}
+
+	// fields declared directly take precedence over promoted ones, which are never required, as in the OpenAPI schema
+	for _, embeddedType := range embedded {
+		promoted := &JSONSchema{Properties: map[string]*JSONSchema{}}
+		g.addFields(promoted, map[string]struct{}{}, embeddedType)
+		for name, prop := range promoted.Properties {
+			if _, ok := s.Properties[name]; !ok {
+				s.Properties[name] = prop
+			}
+		}
+	}
+}
+
+// isFlattened reports whether encoding/json promotes the fields of an embedded struct
+func isFlattened(field reflect.StructField) bool {
+	if !field.Anonymous || indirect(field.Type).Kind() != reflect.Struct {
+		return false
+	}
+	tag := field.Tag.Get("json")
+	return tag == "" || strings.Split(tag, ",")[0] == ""
+}
+
+func (g *jsonSchemaGenerator) schemaFor(typ reflect.Type) *JSONSchema {
+	if indirect(typ) == rawMessageType {
+		return &JSONSchema{}
+	}
+	if registered(typ, g.types) {
+		return g.ref(typ)
+	}
+	if iface, ok := wrappedInterface(typ); ok {
+		return g.wrapper(iface)
+	}
+
+	t := indirect(typ)
+	switch t.Kind() {
+	case reflect.Interface:
+		return &JSONSchema{}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if marshalsJSON(t) {
+				return &JSONSchema{Type: "string"}
+			}
+			return &JSONSchema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &JSONSchema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		s := &JSONSchema{Type: "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			s.AdditionalProperties = g.schemaFor(t.Elem())
+		}
+		return s
+	case reflect.Struct:
+		if format := getOpenAPIFormat(t); format != "" {
+			return &JSONSchema{Type: "string", Format: format}
+		}
+		if g.inline == nil {
+			g.inline = map[reflect.Type]bool{}
+		}
+		if g.inline[t] {
+			return &JSONSchema{Type: "object"}
+		}
+		g.inline[t] = true
+		defer delete(g.inline, t)
+		return g.object(t)
+	}
+	s := &JSONSchema{Type: getOpenAPIType(t), Format: getOpenAPIFormat(t)}
+	if enum, ok := registry.Registry.GetEnum(t); ok {
+		s.Enum = getEnumValues(enum)
+	}
+	return s
+}
+
+// wrapper generates a discriminated union over the registered implementations of iface
+func (g *jsonSchemaGenerator) wrapper(iface reflect.Type) *JSONSchema {
+	s := &JSONSchema{Type: "object"}
+	for _, member := range implementations(iface, g.types) {
+		discriminator := &JSONSchema{}
+		if len(member.names) == 1 {
+			discriminator.Const = member.names[0]
+		} else {
+			for _, name := range member.names {
+				discriminator.Enum = append(discriminator.Enum, name)
+			}
+		}
+		s.OneOf = append(s.OneOf, &JSONSchema{
+			Properties: map[string]*JSONSchema{
+				"type":  discriminator,
+				"model": g.ref(member.typ),
+			},
+			Required: []string{"model", "type"},
+		})
+	}
+	return s
+}
+
+type implementation struct {
+	typ reflect.Type
+	// names are the canonical name and aliases the type is registered under
+	names []string
+}
+
+// implementations returns the registered types implementing iface, ordered by name
+func implementations(iface reflect.Type, types map[string]reflect.Type) []implementation {
+	byType := map[reflect.Type]*implementation{}
+	for name, typ := range types {
+		if !typ.Implements(iface) {
+			continue
+		}
+		impl, ok := byType[typ]
+		if !ok {
+			impl = &implementation{typ: typ}
+			byType[typ] = impl
+		}
+		impl.names = append(impl.names, name)
+	}
+
+	out := make([]implementation, 0, len(byType))
+	for typ, impl := range byType {
+		canonical := canonicalName(typ)
+		sort.Slice(impl.names, func(i, j int) bool {
+			// the canonical name leads, followed by aliases
+			if (impl.names[i] == canonical) != (impl.names[j] == canonical) {
+				return impl.names[i] == canonical
+			}
+			return impl.names[i] < impl.names[j]
+		})
+		out = append(out, *impl)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].names[0] < out[j].names[0] })
+	return out
+}
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	modelType      = reflect.TypeOf((*registry.Model)(nil)).Elem()
+)
+
+// marshalsJSON reports whether typ writes its own JSON, rather than the JSON encoding/json writes for its kind
+func marshalsJSON(typ reflect.Type) bool {
+	return typ.Implements(marshalerType) || reflect.PointerTo(typ).Implements(marshalerType)
+}
+
+// wrappedInterface returns T for registry.Wrapper[T] and types defined from it,
+// such as GraphModelWrapper and TargetWrapper
+func wrappedInterface(typ reflect.Type) (reflect.Type, bool) {
+	t := indirect(typ)
+	if t.Kind() != reflect.Struct || t.NumField() < 3 {
+		return nil, false
+	}
+	model, ok := t.FieldByName("Model")
+	if !ok || model.Type.Kind() != reflect.Interface || !model.Type.Implements(modelType) {
+		return nil, false
+	}
+	tipe, ok := t.FieldByName("Type")
+	if !ok || tipe.Type.Kind() != reflect.String {
+		return nil, false
+	}
+	if _, ok := t.FieldByName("SkipDefaulting"); !ok {
+		return nil, false
+	}
+	return model.Type, true
+}
+
+// registered reports whether typ, or the type it points to, is a registered model
+func registered(typ reflect.Type, types map[string]reflect.Type) bool {
+	registeredType, ok := types[canonicalName(typ)]
+	return ok && indirect(registeredType) == indirect(typ)
+}
+
+// canonicalName returns the name a registered type is registered under, as opposed to its aliases
+func canonicalName(typ reflect.Type) string {
+	return strings.ToLower(indirect(typ).Name())
+}
+
+func indirect(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// exampleValue converts an example tag to a value of the field's JSON type, falling back to the
+// tag itself when it does not parse
+func exampleValue(typ reflect.Type, example string) any {
+	switch getOpenAPIType(typ) {
+	case "integer":
+		if v, err := strconv.ParseInt(example, 10, 64); err == nil {
+			return v
+		}
+	case "number":
+		if v, err := strconv.ParseFloat(example, 64); err == nil {
+			return v
+		}
+	case "boolean":
+		if v, err := strconv.ParseBool(example); err == nil {
+			return v
+		}
+	case "array", "object":
+		var v any
+		if err := json.Unmarshal([]byte(example), &v); err == nil {
+			return v
+		}
+	}
+	return example
+}
+
+// rewriteRefs replaces references to from with to throughout s
+func rewriteRefs(s *JSONSchema, from, to string) {
+	seen := map[*JSONSchema]bool{}
+	var walk func(*JSONSchema)
+	walk = func(s *JSONSchema) {
+		if s == nil || seen[s] {
+			return
+		}
+		seen[s] = true
+		if s.Ref == from {
+			s.Ref = to
+		}
+		for _, prop := range s.Properties {
+			walk(prop)
+		}
+		for _, def := range s.Defs {
+			walk(def)
+		}
+		for _, option := range s.OneOf {
+			walk(option)
+		}
+		walk(s.Items)
+		walk(s.AdditionalProperties)
+	}
+	walk(s)
+}
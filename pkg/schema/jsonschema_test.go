@@ -0,0 +1,171 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateJSONSchema(t *testing.T) {
+	doc, err := GenerateJSONSchema("Asset")
+	require.NoError(t, err)
+
+	assert.Equal(t, JSONSchemaDialect, doc.Schema)
+	assert.Equal(t, "asset", doc.Title)
+	assert.Equal(t, "object", doc.Type)
+	assert.NotEmpty(t, doc.Description)
+
+	// fields of embedded structs are promoted, as encoding/json does
+	require.Contains(t, doc.Properties, "dns")
+	require.Contains(t, doc.Properties, "class")
+	assert.NotContains(t, doc.Properties, "BaseAsset")
+	assert.Contains(t, doc.Required, "dns")
+	assert.NotContains(t, doc.Required, "secret")
+
+	dns := doc.Properties["dns"]
+	assert.Equal(t, "string", dns.Type)
+	assert.Equal(t, "The DNS name, or group identifier associated with this asset.", dns.Description)
+	assert.Equal(t, []any{"example.com"}, dns.Examples)
+
+	// examples are typed like the field
+	assert.Equal(t, []any{int64(168)}, doc.Properties["ttl"].Examples)
+	assert.Equal(t, []any{false}, doc.Properties["private"].Examples)
+}
+
+func TestGenerateJSONSchema_Wrapper(t *testing.T) {
+	doc, err := GenerateJSONSchema("port")
+	require.NoError(t, err)
+
+	parent := doc.Properties["parent"]
+	require.NotNil(t, parent)
+	assert.Equal(t, "Port parent asset.", parent.Description)
+	require.NotEmpty(t, parent.OneOf)
+
+	graphModels := map[string]bool{}
+	for _, name := range registry.GetTypes[model.GraphModel](registry.Registry) {
+		graphModels[name] = true
+	}
+
+	var asset *JSONSchema
+	for _, option := range parent.OneOf {
+		assert.Equal(t, []string{"model", "type"}, option.Required)
+		discriminator := option.Properties["type"]
+		names := discriminator.Enum
+		if discriminator.Const != nil {
+			names = []any{discriminator.Const}
+		}
+		for _, name := range names {
+			assert.True(t, graphModels[name.(string)], "%s is not a GraphModel", name)
+			delete(graphModels, name.(string))
+		}
+		if discriminator.Const == "asset" {
+			asset = option
+		}
+	}
+	assert.Empty(t, graphModels, "every GraphModel is a wrapper option")
+
+	// the wrapped model references a definition in $defs
+	require.NotNil(t, asset)
+	assert.Equal(t, "#/$defs/asset", asset.Properties["model"].Ref)
+	require.Contains(t, doc.Defs, "asset")
+	assert.Contains(t, doc.Defs["asset"].Properties, "dns")
+
+	// the document's own model is the root rather than a definition
+	assert.NotContains(t, doc.Defs, "port")
+	for _, option := range parent.OneOf {
+		if option.Properties["type"].Const == "port" {
+			assert.Equal(t, "#", option.Properties["model"].Ref)
+		}
+	}
+}
+
+func TestGenerateJSONSchema_References(t *testing.T) {
+	docs, err := GenerateJSONSchemas()
+	require.NoError(t, err)
+	assert.Contains(t, docs, "asset")
+	assert.Contains(t, docs, "adobject")
+	assert.NotContains(t, docs, "aduser", "aliases are not repeated")
+
+	for name, doc := range docs {
+		data, err := json.Marshal(doc)
+		require.NoError(t, err, name)
+
+		// every reference resolves within the document
+		var walk func(s *JSONSchema)
+		walk = func(s *JSONSchema) {
+			if s == nil {
+				return
+			}
+			if s.Ref != "" && s.Ref != "#" {
+				assert.Contains(t, doc.Defs, strings.TrimPrefix(s.Ref, "#/$defs/"), "%s: dangling %s", name, s.Ref)
+			}
+			for _, prop := range s.Properties {
+				walk(prop)
+			}
+			for _, option := range s.OneOf {
+				walk(option)
+			}
+			walk(s.Items)
+		}
+		walk(doc)
+		for _, def := range doc.Defs {
+			walk(def)
+		}
+
+		var decoded map[string]any
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, JSONSchemaDialect, decoded["$schema"])
+	}
+}
+
+func TestGenerateJSONSchema_UnknownType(t *testing.T) {
+	_, err := GenerateJSONSchema("nope")
+	assert.Error(t, err)
+}
+
+func TestJSONSchemaFor(t *testing.T) {
+	type nested struct {
+		Value string `json:"value"`
+	}
+	type recursive struct {
+		Next *recursive `json:"next,omitempty"`
+	}
+	type fixture struct {
+		nested
+		Nested   nested            `json:"nested"`
+		List     []nested          `json:"list"`
+		Bytes    []byte            `json:"bytes"`
+		Raw      json.RawMessage   `json:"raw"`
+		Smart    model.SmartBytes  `json:"smart"`
+		Any      any               `json:"any"`
+		Map      map[string]string `json:"map"`
+		Ints     []int64           `json:"ints"`
+		Recurse  recursive         `json:"recurse"`
+		Ignored  string            `json:"-"`
+		internal string
+	}
+
+	g := &jsonSchemaGenerator{types: registry.Registry.GetAllTypes(), defs: map[string]*JSONSchema{}}
+	s := g.schemaFor(reflect.TypeOf(fixture{}))
+
+	assert.Equal(t, &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{"value": {Type: "string"}}, Required: []string{"value"}}, s.Properties["nested"])
+	assert.Equal(t, s.Properties["nested"], s.Properties["list"].Items)
+	assert.Equal(t, &JSONSchema{Type: "string", ContentEncoding: "base64"}, s.Properties["bytes"])
+	assert.Equal(t, &JSONSchema{}, s.Properties["raw"])
+	assert.Equal(t, &JSONSchema{Type: "string"}, s.Properties["smart"])
+	assert.Contains(t, s.Properties, "value")
+	assert.NotContains(t, s.Required, "value", "promoted fields are not required")
+	assert.Equal(t, &JSONSchema{}, s.Properties["any"])
+	assert.Equal(t, "object", s.Properties["map"].Type)
+	assert.Equal(t, &JSONSchema{Type: "integer", Format: "int64"}, s.Properties["ints"].Items)
+	assert.Equal(t, &JSONSchema{Type: "object"}, s.Properties["recurse"].Properties["next"])
+	assert.NotContains(t, s.Properties, "Ignored")
+	assert.NotContains(t, s.Properties, "internal")
+	assert.Empty(t, g.defs)
+}
@@ -2,7 +2,7 @@ package schema
 
 import (
 	"fmt"
-	"os"
+	"path"
 	"reflect"
 	"sort"
 	"strings"
@@ -40,10 +40,18 @@ func GenerateOpenAPISchema() (*openapi3.T, error) {
 	}
 
 	// Second pass: populate schemas with properties and descriptions.
-	for name, typ := range types {
+	// Names are visited in order so that generated component names are stable.
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g := &openAPIGenerator{doc: doc, types: types, components: make(map[reflect.Type]string)}
+	for _, name := range names {
+		typ := types[name]
 		schemaRef := doc.Components.Schemas[name]
 		openapiSchema := schemaRef.Value
-		requiredFields := make(map[string]struct{})
 
 		// Need to handle potential pointer types from registry
 		if typ.Kind() == reflect.Ptr {
@@ -61,132 +69,275 @@ func GenerateOpenAPISchema() (*openapi3.T, error) {
 		modelWithDesc := modelPtrInstance.(registry.Model) // Type assertion is now safe
 		openapiSchema.Description = modelWithDesc.GetDescription()
 
-		for i := 0; i < typ.NumField(); i++ {
-			field := typ.Field(i)
-			fieldType := field.Type
-
-			if !field.IsExported() {
-				continue
-			}
-
-			// Handle embedded registered types.
-			if field.Anonymous && isRegisteredType(fieldType, types) {
-				registeredTypeName := getRegisteredTypeName(fieldType, types)
-				embeddedSchemaRef, exists := doc.Components.Schemas[registeredTypeName]
-				if !exists || embeddedSchemaRef.Value == nil {
-					fmt.Fprintf(os.Stderr, "Warning: Schema for embedded type %s not found or is nil.\n", registeredTypeName)
-					continue
-				}
+		g.addProperties(openapiSchema, typ)
+	}
 
-				// Copy properties.
-				for embeddedPropName, embeddedPropSchemaRef := range embeddedSchemaRef.Value.Properties {
-					openapiSchema.Properties[embeddedPropName] = embeddedPropSchemaRef
-				}
+	return doc, nil
+}
 
-				// Reflect on the original embedded Go struct for required fields.
-				embeddedGoType := fieldType
-				if embeddedGoType.Kind() == reflect.Ptr {
-					embeddedGoType = embeddedGoType.Elem()
-				}
-				if embeddedGoType.Kind() == reflect.Struct {
-					for j := 0; j < embeddedGoType.NumField(); j++ {
-						originalEmbeddedField := embeddedGoType.Field(j)
-						if !originalEmbeddedField.IsExported() {
-							continue
-						}
-						jsonFieldName, isOmitempty, include := getJSONInfo(originalEmbeddedField)
-						if include && !isOmitempty {
-							requiredFields[jsonFieldName] = struct{}{}
-						}
-					}
-				}
-				continue
-			}
+// openAPIGenerator renders Go types into an OpenAPI document. Registered types are referenced by
+// their registered name; other named structs become components of their own.
+type openAPIGenerator struct {
+	doc        *openapi3.T
+	types      map[string]reflect.Type
+	components map[reflect.Type]string
+}
 
-			// Process regular (non-embedded) fields.
-			fieldName, isOmitempty, include := getJSONInfo(field)
-			if !include {
-				continue
-			}
+// addProperties populates schema with the JSON properties of the struct typ. Fields of embedded
+// structs are promoted as encoding/json promotes them, and fields declared on typ without omitempty are required.
+func (g *openAPIGenerator) addProperties(schema *openapi3.Schema, typ reflect.Type) {
+	requiredFields := make(map[string]struct{})
+	g.collectProperties(schema.Properties, requiredFields, typ)
+
+	// Add required fields to the schema.
+	if len(requiredFields) > 0 {
+		finalRequiredList := make([]string, 0, len(requiredFields))
+		for reqField := range requiredFields {
+			finalRequiredList = append(finalRequiredList, reqField)
+		}
+		sort.Strings(finalRequiredList) // Ensure consistent order
+		schema.Required = finalRequiredList
+	}
+}
 
-			if !isOmitempty {
-				requiredFields[fieldName] = struct{}{}
-			}
+func (g *openAPIGenerator) collectProperties(properties openapi3.Schemas, requiredFields map[string]struct{}, typ reflect.Type) {
+	var embedded []reflect.Type
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
 
-			var fieldSchema *openapi3.Schema
+		// Embedded structs are flattened once the fields declared here are known.
+		if isFlattened(field) {
+			embedded = append(embedded, indirect(field.Type))
+			continue
+		}
 
-			// Create references for non-embedded registered types.
-			if isRegisteredType(fieldType, types) {
-				registeredTypeName := getRegisteredTypeName(fieldType, types)
-				openapiSchema.Properties[fieldName] = &openapi3.SchemaRef{
-					Ref: "#/components/schemas/" + registeredTypeName,
-				}
-				continue
-			}
+		if !field.IsExported() {
+			continue
+		}
 
-			// Handle arrays of registered types.
-			if (fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array) && isRegisteredType(fieldType.Elem(), types) {
-				registeredTypeName := getRegisteredTypeName(fieldType.Elem(), types)
-				fieldSchema = &openapi3.Schema{
-					Type: &openapi3.Types{openapi3.TypeArray},
-					Items: &openapi3.SchemaRef{
-						Ref: "#/components/schemas/" + registeredTypeName,
-					},
-				}
-			} else {
-				// Handle regular fields and primitive arrays.
-				fieldSchema = &openapi3.Schema{
-					Type: &openapi3.Types{getOpenAPIType(fieldType)},
-				}
+		fieldName, isOmitempty, include := getJSONInfo(field)
+		if !include {
+			continue
+		}
 
-				if format := getOpenAPIFormat(fieldType); format != "" {
-					fieldSchema.Format = format
-				}
+		if !isOmitempty {
+			requiredFields[fieldName] = struct{}{}
+		}
 
-				if fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
-					elemType := fieldType.Elem()
-					itemSchema := &openapi3.Schema{
-						Type: &openapi3.Types{getOpenAPIType(elemType)},
-					}
-					if format := getOpenAPIFormat(elemType); format != "" {
-						itemSchema.Format = format
-					}
-					// TODO: Add description/example tags for array items?
-					fieldSchema.Items = &openapi3.SchemaRef{
-						Value: itemSchema,
-					}
-				}
-			}
+		fieldSchemaRef := g.fieldSchema(field.Type)
 
-			// Add description and example from tags if available.
-			if desc := field.Tag.Get("desc"); desc != "" {
+		// Add description, example and enum values from tags if available.
+		if fieldSchema := fieldSchemaRef.Value; fieldSchema != nil {
+			desc, example := getDocs(field)
+			if desc != "" {
 				fieldSchema.Description = desc
 			}
-			if example := field.Tag.Get("example"); example != "" {
+			if example != "" {
 				fieldSchema.Example = example
 			}
+			if enum := getEnumTag(field); enum != nil {
+				if fieldSchema.Items != nil && fieldSchema.Items.Value != nil {
+					fieldSchema.Items.Value.Enum = enum
+				} else {
+					fieldSchema.Enum = enum
+				}
+			}
+		}
+
+		properties[fieldName] = fieldSchemaRef
+	}
 
-			openapiSchema.Properties[fieldName] = &openapi3.SchemaRef{
-				Value: fieldSchema,
+	// Fields declared directly take precedence over promoted ones. Promoted fields are never
+	// required, so that flattening an embedded struct does not make clients require fields
+	// they were never asked for.
+	for _, embeddedType := range embedded {
+		promoted := make(openapi3.Schemas)
+		g.collectProperties(promoted, make(map[string]struct{}), embeddedType)
+		for propName, prop := range promoted {
+			if _, exists := properties[propName]; !exists {
+				properties[propName] = prop
 			}
 		}
+	}
+}
+
+func (g *openAPIGenerator) fieldSchema(fieldType reflect.Type) *openapi3.SchemaRef {
+	// Raw JSON may hold any value.
+	if indirect(fieldType) == rawMessageType {
+		return &openapi3.SchemaRef{Value: &openapi3.Schema{}}
+	}
+
+	// Create references for registered types.
+	if registered(fieldType, g.types) {
+		return &openapi3.SchemaRef{Ref: "#/components/schemas/" + canonicalName(fieldType)}
+	}
 
-		// Add required fields to the schema.
-		if len(requiredFields) > 0 {
-			finalRequiredList := make([]string, 0, len(requiredFields))
-			for reqField := range requiredFields {
-				finalRequiredList = append(finalRequiredList, reqField)
+	// Handle Wrapper[T] fields as a union of the registered implementations of T.
+	if iface, ok := wrappedInterface(fieldType); ok {
+		return &openapi3.SchemaRef{Value: getWrapperSchema(g.doc, iface, g.types)}
+	}
+
+	fieldType = indirect(fieldType)
+	switch fieldType.Kind() {
+	case reflect.Slice, reflect.Array:
+		// encoding/json writes byte slices as base64 strings, unless they marshal themselves
+		if fieldType.Elem().Kind() == reflect.Uint8 {
+			bytesSchema := &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}}
+			if !marshalsJSON(fieldType) {
+				bytesSchema.Format = "byte"
 			}
-			sort.Strings(finalRequiredList) // Ensure consistent order
-			openapiSchema.Required = finalRequiredList
+			return &openapi3.SchemaRef{Value: bytesSchema}
 		}
+		return &openapi3.SchemaRef{Value: &openapi3.Schema{
+			Type:  &openapi3.Types{openapi3.TypeArray},
+			Items: g.fieldSchema(fieldType.Elem()),
+		}}
+	case reflect.Map:
+		mapSchema := &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeObject}}
+		if fieldType.Elem().Kind() != reflect.Interface {
+			mapSchema.AdditionalProperties = openapi3.AdditionalProperties{Schema: g.fieldSchema(fieldType.Elem())}
+		}
+		return &openapi3.SchemaRef{Value: mapSchema}
+	case reflect.Struct:
+		if format := getOpenAPIFormat(fieldType); format != "" {
+			return &openapi3.SchemaRef{Value: &openapi3.Schema{
+				Type:   &openapi3.Types{openapi3.TypeString},
+				Format: format,
+			}}
+		}
+		// Anonymous structs have no name to register a component under.
+		if fieldType.Name() == "" {
+			inline := &openapi3.Schema{
+				Type:       &openapi3.Types{openapi3.TypeObject},
+				Properties: make(openapi3.Schemas),
+			}
+			g.addProperties(inline, fieldType)
+			return &openapi3.SchemaRef{Value: inline}
+		}
+		return &openapi3.SchemaRef{Ref: "#/components/schemas/" + g.component(fieldType)}
 	}
 
-	return doc, nil
+	fieldSchema := &openapi3.Schema{
+		Type:   &openapi3.Types{getOpenAPIType(fieldType)},
+		Format: getOpenAPIFormat(fieldType),
+	}
+	if enum, ok := registry.Registry.GetEnum(fieldType); ok {
+		fieldSchema.Enum = getEnumValues(enum)
+	}
+	return &openapi3.SchemaRef{Value: fieldSchema}
+}
+
+// component returns the name of the component schema for an unregistered struct, generating it
+// on first use. Names follow the registry's lowercase convention, qualified by package on conflict.
+func (g *openAPIGenerator) component(typ reflect.Type) string {
+	if name, ok := g.components[typ]; ok {
+		return name
+	}
+
+	name := strings.ToLower(typ.Name())
+	if _, taken := g.doc.Components.Schemas[name]; taken {
+		name = strings.ToLower(path.Base(typ.PkgPath())) + "_" + name
+	}
+	for i := 2; ; i++ {
+		if _, taken := g.doc.Components.Schemas[name]; !taken {
+			break
+		}
+		name = fmt.Sprintf("%s_%d", strings.ToLower(typ.Name()), i)
+	}
+
+	componentSchema := &openapi3.Schema{
+		Type:       &openapi3.Types{openapi3.TypeObject},
+		Properties: make(openapi3.Schemas),
+	}
+	// Registered before it is populated, so recursive types terminate.
+	g.components[typ] = name
+	g.doc.Components.Schemas[name] = &openapi3.SchemaRef{Value: componentSchema}
+	g.addProperties(componentSchema, typ)
+	return name
 }
 
 // --- Internal Helper Functions --- //
 
+// getEnumTag returns the values of a field's comma-separated enum tag
+func getEnumTag(field reflect.StructField) []any {
+	tag := field.Tag.Get("enum")
+	if tag == "" {
+		return nil
+	}
+	var enum []any
+	for _, value := range strings.Split(tag, ",") {
+		enum = append(enum, strings.TrimSpace(value))
+	}
+	return enum
+}
+
+// getEnumValues converts registered enum constants to their underlying JSON values
+func getEnumValues(values []any) []any {
+	enum := make([]any, 0, len(values))
+	for _, value := range values {
+		v := reflect.ValueOf(value)
+		switch v.Kind() {
+		case reflect.String:
+			enum = append(enum, v.String())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			enum = append(enum, v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			enum = append(enum, v.Uint())
+		default:
+			enum = append(enum, value)
+		}
+	}
+	return enum
+}
+
+// getWrapperSchema renders a Wrapper[T] as a oneOf over a {type, model} variant per registered
+// implementation of T, discriminated by type. Variants are shared components named <type>_wrapper.
+func getWrapperSchema(doc *openapi3.T, iface reflect.Type, types map[string]reflect.Type) *openapi3.Schema {
+	wrapperSchema := &openapi3.Schema{
+		Type: &openapi3.Types{openapi3.TypeObject},
+		Discriminator: &openapi3.Discriminator{
+			PropertyName: "type",
+			Mapping:      openapi3.StringMap{},
+		},
+	}
+
+	for _, impl := range implementations(iface, types) {
+		variantName := impl.names[0] + "_wrapper"
+		variantRef := "#/components/schemas/" + variantName
+
+		if _, exists := doc.Components.Schemas[variantName]; !exists {
+			enum := make([]any, 0, len(impl.names))
+			for _, name := range impl.names {
+				enum = append(enum, name)
+			}
+			doc.Components.Schemas[variantName] = &openapi3.SchemaRef{
+				Value: &openapi3.Schema{
+					Type: &openapi3.Types{openapi3.TypeObject},
+					Properties: openapi3.Schemas{
+						"type": &openapi3.SchemaRef{
+							Value: &openapi3.Schema{
+								Type: &openapi3.Types{openapi3.TypeString},
+								Enum: enum,
+							},
+						},
+						"model": &openapi3.SchemaRef{
+							Ref: "#/components/schemas/" + impl.names[0],
+						},
+					},
+					Required: []string{"model", "type"},
+				},
+			}
+		}
+
+		wrapperSchema.OneOf = append(wrapperSchema.OneOf, &openapi3.SchemaRef{Ref: variantRef})
+		for _, name := range impl.names {
+			wrapperSchema.Discriminator.Mapping[name] = variantRef
+		}
+	}
+
+	return wrapperSchema
+}
+
 func getOpenAPIType(t reflect.Type) string {
 	switch t.Kind() {
 	case reflect.String:
@@ -232,28 +383,9 @@ func getOpenAPIFormat(t reflect.Type) string {
 	return ""
 }
 
-func isRegisteredType(t reflect.Type, types map[string]reflect.Type) bool {
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
-	}
-	for _, registeredType := range types {
-		if t == registeredType {
-			return true
-		}
-	}
-	return false
-}
-
-func getRegisteredTypeName(t reflect.Type, types map[string]reflect.Type) string {
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
-	}
-	for name, registeredType := range types {
-		if t == registeredType {
-			return name
-		}
-	}
-	return ""
+// getDocs returns the documentation of a field from its desc and example tags
+func getDocs(field reflect.StructField) (desc string, example string) {
+	return field.Tag.Get("desc"), field.Tag.Get("example")
 }
 
 func getJSONInfo(field reflect.StructField) (name string, omitempty bool, include bool) {
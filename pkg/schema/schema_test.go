@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateOpenAPISchema_Wrappers(t *testing.T) {
+	doc, err := GenerateOpenAPISchema()
+	require.NoError(t, err)
+
+	job := doc.Components.Schemas["job"].Value
+	require.NotNil(t, job)
+
+	targets := registry.GetTypes[model.Target](registry.Registry)
+	sort.Strings(targets)
+
+	for _, field := range []string{"origin", "target", "parent"} {
+		t.Run(field, func(t *testing.T) {
+			wrapper := job.Properties[field].Value
+			require.NotNil(t, wrapper)
+			require.NotNil(t, wrapper.Discriminator)
+			assert.Equal(t, "type", wrapper.Discriminator.PropertyName)
+			assert.NotEmpty(t, wrapper.Description)
+
+			// every registered Target, including aliases, maps to a variant listed in oneOf
+			var mapped []string
+			for name := range wrapper.Discriminator.Mapping {
+				mapped = append(mapped, name)
+			}
+			sort.Strings(mapped)
+			assert.Equal(t, targets, mapped)
+
+			refs := map[string]bool{}
+			for _, option := range wrapper.OneOf {
+				refs[option.Ref] = true
+			}
+			for _, ref := range wrapper.Discriminator.Mapping {
+				assert.True(t, refs[ref], "%s is not a oneOf option", ref)
+			}
+		})
+	}
+
+	// Context is a list of GraphModelWrapper
+	context := job.Properties["context"].Value
+	require.NotNil(t, context)
+	require.NotNil(t, context.Items.Value.Discriminator)
+	assert.Contains(t, context.Items.Value.Discriminator.Mapping, "port")
+
+	variant := doc.Components.Schemas["asset_wrapper"].Value
+	require.NotNil(t, variant)
+	assert.Equal(t, []string{"model", "type"}, variant.Required)
+	assert.Equal(t, "#/components/schemas/asset", variant.Properties["model"].Ref)
+	assert.Equal(t, []any{"asset"}, variant.Properties["type"].Value.Enum)
+
+	aliased := doc.Components.Schemas["adobject_wrapper"].Value
+	require.NotNil(t, aliased)
+	assert.Contains(t, aliased.Properties["type"].Value.Enum, "aduser")
+}
+
+func TestGenerateOpenAPISchema_NestedTypes(t *testing.T) {
+	doc, err := GenerateOpenAPISchema()
+	require.NoError(t, err)
+
+	// unregistered structs are shared components rather than bare objects
+	schedule := doc.Components.Schemas["capabilityschedule"].Value
+	require.NotNil(t, schedule)
+	assert.Equal(t, "#/components/schemas/weeklyschedule", schedule.Properties["weeklySchedule"].Ref)
+	weekly := doc.Components.Schemas["weeklyschedule"].Value
+	require.NotNil(t, weekly)
+	assert.Equal(t, "#/components/schemas/dayschedule", weekly.Properties["monday"].Ref)
+	assert.Contains(t, doc.Components.Schemas, "dayschedule")
+
+	// typed maps describe their values
+	webpage := doc.Components.Schemas["webpage"].Value
+	sso := webpage.Properties["sso_identified"].Value
+	require.NotNil(t, sso.AdditionalProperties.Schema)
+	assert.Equal(t, "#/components/schemas/ssowebpage", sso.AdditionalProperties.Schema.Ref)
+
+	// registered enums are listed
+	assert.Equal(t, []any{"active", "paused", "expired"}, schedule.Properties["status"].Value.Enum)
+	surface := doc.Components.Schemas["agoracapability"].Value.Properties["surface"].Value
+	assert.Contains(t, surface.Enum, "external")
+
+	// embedded structs are flattened
+	asset := doc.Components.Schemas["asset"].Value
+	assert.NotContains(t, asset.Properties, "BaseAsset")
+	assert.Contains(t, asset.Properties, "class")
+	assert.Contains(t, asset.Required, "dns")
+}
+
+func TestOpenAPIGenerator_FieldSchema(t *testing.T) {
+	type nested struct {
+		Value string `json:"value"`
+	}
+	type recursive struct {
+		Next *recursive `json:"next,omitempty"`
+	}
+	type fixture struct {
+		nested
+		Nested  nested            `json:"nested"`
+		Map     map[string]nested `json:"map"`
+		Any     map[string]any    `json:"any"`
+		Kind    string            `json:"kind" enum:"a, b"`
+		Kinds   []string          `json:"kinds" enum:"a,b"`
+		Recurse recursive         `json:"recurse"`
+		Inline  struct{ X int64 } `json:"inline"`
+		Bytes   []byte            `json:"bytes,omitempty"`
+		Raw     json.RawMessage   `json:"raw,omitempty"`
+		Smart   model.SmartBytes  `json:"smart,omitempty"`
+	}
+
+	doc := &openapi3.T{Components: &openapi3.Components{Schemas: make(openapi3.Schemas)}}
+	g := &openAPIGenerator{doc: doc, types: registry.Registry.GetAllTypes(), components: make(map[reflect.Type]string)}
+	s := &openapi3.Schema{Properties: make(openapi3.Schemas)}
+	g.addProperties(s, reflect.TypeOf(fixture{}))
+
+	assert.Equal(t, "#/components/schemas/nested", s.Properties["nested"].Ref)
+	assert.Equal(t, "#/components/schemas/nested", s.Properties["map"].Value.AdditionalProperties.Schema.Ref)
+	assert.Nil(t, s.Properties["any"].Value.AdditionalProperties.Schema)
+	assert.Equal(t, []any{"a", "b"}, s.Properties["kind"].Value.Enum)
+	assert.Equal(t, []any{"a", "b"}, s.Properties["kinds"].Value.Items.Value.Enum)
+	assert.Equal(t, "#/components/schemas/recursive", s.Properties["recurse"].Ref)
+	assert.Equal(t, "#/components/schemas/recursive", doc.Components.Schemas["recursive"].Value.Properties["next"].Ref)
+	assert.Contains(t, s.Properties["inline"].Value.Properties, "X")
+	assert.Equal(t, &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}, Format: "byte"}, s.Properties["bytes"].Value)
+	assert.Equal(t, &openapi3.Schema{}, s.Properties["raw"].Value)
+	assert.Equal(t, &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}}, s.Properties["smart"].Value)
+	// promoted fields are not required
+	assert.Contains(t, s.Properties, "value")
+	assert.Equal(t, []string{"any", "inline", "kind", "kinds", "map", "nested", "recurse"}, s.Required)
+}
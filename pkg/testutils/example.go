@@ -0,0 +1,179 @@
+// Package testutils builds fixtures for tests of registered models. The example of every registered
+// model is round-tripped through JSON, DynamoDB attribute values and gob in this package's tests,
+// so an example tag that does not fit its field, or a field that does not survive a codec, fails
+// them.
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+
+	"github.com/praetorian-inc/tabularium/pkg/modellint"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+)
+
+// Example returns an instance of T populated from its example tags, as ExampleOf does. It panics
+// if T is not a pointer to a struct, or if an example does not parse.
+func Example[T registry.Model]() T {
+	model, err := example(reflect.TypeFor[T]())
+	if err != nil {
+		panic(err)
+	}
+	return model.(T)
+}
+
+// ExampleOf returns an instance of the model registered as name, with every serialized field that
+// has an example tag set to its example, including those of embedded and nested structs. Fields
+// without examples keep their defaults. The instance is then decoded from its JSON like a payload
+// would be, so its hooks have run and may have replaced examples with derived values.
+func ExampleOf(name string) (registry.Model, error) {
+	tipe, ok := registry.Registry.GetType(name)
+	if !ok {
+		return nil, fmt.Errorf("model %q is not registered", name)
+	}
+	return example(tipe)
+}
+
+func example(tipe reflect.Type) (registry.Model, error) {
+	if tipe.Kind() != reflect.Pointer || tipe.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a pointer to a struct", tipe)
+	}
+
+	populated := reflect.New(tipe.Elem())
+	allocateEmbedded(populated.Elem())
+	populated.Interface().(registry.Model).Defaulted()
+	if err := setExamples(populated.Elem()); err != nil {
+		return nil, err
+	}
+	data, err := payload(populated.Interface(), tipe)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s: %w", tipe, err)
+	}
+
+	model := reflect.New(tipe.Elem())
+	allocateEmbedded(model.Elem())
+	if err := registry.UnmarshalModel(data, model.Interface().(registry.Model)); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", tipe, err)
+	}
+	return model.Interface().(registry.Model), nil
+}
+
+// derivedFields are the BaseAsset fields that the hooks of Assetlike models derive from fields of their
+// own. A payload that holds them takes precedence over those fields, so the examples of BaseAsset would
+// replace the model's own examples.
+var derivedFields = []string{
+	// the group field of the model, such as Asset.DNS or AWSResource.AccountRef
+	"group",
+	// the identifier field of the model, such as Asset.Name or WebApplication.PrimaryURL
+	"identifier",
+}
+
+// payload marshals the populated model, leaving out the derived fields of Assetlike models whose hooks
+// set them when they are missing
+func payload(populated any, tipe reflect.Type) ([]byte, error) {
+	data, err := json.Marshal(populated)
+	if err != nil {
+		return nil, err
+	}
+	assetlike, ok := registry.Registry.GetInterface("Assetlike")
+	if !ok || !tipe.Implements(assetlike) {
+		return data, nil
+	}
+
+	var props map[string]any
+	if err := json.Unmarshal(data, &props); err != nil {
+		return nil, err
+	}
+	without := maps.Clone(props)
+	for _, field := range derivedFields {
+		delete(without, field)
+	}
+	derived, err := decodedProps(without, tipe)
+	if err != nil {
+		// models such as Generic hold no fields to derive them from, and reject payloads without them
+		return data, nil
+	}
+	for _, field := range derivedFields {
+		if value, _ := derived[field].(string); value != "" {
+			delete(props, field)
+		}
+	}
+	return json.Marshal(props)
+}
+
+// decodedProps decodes props into a model of type tipe, running its hooks, and returns the properties
+// of the result
+func decodedProps(props map[string]any, tipe reflect.Type) (map[string]any, error) {
+	data, err := json.Marshal(props)
+	if err != nil {
+		return nil, err
+	}
+	model := reflect.New(tipe.Elem())
+	allocateEmbedded(model.Elem())
+	if err := registry.UnmarshalModel(data, model.Interface().(registry.Model)); err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(model.Interface()); err != nil {
+		return nil, err
+	}
+	var decoded map[string]any
+	return decoded, json.Unmarshal(data, &decoded)
+}
+
+// setExamples sets each serialized field of the struct v to its example, descending into embedded
+// and nested structs
+func setExamples(v reflect.Value) error {
+	tipe := v.Type()
+	for i := 0; i < tipe.NumField(); i++ {
+		field, value := tipe.Field(i), v.Field(i)
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "-" || !value.CanSet() {
+			continue
+		}
+
+		example, ok := field.Tag.Lookup("example")
+		if !ok {
+			inner := value
+			if inner.Kind() == reflect.Pointer && !inner.IsNil() {
+				inner = inner.Elem()
+			}
+			if inner.Kind() == reflect.Struct {
+				if err := setExamples(inner); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		parsed, err := modellint.ParseExample(example, field.Type)
+		if err != nil {
+			return fmt.Errorf("%s.%s: example %q: %w", tipe, field.Name, example, err)
+		}
+		value.Set(parsed)
+	}
+	return nil
+}
+
+// allocateEmbedded allocates the nil embedded struct pointers of the struct v, such as the
+// *BaseRelationship of relationships, so that the model can be defaulted
+func allocateEmbedded(v reflect.Value) {
+	tipe := v.Type()
+	for i := 0; i < tipe.NumField(); i++ {
+		field, value := tipe.Field(i), v.Field(i)
+		if !field.Anonymous || !value.CanSet() {
+			continue
+		}
+		if value.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct && value.IsNil() {
+			value.Set(reflect.New(field.Type.Elem()))
+		}
+		if value.Kind() == reflect.Pointer && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Struct {
+			allocateEmbedded(value)
+		}
+	}
+}
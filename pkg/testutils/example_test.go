@@ -0,0 +1,197 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/praetorian-inc/tabularium/pkg/cbor"
+	"github.com/praetorian-inc/tabularium/pkg/model/model"
+	"github.com/praetorian-inc/tabularium/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exampleDetails struct {
+	Port int `json:"port" example:"443"`
+}
+
+type exampleModel struct {
+	registry.BaseModel
+	Name     string            `json:"name" example:"example"`
+	Key      string            `json:"key" example:"ignored"`
+	Tags     []string          `json:"tags" example:"[\"a\", \"b\"]"`
+	Labels   map[string]string `json:"labels" example:"{\"env\": \"prod\"}"`
+	Score    *float64          `json:"score" example:"0.5"`
+	Seen     time.Time         `json:"seen" example:"2023-10-27T10:00:00Z"`
+	Details  exampleDetails    `json:"details"`
+	Status   string            `json:"status"`
+	Internal string            `json:"-" example:"internal"`
+}
+
+func (m *exampleModel) GetDescription() string { return "A model built from examples" }
+func (m *exampleModel) Defaulted()             { m.Status = "active" }
+func (m *exampleModel) GetHooks() []registry.Hook {
+	return []registry.Hook{{Call: func() error {
+		m.Key = "#example#" + m.Name
+		return nil
+	}}}
+}
+
+func TestExample(t *testing.T) {
+	score := 0.5
+	expected := &exampleModel{
+		Name:    "example",
+		Key:     "#example#example",
+		Tags:    []string{"a", "b"},
+		Labels:  map[string]string{"env": "prod"},
+		Score:   &score,
+		Seen:    time.Date(2023, 10, 27, 10, 0, 0, 0, time.UTC),
+		Details: exampleDetails{Port: 443},
+		Status:  "active",
+	}
+	assert.Equal(t, expected, Example[*exampleModel]())
+}
+
+func TestExampleOf(t *testing.T) {
+	asset, err := ExampleOf("asset")
+	require.NoError(t, err)
+	require.IsType(t, &model.Asset{}, asset)
+	assert.Equal(t, "#asset#example.com#169.254.169.254", asset.(*model.Asset).Key)
+	// group and identifier are derived from the asset's own fields, the others keep their examples
+	assert.Equal(t, "example.com", asset.(*model.Asset).BaseAsset.Group)
+	assert.Equal(t, "169.254.169.254", asset.(*model.Asset).BaseAsset.Identifier)
+	generic := Example[*model.Generic]()
+	assert.Equal(t, "dns", generic.BaseAsset.Group)
+	assert.Equal(t, "name", generic.BaseAsset.Identifier)
+
+	port := Example[*model.Port]()
+	assert.Equal(t, "#asset#example.com#10.0.0.1", port.Parent.Model.GetKey())
+
+	_, err = ExampleOf("missing")
+	assert.Error(t, err)
+}
+
+// TestExamples_RoundTrip decodes the example of every registered model from its own JSON, DynamoDB
+// attribute values and gob encodings
+func TestExamples_RoundTrip(t *testing.T) {
+	seen := map[reflect.Type]bool{}
+	var names []string
+	for name, tipe := range registry.Registry.GetAllTypes() {
+		if !seen[tipe] {
+			seen[tipe] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			example, err := ExampleOf(name)
+			require.NoError(t, err)
+			expected, err := json.Marshal(example)
+			require.NoError(t, err)
+
+			fromJSON := newModel(t, name)
+			require.NoError(t, json.Unmarshal(expected, fromJSON))
+			assertSameJSON(t, expected, fromJSON, "json")
+
+			// fields tagged dynamodbav:"-" are not stored
+			stored := newModel(t, name)
+			require.NoError(t, json.Unmarshal(expected, stored))
+			clearFields(reflect.ValueOf(stored).Elem(), "dynamodbav")
+			item, err := attributevalue.MarshalMap(example)
+			require.NoError(t, err)
+			fromDynamo := newModel(t, name)
+			require.NoError(t, attributevalue.UnmarshalMap(item, fromDynamo))
+			assert.Equal(t, normalize(t, stored), normalize(t, fromDynamo), "dynamodb")
+
+			// gob does not tell zero values from pointers to them, or empty slices from nil ones
+			var buf bytes.Buffer
+			require.NoError(t, gob.NewEncoder(&buf).Encode(example))
+			fromGob := newModel(t, name)
+			require.NoError(t, gob.NewDecoder(&buf).Decode(fromGob))
+			assert.Equal(t, normalize(t, example), normalize(t, fromGob), "gob")
+
+			data, err := cbor.Marshal(example)
+			require.NoError(t, err)
+			fromCBOR := newModel(t, name)
+			require.NoError(t, cbor.Unmarshal(data, fromCBOR))
+			assertSameJSON(t, expected, fromCBOR, "cbor")
+		})
+	}
+}
+
+func newModel(t *testing.T, name string) registry.Model {
+	m, ok := registry.Registry.MakeType(name)
+	require.True(t, ok)
+	return m
+}
+
+// normalize returns the JSON of m without its zero and empty values
+func normalize(t *testing.T, m registry.Model) any {
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	var value any
+	require.NoError(t, json.Unmarshal(data, &value))
+	return dropZero(value)
+}
+
+func dropZero(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if item = dropZero(item); item == nil {
+				delete(v, key)
+			} else {
+				v[key] = item
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []any:
+		if len(v) == 0 {
+			return nil
+		}
+		for i, item := range v {
+			v[i] = dropZero(item)
+		}
+	case string, float64, bool:
+		if reflect.ValueOf(v).IsZero() {
+			return nil
+		}
+	}
+	return value
+}
+
+// clearFields zeroes the fields of the struct v tagged `tag:"-"`, descending into nested structs
+func clearFields(v reflect.Value, tag string) {
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		if !value.CanSet() {
+			continue
+		}
+		if field.Tag.Get(tag) == "-" {
+			value.SetZero()
+			continue
+		}
+		if value.Kind() == reflect.Pointer && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Struct {
+			clearFields(value, tag)
+		}
+	}
+}
+
+func assertSameJSON(t *testing.T, expected []byte, m registry.Model, codec string) {
+	actual, err := json.Marshal(m)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(actual), codec)
+}